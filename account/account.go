@@ -0,0 +1,208 @@
+package account
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+	"github.com/rutesun/reservation/exception"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	maxNameLength     = 100
+	minPasswordLength = 8
+	// bcrypt 는 72 byte 이후를 무시하므로 그보다 긴 비밀번호는 받지 않음
+	maxPasswordLength = 72
+)
+
+// Role 은 사용자의 역할
+// Admin 은 모든 작업과 사용자 역할 변경, FacilityManager 는 회의실 관리와 모든 예약 관리,
+// Member 는 자신의 예약, Guest 는 조회만 할 수 있음
+type Role string
+
+const (
+	Admin           Role = "admin"
+	FacilityManager Role = "facility_manager"
+	Member          Role = "member"
+	Guest           Role = "guest"
+)
+
+func (r Role) valid() bool {
+	switch r {
+	case Admin, FacilityManager, Member, Guest:
+		return true
+	}
+	return false
+}
+
+// User 는 로그인한 사용자. Name 이 예약의 사용자(user_name) 로 쓰임
+// Groups 는 group 으로 제한된 회의실을 예약할 수 있는 group 이름 목록
+// PasswordHash 는 저장소에서 읽을 때만 채움
+type User struct {
+	ID           int64     `json:"id"`
+	Name         string    `json:"name"`
+	Role         Role      `json:"role"`
+	Groups       []string  `json:"groups"`
+	CreatedAt    time.Time `json:"createdAt"`
+	PasswordHash string    `json:"-"`
+}
+
+// InGroup 은 사용자가 group 에 속하는지 확인
+func (u *User) InGroup(group string) bool {
+	for _, g := range u.Groups {
+		if g == group {
+			return true
+		}
+	}
+	return false
+}
+
+// Anonymous 는 로그인하지 않은 사용자
+func Anonymous() *User {
+	return &User{Role: Guest, Groups: []string{}}
+}
+
+type contextKey struct{}
+
+// WithUser 는 요청한 사용자를 ctx 에 담음
+func WithUser(ctx context.Context, user *User) context.Context {
+	return context.WithValue(ctx, contextKey{}, user)
+}
+
+// FromContext 는 ctx 에 담긴 요청한 사용자
+// CLI 처럼 요청한 사용자 없이 내부에서 호출하면 false
+func FromContext(ctx context.Context) (*User, bool) {
+	user, ok := ctx.Value(contextKey{}).(*User)
+	return user, ok
+}
+
+type accountRepository interface {
+	CreateUser(ctx context.Context, name, passwordHash string, now time.Time) (int64, error)
+	FindUser(ctx context.Context, name string) (*User, error)
+	UpdateUser(ctx context.Context, user *User) error
+	CreateToken(ctx context.Context, userID int64, tokenHash string, expiresAt time.Time) error
+	FindUserByToken(ctx context.Context, tokenHash string, now time.Time) (*User, error)
+	DeleteToken(ctx context.Context, tokenHash string) error
+}
+
+type Service struct {
+	account accountRepository
+	// tokenTTL 은 로그인 token 의 유효 기간
+	tokenTTL time.Duration
+}
+
+func New(account accountRepository, tokenTTL time.Duration) *Service {
+	return &Service{account: account, tokenTTL: tokenTTL}
+}
+
+// SignUp 은 사용자를 등록하며 이미 있는 이름이면 exception.UserExists 를 반환
+func (s *Service) SignUp(ctx context.Context, name, password string) (*User, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxNameLength {
+		return nil, errors.WithStack(exception.Invalid("name", "이름은 1~%d 자입니다", maxNameLength))
+	}
+	if len(password) < minPasswordLength || len(password) > maxPasswordLength {
+		return nil, errors.WithStack(exception.Invalid("password", "비밀번호는 %d~%d byte 입니다", minPasswordLength, maxPasswordLength))
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	now := time.Now()
+	id, err := s.account.CreateUser(ctx, name, string(hash), now)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &User{ID: id, Name: name, Role: Member, Groups: []string{}, CreatedAt: now}, nil
+}
+
+// Update 는 사용자의 역할과 group 을 바꾸며 Admin 만 할 수 있음
+// 요청한 사용자가 없는 내부 호출(CLI) 은 첫 Admin 을 만들 때 사용
+func (s *Service) Update(ctx context.Context, name string, role Role, groups []string) (*User, error) {
+	if actor, ok := FromContext(ctx); ok && actor.Role != Admin {
+		return nil, errors.WithStack(exception.Forbidden.WithDetail("관리자만 사용자의 역할을 바꿀 수 있습니다"))
+	}
+	if !role.valid() {
+		return nil, errors.WithStack(exception.Invalid("role", "role 은 admin, facility_manager, member, guest 중 하나입니다"))
+	}
+
+	user, err := s.account.FindUser(ctx, strings.TrimSpace(name))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	user.Role, user.Groups = role, normalizeGroups(groups)
+	if err := s.account.UpdateUser(ctx, user); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	user.PasswordHash = ""
+	return user, nil
+}
+
+// normalizeGroups 는 group 이름의 공백과 중복을 제거하여 정렬
+func normalizeGroups(groups []string) []string {
+	seen := make(map[string]bool)
+	normalized := []string{}
+	for _, g := range groups {
+		g = strings.TrimSpace(g)
+		if g == "" || seen[g] {
+			continue
+		}
+		seen[g] = true
+		normalized = append(normalized, g)
+	}
+	sort.Strings(normalized)
+	return normalized
+}
+
+// Login 은 이름과 비밀번호를 확인하고 Authenticate 에 쓸 token 을 발급
+// 없는 이름과 틀린 비밀번호는 구분하지 않고 exception.Unauthorized 를 반환
+func (s *Service) Login(ctx context.Context, name, password string) (string, *User, error) {
+	user, err := s.account.FindUser(ctx, strings.TrimSpace(name))
+	if errors.Cause(err) == exception.UserNotFound {
+		return "", nil, errors.WithStack(exception.Unauthorized.WithDetail("이름 or 비밀번호가 맞지 않습니다"))
+	} else if err != nil {
+		return "", nil, errors.WithStack(err)
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return "", nil, errors.WithStack(exception.Unauthorized.WithDetail("이름 or 비밀번호가 맞지 않습니다"))
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", nil, errors.WithStack(err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+	if err := s.account.CreateToken(ctx, user.ID, hashToken(token), time.Now().Add(s.tokenTTL)); err != nil {
+		return "", nil, errors.WithStack(err)
+	}
+	user.PasswordHash = ""
+	return token, user, nil
+}
+
+// Authenticate 는 token 의 사용자를 찾으며 없거나 만료된 token 이면 exception.Unauthorized 를 반환
+func (s *Service) Authenticate(ctx context.Context, token string) (*User, error) {
+	user, err := s.account.FindUserByToken(ctx, hashToken(token), time.Now())
+	if errors.Cause(err) == exception.UserNotFound {
+		return nil, errors.WithStack(exception.Unauthorized.WithDetail("만료되었거나 잘못된 token 입니다"))
+	}
+	return user, errors.WithStack(err)
+}
+
+// Logout 은 token 을 더 이상 쓸 수 없게 함
+func (s *Service) Logout(ctx context.Context, token string) error {
+	return errors.WithStack(s.account.DeleteToken(ctx, hashToken(token)))
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,82 @@
+package account_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rutesun/reservation/account"
+	"github.com/rutesun/reservation/exception"
+	"github.com/rutesun/reservation/memory"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestService(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.New()
+	s := account.New(repo, time.Hour)
+
+	user, err := s.SignUp(ctx, " Ted ", "password1")
+	assert.NoError(t, err)
+	assert.Equal(t, "Ted", user.Name)
+
+	_, err = s.SignUp(ctx, "Ted", "password2")
+	assert.Equal(t, exception.UserExists, errors.Cause(err))
+	_, err = s.SignUp(ctx, "Amy", "short")
+	assert.Equal(t, exception.InvalidRequest, errors.Cause(err))
+
+	_, _, err = s.Login(ctx, "Ted", "wrong-password")
+	assert.Equal(t, exception.Unauthorized, errors.Cause(err))
+	_, _, err = s.Login(ctx, "Amy", "password1")
+	assert.Equal(t, exception.Unauthorized, errors.Cause(err))
+
+	token, logged, err := s.Login(ctx, "Ted", "password1")
+	assert.NoError(t, err)
+	assert.Equal(t, user.ID, logged.ID)
+	assert.Empty(t, logged.PasswordHash)
+
+	found, err := s.Authenticate(ctx, token)
+	assert.NoError(t, err)
+	assert.Equal(t, "Ted", found.Name)
+
+	_, err = s.Authenticate(ctx, token+"x")
+	assert.Equal(t, exception.Unauthorized, errors.Cause(err))
+
+	assert.NoError(t, s.Logout(ctx, token))
+	_, err = s.Authenticate(ctx, token)
+	assert.Equal(t, exception.Unauthorized, errors.Cause(err))
+
+	expired := account.New(repo, -time.Second)
+	token, _, err = expired.Login(ctx, "Ted", "password1")
+	assert.NoError(t, err)
+	_, err = expired.Authenticate(ctx, token)
+	assert.Equal(t, exception.Unauthorized, errors.Cause(err))
+}
+
+func TestService_Update(t *testing.T) {
+	ctx := context.Background()
+	s := account.New(memory.New(), time.Hour)
+	_, err := s.SignUp(ctx, "Ted", "password1")
+	assert.NoError(t, err)
+
+	user, err := s.Update(ctx, "Ted", account.FacilityManager, []string{" design", "dev", "design", ""})
+	assert.NoError(t, err)
+	assert.Equal(t, account.FacilityManager, user.Role)
+	assert.Equal(t, []string{"design", "dev"}, user.Groups)
+
+	_, err = s.Update(ctx, "Ted", account.Role("owner"), nil)
+	assert.Equal(t, exception.InvalidRequest, errors.Cause(err))
+	_, err = s.Update(ctx, "Amy", account.Member, nil)
+	assert.Equal(t, exception.UserNotFound, errors.Cause(err))
+
+	member := account.WithUser(ctx, &account.User{Name: "Amy", Role: account.Member})
+	_, err = s.Update(member, "Ted", account.Admin, nil)
+	assert.Equal(t, exception.Forbidden, errors.Cause(err))
+
+	admin := account.WithUser(ctx, &account.User{Name: "Amy", Role: account.Admin})
+	user, err = s.Update(admin, "Ted", account.Guest, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, account.Guest, user.Role)
+	assert.Empty(t, user.Groups)
+}
@@ -0,0 +1,222 @@
+package calendar
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/rutesun/reservation/reservation"
+)
+
+const (
+	ContentType = "text/calendar; charset=utf-8"
+
+	prodID      = "-//rutesun//reservation//KO"
+	utcFormat   = "20060102T150405Z"
+	localFormat = "20060102T150405"
+	// maxLine 은 RFC 5545 에서 정한 한 줄의 최대 길이(octet). 넘으면 다음 줄로 접음
+	maxLine = 75
+)
+
+// Write 는 일정을 iCalendar(RFC 5545) 형식으로 씀
+// 반복 예약은 RRULE 을 가진 VEVENT 하나로 쓰고 취소된 회차는 EXDATE, 변경된 회차는 RECURRENCE-ID 로 씀
+// loc 이 이름 있는 시간대(ex: Asia/Seoul) 이면 TZID 로 써서 일광 절약 시간이 바뀌어도 같은 시각으로 반복되고, 아니면 UTC 로 씀
+func Write(w io.Writer, cal *reservation.Calendar, loc *time.Location, now time.Time) error {
+	e := &encoder{w: bufio.NewWriter(w), loc: loc, stamp: now.UTC().Format(utcFormat)}
+	if loc == nil || loc == time.UTC || loc.String() == "Local" {
+		e.loc = time.UTC
+	}
+
+	e.line("BEGIN:VCALENDAR")
+	e.line("VERSION:2.0")
+	e.line("PRODID:" + prodID)
+	e.line("CALSCALE:GREGORIAN")
+	e.line("METHOD:PUBLISH")
+	e.line("X-WR-CALNAME:" + escape(cal.Name))
+	if e.loc != time.UTC {
+		e.line("X-WR-TIMEZONE:" + e.loc.String())
+	}
+
+	for _, detail := range cal.Reservations {
+		e.event(reservationUID(detail.ID), detail, nil, "")
+	}
+	for _, series := range cal.Series {
+		e.series(series)
+	}
+
+	e.line("END:VCALENDAR")
+	if e.err != nil {
+		return e.err
+	}
+	return e.w.Flush()
+}
+
+func reservationUID(id int64) string {
+	return fmt.Sprintf("reservation-%d@reservation", id)
+}
+
+func seriesUID(id int64) string {
+	return fmt.Sprintf("series-%d@reservation", id)
+}
+
+type encoder struct {
+	w     *bufio.Writer
+	loc   *time.Location
+	stamp string
+	err   error
+}
+
+// series 는 처음 남아 있는 (변경되지 않은) 회차를 DTSTART 로 하여 규칙을 다시 펼친 뒤 저장된 회차와 맞춰 봄
+// 규칙으로 계산한 시간과 저장된 회차가 다르면(ex: 다른 시간대로 만든 반복 예약) 회차마다 따로 씀
+func (e *encoder) series(series *reservation.Series) {
+	var first *reservation.Detail
+	for _, o := range series.Occurrences {
+		if !o.Exception {
+			first = o
+			break
+		}
+	}
+
+	expected, rule, ok := expand(series, first, e.loc)
+	if !ok {
+		for _, o := range series.Occurrences {
+			e.event(reservationUID(o.ID), o, nil, "")
+		}
+		return
+	}
+
+	byOccurrence := make(map[int]*reservation.Detail)
+	for _, o := range series.Occurrences {
+		byOccurrence[o.Occurrence] = o
+	}
+
+	excluded := []time.Time{}
+	overrides := []*reservation.Detail{}
+	overridden := []time.Time{}
+	for i, slot := range expected {
+		o, ok := byOccurrence[first.Occurrence+i]
+		switch {
+		case !ok:
+			excluded = append(excluded, slot.Start)
+		case o.Exception:
+			overrides = append(overrides, o)
+			overridden = append(overridden, slot.Start)
+		}
+	}
+
+	uid := seriesUID(series.ID)
+	master := *first
+	master.Memo = series.Memo
+	e.event(uid, &master, excluded, rule)
+	for i, o := range overrides {
+		e.line("BEGIN:VEVENT")
+		e.line("UID:" + uid)
+		e.line("RECURRENCE-ID" + e.time(overridden[i]))
+		e.body(o)
+		e.line("END:VEVENT")
+	}
+
+	// 규칙의 첫 회차보다 앞선 변경된 회차는 반복에 속하지 않으므로 따로 씀
+	for _, o := range series.Occurrences {
+		if o.Occurrence < first.Occurrence {
+			e.event(reservationUID(o.ID), o, nil, "")
+		}
+	}
+}
+
+// expand 는 first 회차부터 남은 규칙과 그 규칙으로 계산한 회차 시간을 반환
+// 저장된 회차가 계산한 시간과 모두 같아야 ok
+func expand(series *reservation.Series, first *reservation.Detail, loc *time.Location) ([]reservation.Slot, string, bool) {
+	if first == nil {
+		return nil, "", false
+	}
+	rule, err := reservation.ParseRule(series.Rule, loc)
+	if err != nil {
+		return nil, "", false
+	}
+	if rule.Count > 0 {
+		rule.Count -= first.Occurrence - 1
+	}
+	expected, err := rule.Expand(first.Start, first.End)
+	if err != nil {
+		return nil, "", false
+	}
+
+	for _, o := range series.Occurrences {
+		i := o.Occurrence - first.Occurrence
+		if o.Exception || i < 0 {
+			continue
+		}
+		if i >= len(expected) || !expected[i].Start.Equal(o.Start) || !expected[i].End.Equal(o.End) {
+			return nil, "", false
+		}
+	}
+	return expected, rule.String(), true
+}
+
+func (e *encoder) event(uid string, detail *reservation.Detail, excluded []time.Time, rule string) {
+	e.line("BEGIN:VEVENT")
+	e.line("UID:" + uid)
+	e.body(detail)
+	if rule != "" {
+		e.line("RRULE:" + rule)
+	}
+	for _, t := range excluded {
+		e.line("EXDATE" + e.time(t))
+	}
+	e.line("END:VEVENT")
+}
+
+func (e *encoder) body(detail *reservation.Detail) {
+	e.line("DTSTAMP:" + e.stamp)
+	e.line("DTSTART" + e.time(detail.Start))
+	e.line("DTEND" + e.time(detail.End))
+	e.line("SUMMARY:" + escape(fmt.Sprintf("%s (%s)", detail.Room.Name, detail.User)))
+	e.line("LOCATION:" + escape(detail.Room.Name))
+	// 승인 대기 중인 예약은 일정 앱에서 미확정으로 표시
+	if detail.Status == reservation.StatusPending {
+		e.line("STATUS:TENTATIVE")
+	}
+	if detail.Memo != "" {
+		e.line("DESCRIPTION:" + escape(detail.Memo))
+	}
+}
+
+// time 은 ":20180807T010000Z" or ";TZID=Asia/Seoul:20180807T100000" 형식으로 반환
+func (e *encoder) time(t time.Time) string {
+	if e.loc == time.UTC {
+		return ":" + t.UTC().Format(utcFormat)
+	}
+	return ";TZID=" + e.loc.String() + ":" + t.In(e.loc).Format(localFormat)
+}
+
+// line 은 CRLF 로 끝나는 한 줄을 쓰며 75 octet 을 넘으면 UTF-8 문자 경계에서 접음
+func (e *encoder) line(s string) {
+	if e.err != nil {
+		return
+	}
+	width := 0
+	for _, r := range s {
+		size := len(string(r))
+		if width+size > maxLine {
+			if _, e.err = e.w.WriteString("\r\n "); e.err != nil {
+				return
+			}
+			width = 1
+		}
+		if _, e.err = e.w.WriteRune(r); e.err != nil {
+			return
+		}
+		width += size
+	}
+	_, e.err = e.w.WriteString("\r\n")
+}
+
+var escaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+// escape 는 TEXT 값의 특수 문자를 escape
+func escape(s string) string {
+	return escaper.Replace(s)
+}
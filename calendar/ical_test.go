@@ -0,0 +1,123 @@
+package calendar
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rutesun/reservation/reservation"
+	"github.com/stretchr/testify/assert"
+)
+
+var room = reservation.Room{ID: 1, Name: "회의실A"}
+
+func at(loc *time.Location, value string) time.Time {
+	t, _ := time.ParseInLocation("2006-01-02T15:04", value, loc)
+	return t
+}
+
+func occurrence(id int64, seriesID int64, n int, st time.Time, exception bool) *reservation.Detail {
+	return &reservation.Detail{
+		ID: id, Room: room, User: "Ted",
+		Start: st, End: st.Add(time.Hour),
+		SeriesID: &seriesID, Occurrence: n, Exception: exception,
+	}
+}
+
+func write(t *testing.T, cal *reservation.Calendar, loc *time.Location) string {
+	buf := &bytes.Buffer{}
+	assert.NoError(t, Write(buf, cal, loc, at(time.UTC, "2018-08-01T00:00")))
+	return buf.String()
+}
+
+func TestWrite(t *testing.T) {
+	seoul, _ := time.LoadLocation("Asia/Seoul")
+
+	t.Run("단건 예약", func(t *testing.T) {
+		out := write(t, &reservation.Calendar{
+			Name: "회의실A",
+			Reservations: []*reservation.Detail{{
+				ID: 7, Room: room, User: "Ted",
+				Start: at(seoul, "2018-08-07T10:00"), End: at(seoul, "2018-08-07T11:30"),
+				Memo: "주간 회의; 안건, 공유\n2부",
+			}},
+		}, time.UTC)
+
+		assert.True(t, strings.HasPrefix(out, "BEGIN:VCALENDAR\r\n"))
+		assert.Contains(t, out, "UID:reservation-7@reservation\r\n")
+		assert.Contains(t, out, "DTSTART:20180807T010000Z\r\n")
+		assert.Contains(t, out, "DTEND:20180807T023000Z\r\n")
+		assert.Contains(t, out, `DESCRIPTION:주간 회의\; 안건\, 공유\n2부`+"\r\n")
+		assert.NotContains(t, out, "RRULE")
+	})
+
+	t.Run("반복 예약의 취소, 변경된 회차", func(t *testing.T) {
+		// 2회차는 취소, 3회차는 변경, 1회차는 변경되어 규칙은 2회차부터 시작
+		out := write(t, &reservation.Calendar{
+			Name: "회의실A",
+			Series: []*reservation.Series{{
+				ID: 3, Room: room, User: "Ted", Rule: "FREQ=WEEKLY;COUNT=5", Memo: "1:1",
+				Occurrences: []*reservation.Detail{
+					occurrence(10, 3, 1, at(seoul, "2018-08-06T09:00"), true),
+					occurrence(12, 3, 3, at(seoul, "2018-08-20T14:00"), true),
+					occurrence(13, 3, 4, at(seoul, "2018-08-27T10:00"), false),
+					occurrence(14, 3, 5, at(seoul, "2018-09-03T10:00"), false),
+				},
+			}},
+		}, seoul)
+
+		assert.Contains(t, out, "X-WR-TIMEZONE:Asia/Seoul\r\n")
+		assert.Contains(t, out, "UID:series-3@reservation\r\n")
+		assert.Contains(t, out, "DTSTART;TZID=Asia/Seoul:20180827T100000\r\n")
+		assert.Contains(t, out, "RRULE:FREQ=WEEKLY;COUNT=2\r\n")
+		assert.Contains(t, out, "UID:reservation-10@reservation\r\n")
+		assert.Equal(t, 1, strings.Count(out, "UID:series-3@reservation"))
+		assert.NotContains(t, out, "EXDATE")
+	})
+
+	t.Run("규칙 중간의 취소, 변경된 회차", func(t *testing.T) {
+		out := write(t, &reservation.Calendar{
+			Name: "회의실A",
+			Series: []*reservation.Series{{
+				ID: 4, Room: room, User: "Ted", Rule: "FREQ=WEEKLY;COUNT=4",
+				Occurrences: []*reservation.Detail{
+					occurrence(20, 4, 1, at(seoul, "2018-08-06T10:00"), false),
+					occurrence(22, 4, 3, at(seoul, "2018-08-21T15:00"), true),
+					occurrence(23, 4, 4, at(seoul, "2018-08-27T10:00"), false),
+				},
+			}},
+		}, seoul)
+
+		assert.Contains(t, out, "RRULE:FREQ=WEEKLY;COUNT=4\r\n")
+		assert.Contains(t, out, "EXDATE;TZID=Asia/Seoul:20180813T100000\r\n")
+		assert.Contains(t, out, "RECURRENCE-ID;TZID=Asia/Seoul:20180820T100000\r\n")
+		assert.Contains(t, out, "DTSTART;TZID=Asia/Seoul:20180821T150000\r\n")
+		assert.Equal(t, 2, strings.Count(out, "UID:series-4@reservation"))
+	})
+
+	t.Run("규칙과 맞지 않으면 회차마다 따로", func(t *testing.T) {
+		out := write(t, &reservation.Calendar{
+			Name: "회의실A",
+			Series: []*reservation.Series{{
+				ID: 5, Room: room, User: "Ted", Rule: "FREQ=DAILY;COUNT=2",
+				Occurrences: []*reservation.Detail{
+					occurrence(30, 5, 1, at(seoul, "2018-08-06T10:00"), false),
+					occurrence(31, 5, 2, at(seoul, "2018-08-08T10:00"), false),
+				},
+			}},
+		}, seoul)
+
+		assert.NotContains(t, out, "RRULE")
+		assert.Contains(t, out, "UID:reservation-30@reservation\r\n")
+		assert.Contains(t, out, "UID:reservation-31@reservation\r\n")
+	})
+}
+
+func TestEncoder_Line(t *testing.T) {
+	out := write(t, &reservation.Calendar{Name: strings.Repeat("가", 40)}, time.UTC)
+	for _, line := range strings.Split(out, "\r\n") {
+		assert.True(t, len(line) <= maxLine, line)
+	}
+	assert.Contains(t, out, "\r\n 가")
+}
@@ -0,0 +1,176 @@
+package calendar
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rutesun/reservation/exception"
+	"github.com/rutesun/reservation/reservation"
+)
+
+// ImportOptions 의 User 는 ORGANIZER 가 없는 일정의 사용자
+// Owner 가 있으면 ORGANIZER 와 관계없이 모든 일정을 Owner 로 예약
+// DryRun 이면 예약하지 않고 가져올 수 있는지만 확인
+type ImportOptions struct {
+	User   string
+	Owner  string
+	DryRun bool
+}
+
+// ImportEntry 는 일정 하나를 가져온 결과
+// 가져왔으면 ID(단건) or SeriesID(반복), 건너뛰었으면 Reason, 겹치면 Conflicts 를 포함
+type ImportEntry struct {
+	UID       string                 `json:"uid"`
+	Summary   string                 `json:"summary"`
+	Location  string                 `json:"location"`
+	Start     time.Time              `json:"startTime"`
+	End       time.Time              `json:"endTime"`
+	ID        int64                  `json:"id,omitempty"`
+	SeriesID  int64                  `json:"seriesId,omitempty"`
+	Reason    string                 `json:"reason,omitempty"`
+	Conflicts []reservation.Conflict `json:"conflicts,omitempty"`
+}
+
+// Report 는 파일 하나를 가져온 결과
+type Report struct {
+	DryRun      bool           `json:"dryRun"`
+	Imported    []*ImportEntry `json:"imported"`
+	Skipped     []*ImportEntry `json:"skipped"`
+	Conflicting []*ImportEntry `json:"conflicting"`
+}
+
+// Import 는 일정마다 LOCATION 과 이름이 같은 회의실에 Service.Make 로 예약
+// 잘못된 일정은 건너뛰고 겹치는 일정은 예약하지 않으며, 저장소 오류가 나면 그 자리에서 멈추고 오류를 반환
+// 반복 일정은 한 회차라도 겹치면 전체를 예약하지 않음
+func Import(ctx context.Context, s *reservation.Service, events []*Event, opt ImportOptions) (*Report, error) {
+	rooms, err := s.RoomList(ctx, reservation.RoomFilter{})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	byName := make(map[string]int64)
+	for _, room := range rooms {
+		byName[roomKey(room.Name)] = room.ID
+	}
+
+	report := &Report{
+		DryRun:      opt.DryRun,
+		Imported:    []*ImportEntry{},
+		Skipped:     []*ImportEntry{},
+		Conflicting: []*ImportEntry{},
+	}
+	// DryRun 이면 실제로 예약하지 않으므로 파일 안의 앞 일정과 겹치는지 따로 확인
+	planned := make(map[int64][]reservation.Slot)
+	for _, event := range events {
+		entry := &ImportEntry{
+			UID:      event.UID,
+			Summary:  event.Summary,
+			Location: event.Location,
+			Start:    event.Start,
+			End:      event.End,
+		}
+
+		roomID, ok := byName[roomKey(event.Location)]
+		user := event.Organizer
+		if opt.Owner != "" {
+			user = opt.Owner
+		} else if user == "" {
+			user = opt.User
+		}
+		switch {
+		case event.Problem != "":
+			entry.Reason = event.Problem
+		case !ok:
+			entry.Reason = "회의실을 찾을 수 없습니다: " + event.Location
+		case user == "":
+			entry.Reason = "ORGANIZER 가 없어 사용자를 알 수 없습니다"
+		}
+		if entry.Reason != "" {
+			report.Skipped = append(report.Skipped, entry)
+			continue
+		}
+
+		extra := reservation.ExtraInfo{Memo: memo(event), DryRun: opt.DryRun}
+		if event.Rule != "" {
+			if extra.Rule, err = reservation.ParseRule(event.Rule, event.Loc); err != nil {
+				entry.Reason = reason(err)
+				report.Skipped = append(report.Skipped, entry)
+				continue
+			}
+		}
+
+		res, err := s.Make(ctx, roomID, user, event.Start, event.End, extra)
+		if conflict, ok := reservation.AsConflict(err); ok {
+			entry.Conflicts = conflict.Conflicts
+			report.Conflicting = append(report.Conflicting, entry)
+			continue
+		}
+		if err != nil {
+			e, _ := exception.Find(err)
+			if e == nil || e.Status >= 500 {
+				return report, errors.WithStack(err)
+			}
+			if e == exception.Unavailable {
+				report.Conflicting = append(report.Conflicting, entry)
+			} else {
+				entry.Reason = reason(err)
+				report.Skipped = append(report.Skipped, entry)
+			}
+			continue
+		}
+
+		if opt.DryRun {
+			res.Conflicts = append(res.Conflicts, overlapping(planned[roomID], res.Booked)...)
+			if len(res.Conflicts) == 0 {
+				planned[roomID] = append(planned[roomID], res.Booked...)
+			}
+		}
+		if len(res.Conflicts) > 0 {
+			entry.Conflicts = res.Conflicts
+			report.Conflicting = append(report.Conflicting, entry)
+			continue
+		}
+		entry.ID, entry.SeriesID = res.ID, res.SeriesID
+		report.Imported = append(report.Imported, entry)
+	}
+	return report, nil
+}
+
+// overlapping 은 booked 중 planned 와 겹치는 시간. 겹치는 기존 예약이 없으므로 With 는 비어 있음
+func overlapping(planned, booked []reservation.Slot) []reservation.Conflict {
+	conflicts := []reservation.Conflict{}
+	for _, b := range booked {
+		for _, p := range planned {
+			if p.End.After(b.Start) && p.Start.Before(b.End) {
+				conflicts = append(conflicts, reservation.Conflict{Slot: b})
+				break
+			}
+		}
+	}
+	return conflicts
+}
+
+// roomKey 는 대소문자, 앞뒤 공백을 무시하고 회의실 이름을 비교하기 위한 값
+func roomKey(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// memo 는 SUMMARY 와 DESCRIPTION 을 이어서 예약 메모로 사용
+func memo(event *Event) string {
+	if event.Description == "" {
+		return event.Summary
+	}
+	if event.Summary == "" {
+		return event.Description
+	}
+	return event.Summary + "\n" + event.Description
+}
+
+// reason 은 건너뛴 이유로 오류의 설명이 있으면 설명을 사용
+func reason(err error) string {
+	if _, detailed := exception.Find(err); detailed != nil && detailed.Detail != "" {
+		return detailed.Detail
+	}
+	return err.Error()
+}
@@ -0,0 +1,79 @@
+package calendar
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rutesun/reservation/memory"
+	"github.com/rutesun/reservation/reservation"
+	"github.com/stretchr/testify/assert"
+)
+
+func uids(entries []*ImportEntry) []string {
+	list := make([]string, len(entries))
+	for i, e := range entries {
+		list[i] = e.UID
+	}
+	return list
+}
+
+func TestImport(t *testing.T) {
+	ctx := context.Background()
+	service := reservation.New(memory.New("회의실A", "회의실B"))
+
+	file := func() []*Event {
+		events, err := Parse(ics(
+			"BEGIN:VEVENT", "UID:single", "LOCATION:회의실a ",
+			"DTSTART:20180807T010000Z", "DTEND:20180807T020000Z", "END:VEVENT",
+			"BEGIN:VEVENT", "UID:weekly", "LOCATION:회의실B", "ORGANIZER;CN=Amy:mailto:amy@example.com",
+			"DTSTART;TZID=Asia/Seoul:20180808T100000", "DURATION:PT1H", "RRULE:FREQ=WEEKLY;COUNT=3", "END:VEVENT",
+			"BEGIN:VEVENT", "UID:overlap", "LOCATION:회의실A",
+			"DTSTART:20180807T013000Z", "DTEND:20180807T023000Z", "END:VEVENT",
+			"BEGIN:VEVENT", "UID:unknown-room", "LOCATION:대강당",
+			"DTSTART:20180807T010000Z", "DTEND:20180807T020000Z", "END:VEVENT",
+			"BEGIN:VEVENT", "UID:off-grid", "LOCATION:회의실B",
+			"DTSTART:20180807T011000Z", "DTEND:20180807T020000Z", "END:VEVENT",
+		), nil)
+		assert.NoError(t, err)
+		return events
+	}
+
+	report, err := Import(ctx, service, file(), ImportOptions{User: "Ted", DryRun: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"single", "weekly"}, uids(report.Imported))
+	assert.Equal(t, []string{"overlap"}, uids(report.Conflicting))
+	assert.Equal(t, []string{"unknown-room", "off-grid"}, uids(report.Skipped))
+
+	list, err := service.List(ctx, at(time.UTC, "2018-08-01T00:00"), at(time.UTC, "2018-09-01T00:00"))
+	assert.NoError(t, err)
+	assert.Empty(t, list, "dry-run 은 예약하지 않음")
+
+	report, err = Import(ctx, service, file(), ImportOptions{User: "Ted"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"single", "weekly"}, uids(report.Imported))
+	assert.NotZero(t, report.Imported[0].ID)
+	assert.NotZero(t, report.Imported[1].SeriesID)
+	if assert.Len(t, report.Conflicting, 1) && assert.Len(t, report.Conflicting[0].Conflicts, 1) {
+		assert.Equal(t, report.Imported[0].ID, report.Conflicting[0].Conflicts[0].With.ID)
+	}
+
+	series, err := service.FindSeries(ctx, report.Imported[1].SeriesID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Amy", series.User)
+	assert.Len(t, series.Occurrences, 3)
+
+	report, err = Import(ctx, service, file(), ImportOptions{User: "Ted"})
+	assert.NoError(t, err)
+	assert.Empty(t, report.Imported, "같은 파일을 다시 가져오면 모두 겹침")
+	assert.Len(t, report.Conflicting, 3)
+
+	service = reservation.New(memory.New("회의실A", "회의실B"))
+	report, err = Import(ctx, service, file(), ImportOptions{Owner: "Ryan"})
+	assert.NoError(t, err)
+	if assert.Len(t, report.Imported, 2) {
+		series, err := service.FindSeries(ctx, report.Imported[1].SeriesID)
+		assert.NoError(t, err)
+		assert.Equal(t, "Ryan", series.User, "Owner 가 있으면 ORGANIZER 를 무시")
+	}
+}
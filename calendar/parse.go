@@ -0,0 +1,285 @@
+package calendar
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rutesun/reservation/exception"
+)
+
+// maxEvents 는 파일 하나에서 읽을 수 있는 최대 VEVENT 수
+const maxEvents = 5000
+
+// Event 는 iCalendar 파일의 VEVENT 하나
+// Problem 이 있으면 가져올 수 없는 일정이며 그 이유
+type Event struct {
+	UID         string
+	Summary     string
+	Description string
+	Location    string
+	Organizer   string
+	Start       time.Time
+	End         time.Time
+	Rule        string
+	Loc         *time.Location
+	Problem     string
+}
+
+// property 는 content line 하나. ex) DTSTART;TZID=Asia/Seoul:20180807T100000
+type property struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// Parse 는 iCalendar(RFC 5545) 파일에서 VEVENT 를 읽음
+// 시간대(TZID) 가 없는 시간은 loc 기준이며 TZID 는 IANA 이름(ex: Asia/Seoul) 만 지원
+// 파일 형식이 잘못되면 exception.InvalidRequest, 일정 하나의 문제는 Event.Problem 에 기록
+func Parse(r io.Reader, loc *time.Location) ([]*Event, error) {
+	if loc == nil {
+		loc = time.Local
+	}
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	events := []*Event{}
+	var props []*property
+	depth, inEvent := 0, false
+	for i, line := range lines {
+		if line == "" {
+			continue
+		}
+		prop, ok := parseLine(line)
+		if !ok {
+			return nil, exception.Invalid("file", "%d 번째 줄의 형식이 잘못되었습니다", i+1)
+		}
+
+		switch {
+		case prop.name == "BEGIN":
+			depth++
+			if depth == 1 && prop.value != "VCALENDAR" {
+				return nil, exception.Invalid("file", "VCALENDAR 가 아닙니다")
+			}
+			if depth == 2 && prop.value == "VEVENT" {
+				inEvent, props = true, nil
+			}
+		case prop.name == "END":
+			if depth == 2 && inEvent {
+				if len(events) == maxEvents {
+					return nil, exception.Invalid("file", "일정은 %d 개까지 가져올 수 있습니다", maxEvents)
+				}
+				events = append(events, newEvent(props, loc))
+				inEvent = false
+			}
+			depth--
+			if depth < 0 {
+				return nil, exception.Invalid("file", "%d 번째 줄의 END 에 맞는 BEGIN 이 없습니다", i+1)
+			}
+		case inEvent && depth == 2:
+			props = append(props, prop)
+		}
+	}
+	if depth != 0 {
+		return nil, exception.Invalid("file", "END:VCALENDAR 가 없습니다")
+	}
+	return events, nil
+}
+
+// unfold 는 공백으로 시작하는 줄을 앞 줄에 이어 붙임
+func unfold(r io.Reader) ([]string, error) {
+	lines := []string{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(lines) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.WithStack(exception.Invalid("file", "파일을 읽을 수 없습니다: %v", err))
+	}
+	return lines, nil
+}
+
+// parseLine 은 name *(";" param) ":" value 형식을 읽음. param 값은 큰따옴표 안에 ':', ';' 를 포함할 수 있음
+func parseLine(line string) (*property, bool) {
+	quoted := false
+	colon := -1
+	for i, r := range line {
+		if r == '"' {
+			quoted = !quoted
+		} else if r == ':' && !quoted {
+			colon = i
+			break
+		}
+	}
+	if colon <= 0 {
+		return nil, false
+	}
+
+	prop := &property{params: make(map[string]string), value: line[colon+1:]}
+	parts := splitParams(line[:colon])
+	prop.name = strings.ToUpper(parts[0])
+	for _, param := range parts[1:] {
+		kv := strings.SplitN(param, "=", 2)
+		if len(kv) != 2 {
+			return nil, false
+		}
+		prop.params[strings.ToUpper(kv[0])] = strings.Trim(kv[1], `"`)
+	}
+	if prop.name == "BEGIN" || prop.name == "END" {
+		prop.value = strings.ToUpper(prop.value)
+	}
+	return prop, true
+}
+
+func splitParams(s string) []string {
+	parts := []string{}
+	quoted, start := false, 0
+	for i, r := range s {
+		if r == '"' {
+			quoted = !quoted
+		} else if r == ';' && !quoted {
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+func newEvent(props []*property, loc *time.Location) *Event {
+	event := &Event{Loc: loc}
+	problem := func(p string) {
+		if event.Problem == "" {
+			event.Problem = p
+		}
+	}
+
+	var duration string
+	for _, prop := range props {
+		switch prop.name {
+		case "UID":
+			event.UID = prop.value
+		case "SUMMARY":
+			event.Summary = unescape(prop.value)
+		case "DESCRIPTION":
+			event.Description = unescape(prop.value)
+		case "LOCATION":
+			event.Location = unescape(prop.value)
+		case "ORGANIZER":
+			event.Organizer = organizer(prop)
+		case "DTSTART":
+			t, l, err := parseTime(prop, loc)
+			if err != nil {
+				problem(err.Error())
+			}
+			event.Start, event.Loc = t, l
+		case "DTEND":
+			t, _, err := parseTime(prop, loc)
+			if err != nil {
+				problem(err.Error())
+			}
+			event.End = t
+		case "DURATION":
+			duration = prop.value
+		case "RRULE":
+			event.Rule = prop.value
+		case "STATUS":
+			if strings.ToUpper(prop.value) == "CANCELLED" {
+				problem("취소된 일정입니다")
+			}
+		case "RECURRENCE-ID":
+			problem("반복 일정의 변경된 회차는 지원하지 않습니다")
+		case "EXDATE", "RDATE":
+			problem(prop.name + " 는 지원하지 않습니다")
+		}
+	}
+
+	switch {
+	case event.Start.IsZero():
+		problem("DTSTART 가 없습니다")
+	case event.End.IsZero() && duration != "":
+		d, err := parseDuration(duration)
+		if err != nil {
+			problem(err.Error())
+		}
+		event.End = event.Start.Add(d)
+	case event.End.IsZero():
+		problem("DTEND 나 DURATION 이 없습니다")
+	}
+	return event
+}
+
+// organizer 는 CN 이 있으면 CN, 없으면 mailto 주소를 사용자 이름으로 사용
+func organizer(prop *property) string {
+	if cn := prop.params["CN"]; cn != "" {
+		return cn
+	}
+	value := prop.value
+	if strings.HasPrefix(strings.ToLower(value), "mailto:") {
+		value = value[len("mailto:"):]
+	}
+	return value
+}
+
+// parseTime 은 20180807T010000Z(UTC), TZID 가 있는 지역 시간, 시간대 없는 시간(loc) 을 지원
+// 하루 종일 일정(VALUE=DATE) 은 회의실 예약으로 가져오지 않음
+func parseTime(prop *property, loc *time.Location) (time.Time, *time.Location, error) {
+	if prop.params["VALUE"] == "DATE" || len(prop.value) == len("20060102") {
+		return time.Time{}, loc, errors.New("하루 종일 일정은 지원하지 않습니다")
+	}
+	if strings.HasSuffix(prop.value, "Z") {
+		t, err := time.Parse(utcFormat, prop.value)
+		if err != nil {
+			return t, loc, errors.Errorf("잘못된 %s 입니다: %s", prop.name, prop.value)
+		}
+		return t, time.UTC, nil
+	}
+	if tzid := prop.params["TZID"]; tzid != "" {
+		l, err := time.LoadLocation(strings.TrimPrefix(tzid, "/"))
+		if err != nil {
+			return time.Time{}, loc, errors.Errorf("알 수 없는 시간대입니다: %s", tzid)
+		}
+		loc = l
+	}
+	t, err := time.ParseInLocation(localFormat, prop.value, loc)
+	if err != nil {
+		return t, loc, errors.Errorf("잘못된 %s 입니다: %s", prop.name, prop.value)
+	}
+	return t, loc, nil
+}
+
+var durationPattern = regexp.MustCompile(`^\+?P(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+
+// parseDuration 은 P1W, P1D, PT1H30M 같은 RFC 5545 DURATION 을 읽음
+func parseDuration(value string) (time.Duration, error) {
+	m := durationPattern.FindStringSubmatch(value)
+	if m == nil || value == "P" || strings.HasSuffix(value, "T") {
+		return 0, errors.Errorf("잘못된 DURATION 입니다: %s", value)
+	}
+	units := []time.Duration{7 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute, time.Second}
+	var d time.Duration
+	for i, unit := range units {
+		if m[i+1] != "" {
+			n, _ := strconv.Atoi(m[i+1])
+			d += time.Duration(n) * unit
+		}
+	}
+	return d, nil
+}
+
+var unescaper = strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n")
+
+func unescape(s string) string {
+	return unescaper.Replace(s)
+}
@@ -0,0 +1,122 @@
+package calendar
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rutesun/reservation/exception"
+	"github.com/rutesun/reservation/reservation"
+	"github.com/stretchr/testify/assert"
+)
+
+func ics(lines ...string) *strings.Reader {
+	return strings.NewReader(strings.Join(append(append([]string{"BEGIN:VCALENDAR", "VERSION:2.0"}, lines...), "END:VCALENDAR"), "\r\n"))
+}
+
+func TestParse(t *testing.T) {
+	seoul, _ := time.LoadLocation("Asia/Seoul")
+
+	events, err := Parse(ics(
+		"BEGIN:VEVENT",
+		"UID:a@old",
+		"SUMMARY:주간 회의\\, 공유",
+		"DESCRIPTION:1부\\n2부 이어지는",
+		"  설명",
+		`ORGANIZER;CN="Ted; 개발":mailto:ted@example.com`,
+		"LOCATION:회의실A",
+		"DTSTART;TZID=Asia/Seoul:20180807T100000",
+		"DURATION:PT1H30M",
+		"RRULE:FREQ=WEEKLY;COUNT=2",
+		"BEGIN:VALARM",
+		"TRIGGER:-PT15M",
+		"END:VALARM",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:b@old",
+		"ORGANIZER:mailto:amy@example.com",
+		"DTSTART:20180807T010000Z",
+		"DTEND:20180807T020000Z",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:c@old",
+		"DTSTART;VALUE=DATE:20180807",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:d@old",
+		"DTSTART:20180807T100000",
+		"DTEND:20180807T110000",
+		"STATUS:CANCELLED",
+		"END:VEVENT",
+	), seoul)
+	if !assert.NoError(t, err) || !assert.Len(t, events, 4) {
+		return
+	}
+
+	a := events[0]
+	assert.Equal(t, "주간 회의, 공유", a.Summary)
+	assert.Equal(t, "1부\n2부 이어지는 설명", a.Description)
+	assert.Equal(t, "Ted; 개발", a.Organizer)
+	assert.Equal(t, "회의실A", a.Location)
+	assert.True(t, a.Start.Equal(at(seoul, "2018-08-07T10:00")))
+	assert.Equal(t, 90*time.Minute, a.End.Sub(a.Start))
+	assert.Equal(t, "FREQ=WEEKLY;COUNT=2", a.Rule)
+	assert.Equal(t, seoul, a.Loc)
+	assert.Empty(t, a.Problem)
+
+	assert.Equal(t, "amy@example.com", events[1].Organizer)
+	assert.True(t, events[1].Start.Equal(at(seoul, "2018-08-07T10:00")))
+	assert.Equal(t, time.UTC, events[1].Loc)
+
+	assert.Equal(t, "하루 종일 일정은 지원하지 않습니다", events[2].Problem)
+	assert.Equal(t, "취소된 일정입니다", events[3].Problem)
+
+	for _, broken := range []*strings.Reader{
+		strings.NewReader("BEGIN:VEVENT\r\nEND:VEVENT"),
+		ics("BEGIN:VEVENT", "DTSTART"),
+		strings.NewReader("BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nEND:VEVENT"),
+	} {
+		_, err := Parse(broken, seoul)
+		assert.Equal(t, exception.InvalidRequest, errors.Cause(err))
+	}
+}
+
+func TestParse_Write(t *testing.T) {
+	seoul, _ := time.LoadLocation("Asia/Seoul")
+	cal := &reservation.Calendar{
+		Name: "회의실A",
+		Reservations: []*reservation.Detail{{
+			ID: 7, Room: room, User: "Ted",
+			Start: at(seoul, "2018-08-07T10:00"), End: at(seoul, "2018-08-07T11:30"),
+			Memo: "주간 회의; 안건, 공유\n2부 " + strings.Repeat("긴 설명", 20),
+		}},
+	}
+	out := write(t, cal, seoul)
+
+	events, err := Parse(strings.NewReader(out), time.UTC)
+	if assert.NoError(t, err) && assert.Len(t, events, 1) {
+		assert.Equal(t, "reservation-7@reservation", events[0].UID)
+		assert.Equal(t, cal.Reservations[0].Memo, events[0].Description)
+		assert.True(t, cal.Reservations[0].Start.Equal(events[0].Start))
+		assert.Equal(t, seoul, events[0].Loc)
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	for value, want := range map[string]time.Duration{
+		"PT1H":     time.Hour,
+		"PT30M":    30 * time.Minute,
+		"P1DT2H":   26 * time.Hour,
+		"P1W":      7 * 24 * time.Hour,
+		"+PT1H30M": 90 * time.Minute,
+	} {
+		d, err := parseDuration(value)
+		assert.NoError(t, err, value)
+		assert.Equal(t, want, d, value)
+	}
+	for _, value := range []string{"P", "PT", "1H", "-PT1H", "PT1.5H"} {
+		_, err := parseDuration(value)
+		assert.Error(t, err, value)
+	}
+}
@@ -0,0 +1,72 @@
+package calendar
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// nonceSize 는 구독 token 마다 다른 임의 값의 크기(byte)
+const nonceSize = 8
+
+// Signer 는 일정 구독 token 을 만들고 확인
+// token 은 "임의 값.HMAC(secret, feed + 임의 값)" 이므로 저장하지 않고도 확인할 수 있고 구독마다 다름
+// secret 이 없으면 token 없이 누구나 구독할 수 있음
+type Signer struct {
+	secret []byte
+}
+
+func NewSigner(secret string) *Signer {
+	return &Signer{secret: []byte(secret)}
+}
+
+// Protected 는 구독에 token 이 필요한지 여부
+func (s *Signer) Protected() bool {
+	return len(s.secret) > 0
+}
+
+// Sign 은 feed(ex: rooms/1, users/Ted) 를 구독할 새 token 을 만듦
+func (s *Signer) Sign(feed string) (string, error) {
+	if !s.Protected() {
+		return "", errors.New("calendar secret 이 설정되지 않았습니다")
+	}
+	nonce := make([]byte, nonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", errors.WithStack(err)
+	}
+	n := hex.EncodeToString(nonce)
+	return n + "." + s.mac(feed, n), nil
+}
+
+// Verify 는 token 이 feed 를 위해 만든 것인지 확인
+func (s *Signer) Verify(feed, token string) bool {
+	if !s.Protected() {
+		return true
+	}
+	parts := strings.SplitN(token, ".", 2)
+	if len(parts) != 2 {
+		return false
+	}
+	return hmac.Equal([]byte(parts[1]), []byte(s.mac(feed, parts[0])))
+}
+
+func (s *Signer) mac(feed, nonce string) string {
+	h := hmac.New(sha256.New, s.secret)
+	h.Write([]byte(feed + "\n" + nonce))
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil))
+}
+
+// RoomFeed, UserFeed 는 token 을 만들고 확인할 때 쓰는 feed 이름
+func RoomFeed(roomID int64) string {
+	return "rooms/" + strconv.FormatInt(roomID, 10)
+}
+
+func UserFeed(userName string) string {
+	return "users/" + userName
+}
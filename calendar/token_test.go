@@ -0,0 +1,27 @@
+package calendar
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSigner(t *testing.T) {
+	signer := NewSigner("secret")
+
+	token, err := signer.Sign(RoomFeed(1))
+	assert.NoError(t, err)
+	assert.True(t, signer.Verify(RoomFeed(1), token))
+	assert.False(t, signer.Verify(RoomFeed(2), token))
+	assert.False(t, NewSigner("other").Verify(RoomFeed(1), token))
+	assert.False(t, signer.Verify(RoomFeed(1), ""))
+
+	another, err := signer.Sign(RoomFeed(1))
+	assert.NoError(t, err)
+	assert.NotEqual(t, token, another)
+
+	public := NewSigner("")
+	assert.True(t, public.Verify(UserFeed("Ted"), ""))
+	_, err = public.Sign(UserFeed("Ted"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/rutesun/reservation/calendar"
+	"github.com/rutesun/reservation/config"
+)
+
+// runCalendarToken 은 일정 구독 token 을 만들어 구독 경로와 함께 출력
+//
+//	rooms <id>     회의실 일정
+//	users <name>   사용자 일정
+func runCalendarToken(setting *config.Setting, args []string) error {
+	if len(args) != 2 {
+		return errors.New("사용법: calendar-token rooms <id> | users <name>")
+	}
+
+	var feed, path string
+	switch args[0] {
+	case "rooms":
+		id, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return errors.Wrap(err, "잘못된 회의실 id 입니다")
+		}
+		feed = calendar.RoomFeed(id)
+		path = fmt.Sprintf("/rooms/%d/calendar.ics", id)
+	case "users":
+		feed = calendar.UserFeed(args[1])
+		path = "/users/" + url.PathEscape(args[1]) + "/calendar.ics"
+	default:
+		return errors.Errorf("알 수 없는 일정 종류입니다: %s", args[0])
+	}
+
+	token, err := calendar.NewSigner(setting.CalendarSecret).Sign(feed)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s?token=%s\n", path, url.QueryEscape(token))
+	return nil
+}
@@ -2,16 +2,28 @@ package config
 
 import (
 	"fmt"
+	"net/url"
+	"time"
 
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/jmoiron/sqlx"
 	"github.com/kelseyhightower/envconfig"
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const (
+	MariaDB  = "mariadb"
+	Postgres = "postgres"
+	SQLite   = "sqlite"
+	Memory   = "memory"
 )
 
 type config struct {
 	Host     string `default:"0.0.0.0"`
 	Port     int    `default:"8080"`
 	Database struct {
+		Driver       string `default:"mariadb"`
 		User         string `default:"root"`
 		Password     string
 		Host         string `default:"0.0.0.0"`
@@ -21,6 +33,68 @@ type config struct {
 		Location     string `default:"UTC"`
 		MaxIdleConns int    `default:"1"`
 		MaxOpenConns int    `default:"10"`
+		// 구동 시 적용되지 않은 migration 을 자동으로 적용
+		Migrate bool `default:"true"`
+		// query 하나에 허용되는 시간, 0 이면 제한 없음
+		QueryTimeout time.Duration `default:"5s"`
+	}
+	Postgres struct {
+		SSLMode string `default:"disable"`
+	}
+	SQLite struct {
+		Path        string `default:"reservation.db"`
+		BusyTimeout int    `default:"5000"`
+	}
+	Memory struct {
+		Rooms []string `default:"회의실A,회의실B,회의실C"`
+	}
+	Calendar struct {
+		// 일정 구독 token 을 만드는 secret, 없으면 token 없이 구독 가능
+		Secret string
+	}
+	Auth struct {
+		// 로그인하지 않은 사용자도 조회(GET) 할 수 있는지, false 이면 가입, 로그인 외에는 모두 로그인 필요
+		AnonymousRead bool `default:"true"`
+		// 로그인 token 의 유효 기간
+		TokenTTL time.Duration `default:"720h"`
+	}
+	Reservation struct {
+		// 시작 후 체크인을 기다리는 시간, 지나면 no_show 로 바꿔 회의실 시간을 돌려줌. 0 이면 바꾸지 않음
+		NoShowGrace time.Duration `default:"15m"`
+		// no_show 로 바꿀 예약을 확인하는 주기
+		NoShowInterval time.Duration `default:"1m"`
+	}
+	Notify   Notify
+	Webhooks struct {
+		// 관리자가 등록한 webhook 에 전달마다 보내는 최대 횟수와 첫 재시도까지 기다리는 시간
+		Attempts int           `default:"6"`
+		Backoff  time.Duration `default:"10s"`
+		// 다시 보낼 전달을 확인하는 주기, 0 이면 webhook 을 보내지 않음
+		Interval time.Duration `default:"10s"`
+	}
+}
+
+// Notify 는 예약 알림 설정이며 SMTP.Addr, Webhook.URL 중 설정한 채널로 알림을 보냄
+type Notify struct {
+	// 알림 template 의 언어
+	Locale string `default:"ko"`
+	// 기본 template 을 덮어쓸 template 이 있는 directory (<locale>/<event type>.tmpl)
+	TemplateDir string
+	// 알림에 표시할 시간대
+	Timezone string `default:"Local"`
+	// 채널마다 보내는 최대 횟수와 첫 재시도까지 기다리는 시간
+	Attempts int           `default:"3"`
+	Backoff  time.Duration `default:"1s"`
+	SMTP     struct {
+		Addr     string
+		From     string `default:"noreply@localhost"`
+		Username string
+		Password string
+		// 사용자 이름이 email 주소가 아닐 때 붙일 domain
+		Domain string
+	}
+	Webhook struct {
+		URL string
 	}
 }
 
@@ -33,10 +107,70 @@ func Parse() (*config, error) {
 }
 
 type Setting struct {
-	DB *sqlx.DB
+	Driver       string
+	DB           *sqlx.DB
+	Rooms        []string
+	Migrate      bool
+	QueryTimeout time.Duration
+	// CalendarSecret 은 일정 구독 token 을 만드는 secret
+	CalendarSecret string
+	AnonymousRead  bool
+	TokenTTL       time.Duration
+	NoShowGrace    time.Duration
+	NoShowInterval time.Duration
+	Notify         Notify
+	// 관리자가 등록한 webhook 의 재시도 설정
+	WebhookAttempts int
+	WebhookBackoff  time.Duration
+	WebhookInterval time.Duration
 }
 
 func Make(c *config) (*Setting, error) {
+	var (
+		setting *Setting
+		err     error
+	)
+	switch c.Database.Driver {
+	case Memory:
+		return &Setting{
+			Driver:          Memory,
+			Rooms:           c.Memory.Rooms,
+			CalendarSecret:  c.Calendar.Secret,
+			AnonymousRead:   c.Auth.AnonymousRead,
+			TokenTTL:        c.Auth.TokenTTL,
+			NoShowGrace:     c.Reservation.NoShowGrace,
+			NoShowInterval:  c.Reservation.NoShowInterval,
+			Notify:          c.Notify,
+			WebhookAttempts: c.Webhooks.Attempts,
+			WebhookBackoff:  c.Webhooks.Backoff,
+			WebhookInterval: c.Webhooks.Interval,
+		}, nil
+	case Postgres:
+		setting, err = makePostgres(c)
+	case SQLite:
+		setting, err = makeSQLite(c)
+	case MariaDB:
+		setting, err = makeMariaDB(c)
+	default:
+		return nil, fmt.Errorf("unknown database driver: %s", c.Database.Driver)
+	}
+	if setting != nil {
+		setting.Migrate = c.Database.Migrate
+		setting.QueryTimeout = c.Database.QueryTimeout
+		setting.CalendarSecret = c.Calendar.Secret
+		setting.AnonymousRead = c.Auth.AnonymousRead
+		setting.TokenTTL = c.Auth.TokenTTL
+		setting.NoShowGrace = c.Reservation.NoShowGrace
+		setting.NoShowInterval = c.Reservation.NoShowInterval
+		setting.Notify = c.Notify
+		setting.WebhookAttempts = c.Webhooks.Attempts
+		setting.WebhookBackoff = c.Webhooks.Backoff
+		setting.WebhookInterval = c.Webhooks.Interval
+	}
+	return setting, err
+}
+
+func makeMariaDB(c *config) (*Setting, error) {
 	endpoint := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=%s&parseTime=True&loc=%s",
 		c.Database.User, c.Database.Password,
 		c.Database.Host, c.Database.Port, c.Database.Name,
@@ -51,5 +185,38 @@ func Make(c *config) (*Setting, error) {
 
 	err = db.Ping()
 
-	return &Setting{db}, err
+	return &Setting{Driver: MariaDB, DB: db}, err
+}
+
+func makePostgres(c *config) (*Setting, error) {
+	endpoint := fmt.Sprintf("postgres://%s:%s@%s:%d/%s?sslmode=%s",
+		url.QueryEscape(c.Database.User), url.QueryEscape(c.Database.Password),
+		c.Database.Host, c.Database.Port, c.Database.Name,
+		c.Postgres.SSLMode)
+	db, err := sqlx.Open("postgres", endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	db.SetMaxIdleConns(c.Database.MaxIdleConns)
+	db.SetMaxOpenConns(c.Database.MaxOpenConns)
+
+	err = db.Ping()
+
+	return &Setting{Driver: Postgres, DB: db}, err
+}
+
+func makeSQLite(c *config) (*Setting, error) {
+	endpoint := fmt.Sprintf("file:%s?_foreign_keys=1&_busy_timeout=%d&_txlock=immediate", c.SQLite.Path, c.SQLite.BusyTimeout)
+	db, err := sqlx.Open("sqlite3", endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	// sqlite 는 쓰기가 파일 단위로 직렬화되므로 connection 을 늘려도 이득이 없음
+	db.SetMaxOpenConns(1)
+
+	err = db.Ping()
+
+	return &Setting{Driver: SQLite, DB: db}, err
 }
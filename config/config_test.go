@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -24,6 +25,9 @@ func TestDefaultParse(t *testing.T) {
 	assert.Equal(t, con.Database.User, "root")
 	assert.Equal(t, con.Database.Port, 3306)
 	assert.Empty(t, con.Database.Password)
+	assert.Equal(t, 15*time.Minute, con.Reservation.NoShowGrace)
+	assert.Equal(t, "ko", con.Notify.Locale)
+	assert.Equal(t, 3, con.Notify.Attempts)
 }
 
 func TestParse(t *testing.T) {
@@ -0,0 +1,194 @@
+package controller
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/pkg/errors"
+	"github.com/rutesun/reservation/account"
+	"github.com/rutesun/reservation/exception"
+)
+
+const (
+	// userKey 는 gin.Context 에 로그인한 사용자를 담는 key
+	userKey = "user"
+	// sessionCookie 는 브라우저에서 로그인 token 을 담는 cookie
+	sessionCookie = "session"
+)
+
+// Authenticate 는 Authorization: Bearer <token> header or session cookie 의 token 으로 사용자를 찾아
+// gin.Context 와 request context(account.WithUser) 에 담음
+// token 이 없으면 로그인하지 않은 사용자(account.Guest) 로 다음 handler 를 실행하며, 잘못된 Bearer token 이면 401 로 응답
+// 만료된 cookie 는 무시하여 로그인하지 않은 사용자로 처리
+func Authenticate(s *account.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var user *account.User
+		if token, fromHeader := bearerToken(c); token != "" {
+			var err error
+			user, err = s.Authenticate(c.Request.Context(), token)
+			if err != nil && (fromHeader || errors.Cause(err) != exception.Unauthorized) {
+				c.Error(err)
+				c.Abort()
+				return
+			}
+		}
+
+		if user != nil {
+			c.Set(userKey, user)
+		} else {
+			user = account.Anonymous()
+		}
+		c.Request = c.Request.WithContext(account.WithUser(c.Request.Context(), user))
+	}
+}
+
+// RequireUser 는 로그인하지 않았으면 401 로 응답
+func RequireUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if currentUser(c) == nil {
+			c.Error(exception.Unauthorized)
+			c.Abort()
+		}
+	}
+}
+
+// bearerToken 은 Authorization header 의 token 을 우선 사용하고 없으면 session cookie 를 사용
+func bearerToken(c *gin.Context) (string, bool) {
+	if header := c.GetHeader("Authorization"); header != "" {
+		if len(header) > len("Bearer ") && strings.EqualFold(header[:len("Bearer ")], "Bearer ") {
+			return strings.TrimSpace(header[len("Bearer "):]), true
+		}
+		return "", false
+	}
+	token, _ := c.Cookie(sessionCookie)
+	return token, false
+}
+
+func currentUser(c *gin.Context) *account.User {
+	if v, ok := c.Get(userKey); ok {
+		return v.(*account.User)
+	}
+	return nil
+}
+
+// userName 은 로그인한 사용자의 이름이며 예약한 사용자로 사용
+func userName(c *gin.Context) (string, error) {
+	user := currentUser(c)
+	if user == nil {
+		return "", exception.Unauthorized
+	}
+	return user.Name, nil
+}
+
+type accountRequest struct {
+	Name     string `form:"name" binding:"required"`
+	Password string `form:"password" binding:"required"`
+}
+
+// SignUpController 는 name, password 로 사용자를 등록
+func SignUpController(s *account.Service) func(context *gin.Context) {
+	return func(c *gin.Context) {
+		req := accountRequest{}
+		if err := c.ShouldBindWith(&req, binding.Form); err != nil {
+			c.Error(invalid(err))
+			return
+		}
+
+		if res, err := s.SignUp(c.Request.Context(), req.Name, req.Password); err == nil {
+			c.JSON(http.StatusCreated, gin.H{
+				"result": res,
+			})
+			return
+		} else {
+			c.Error(err)
+			return
+		}
+	}
+}
+
+// LoginController 는 name, password 를 확인하고 token 을 응답
+// API 는 Authorization: Bearer <token> 으로, 브라우저는 함께 설정되는 session cookie 로 로그인 상태를 유지
+func LoginController(s *account.Service, ttl time.Duration) func(context *gin.Context) {
+	return func(c *gin.Context) {
+		req := accountRequest{}
+		if err := c.ShouldBindWith(&req, binding.Form); err != nil {
+			c.Error(invalid(err))
+			return
+		}
+
+		token, user, err := s.Login(c.Request.Context(), req.Name, req.Password)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		setSession(c, token, int(ttl.Seconds()))
+		c.JSON(http.StatusOK, gin.H{
+			"result": user,
+			"token":  token,
+		})
+	}
+}
+
+// LogoutController 는 요청에 사용한 token 을 폐기하고 session cookie 를 지움
+func LogoutController(s *account.Service) func(context *gin.Context) {
+	return func(c *gin.Context) {
+		if token, _ := bearerToken(c); token != "" {
+			if err := s.Logout(c.Request.Context(), token); err != nil {
+				c.Error(err)
+				return
+			}
+		}
+		setSession(c, "", -1)
+		c.JSON(http.StatusOK, gin.H{
+			"result": true,
+		})
+	}
+}
+
+// UpdateUserController 는 사용자의 role 과 group (여러번 줄 수 있음) 을 바꾸며 관리자만 할 수 있음
+func UpdateUserController(s *account.Service) func(context *gin.Context) {
+	return func(c *gin.Context) {
+		role, ok := c.GetPostForm("role")
+		if !ok {
+			c.Error(exception.Invalid("role", "role 이 필요합니다"))
+			return
+		}
+		groups := c.PostFormArray("group")
+
+		if res, err := s.Update(c.Request.Context(), c.Param("name"), account.Role(role), groups); err == nil {
+			c.JSON(http.StatusOK, gin.H{
+				"result": res,
+			})
+			return
+		} else {
+			c.Error(err)
+			return
+		}
+	}
+}
+
+// MeController 는 로그인한 사용자를 응답
+func MeController() func(context *gin.Context) {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"result": currentUser(c),
+		})
+	}
+}
+
+// setSession 은 다른 사이트에서 보낸 요청에는 실리지 않는(SameSite=Strict) session cookie 를 설정
+// maxAge 가 0 보다 작으면 cookie 를 지움
+func setSession(c *gin.Context, token string, maxAge int) {
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    token,
+		Path:     "/",
+		MaxAge:   maxAge,
+		Secure:   c.Request.TLS != nil,
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
@@ -0,0 +1,42 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rutesun/reservation/exception"
+	"github.com/rutesun/reservation/reservation"
+)
+
+// ApproveController 는 승인 대기 중인 예약을 승인하며 관리자만 할 수 있음
+func ApproveController(s *reservation.Service) func(context *gin.Context) {
+	return reviewController(s.Approve)
+}
+
+// RejectController 는 승인 대기 중인 예약을 거절하며 관리자만 할 수 있음
+func RejectController(s *reservation.Service) func(context *gin.Context) {
+	return reviewController(s.Reject)
+}
+
+// reviewController 는 승인 대기 중이 아닌 예약이면 409 로 응답
+func reviewController(review func(ctx context.Context, reservationID int64) (*reservation.Detail, error)) func(context *gin.Context) {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.Error(exception.Invalid("id", "잘못된 id 형식입니다."))
+			return
+		}
+
+		if res, err := review(c.Request.Context(), int64(id)); err == nil {
+			c.JSON(http.StatusOK, gin.H{
+				"result": res,
+			})
+			return
+		} else {
+			c.Error(err)
+			return
+		}
+	}
+}
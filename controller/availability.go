@@ -0,0 +1,62 @@
+package controller
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rutesun/reservation/exception"
+	"github.com/rutesun/reservation/reservation"
+)
+
+// SearchController 는 from, to 사이에서 duration 만큼 비어 있는 시간을 회의실별로 찾음
+// ex) /availability/search?from=2018-08-07T13:00:00+09:00&to=2018-08-07T18:00:00+09:00&duration=60m&minCapacity=6
+// duration 을 주지 않으면 60m, 회의실 조건은 GET /rooms 와 동일
+func SearchController(s *reservation.Service) func(context *gin.Context) {
+	return func(c *gin.Context) {
+		from, err := parseQueryTime(c, "from")
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		to, err := parseQueryTime(c, "to")
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		duration, err := time.ParseDuration(c.DefaultQuery("duration", "60m"))
+		if err != nil {
+			c.Error(exception.Invalid("duration", "잘못된 duration 형식입니다 (ex: 60m, 1h30m)"))
+			return
+		}
+
+		filter, err := bindRoomFilter(c)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		if res, err := s.Search(c.Request.Context(), from, to, duration, filter); err == nil {
+			c.JSON(http.StatusOK, gin.H{
+				"result": res,
+			})
+			return
+		} else {
+			c.Error(err)
+			return
+		}
+	}
+}
+
+// parseQueryTime 은 RFC3339 형식의 query 를 읽음
+// query string 에서 인코딩하지 않은 '+' 는 공백으로 바뀌므로 되돌려서 처리
+func parseQueryTime(c *gin.Context, key string) (time.Time, error) {
+	v := strings.Replace(c.Query(key), " ", "+", 1)
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return t, exception.Invalid(key, "잘못된 %s 형식입니다 (ex: 2006-01-02T15:04:05+09:00)", key)
+	}
+	return t, nil
+}
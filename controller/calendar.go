@@ -0,0 +1,76 @@
+package controller
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rutesun/reservation/calendar"
+	"github.com/rutesun/reservation/exception"
+	"github.com/rutesun/reservation/reservation"
+)
+
+// RoomCalendarController 는 회의실 예약을 iCalendar 로 응답하여 일정 앱에서 구독할 수 있게 함
+// ex) /rooms/1/calendar.ics?token=...&timezone=Asia/Seoul
+// calendar secret 이 설정되어 있으면 ./app calendar-token rooms 1 로 만든 token 이 필요
+func RoomCalendarController(s *reservation.Service, signer *calendar.Signer) func(context *gin.Context) {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.Error(exception.Invalid("id", "잘못된 id 형식입니다."))
+			return
+		}
+		if !signer.Verify(calendar.RoomFeed(int64(id)), c.Query("token")) {
+			c.Error(exception.InvalidToken)
+			return
+		}
+
+		if cal, err := s.RoomCalendar(c.Request.Context(), int64(id)); err == nil {
+			writeCalendar(c, cal)
+			return
+		} else {
+			c.Error(err)
+			return
+		}
+	}
+}
+
+// UserCalendarController 는 사용자 예약을 iCalendar 로 응답하며 token, timezone 은 RoomCalendarController 와 같음
+func UserCalendarController(s *reservation.Service, signer *calendar.Signer) func(context *gin.Context) {
+	return func(c *gin.Context) {
+		name := c.Param("name")
+		if !signer.Verify(calendar.UserFeed(name), c.Query("token")) {
+			c.Error(exception.InvalidToken)
+			return
+		}
+
+		if cal, err := s.UserCalendar(c.Request.Context(), name); err == nil {
+			writeCalendar(c, cal)
+			return
+		} else {
+			c.Error(err)
+			return
+		}
+	}
+}
+
+// writeCalendar 는 timezone query 기준으로 일정을 씀. timezone 이 없으면 UTC
+func writeCalendar(c *gin.Context, cal *reservation.Calendar) {
+	loc := time.UTC
+	if timezone := c.Query("timezone"); timezone != "" {
+		var err error
+		if loc, err = time.LoadLocation(timezone); err != nil {
+			c.Error(exception.Invalid("timezone", "잘못된 timezone 입니다: %s", timezone))
+			return
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	if err := calendar.Write(buf, cal, loc, time.Now()); err != nil {
+		c.Error(err)
+		return
+	}
+	c.Data(http.StatusOK, calendar.ContentType, buf.Bytes())
+}
@@ -0,0 +1,89 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rutesun/reservation/exception"
+	"github.com/rutesun/reservation/log"
+	"github.com/rutesun/reservation/reservation"
+)
+
+const problemContentType = "application/problem+json"
+
+// problem 은 RFC 7807 형식의 오류 응답
+// Errors 는 잘못된 요청 항목, Conflicts 는 겹치는 예약(memo 제외)
+type problem struct {
+	Type      string                 `json:"type"`
+	Title     string                 `json:"title"`
+	Status    int                    `json:"status"`
+	Detail    string                 `json:"detail,omitempty"`
+	Instance  string                 `json:"instance,omitempty"`
+	Code      exception.Code         `json:"code"`
+	Errors    []exception.Field      `json:"errors,omitempty"`
+	Conflicts []reservation.Conflict `json:"conflicts,omitempty"`
+}
+
+// ErrorHandler 는 controller 가 c.Error 로 남긴 마지막 오류를 problem+json 으로 응답
+// exception.Error 이면 그 Status 로, 아니면 내용을 숨기고 500 으로 응답
+func ErrorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+
+		p := newProblem(c.Errors.Last().Err)
+		p.Instance = c.Request.URL.Path
+		body, err := json.Marshal(p)
+		if err != nil {
+			log.Errorf("%+v", err)
+			c.AbortWithStatus(http.StatusInternalServerError)
+			return
+		}
+		c.Data(p.Status, problemContentType, body)
+	}
+}
+
+func newProblem(err error) *problem {
+	e, detailed := exception.Find(err)
+	if e == nil {
+		log.Errorf("%+v", err)
+		return &problem{
+			Type:   problemType(exception.CodeInternal),
+			Title:  "서버 오류가 발생했습니다",
+			Status: http.StatusInternalServerError,
+			Code:   exception.CodeInternal,
+		}
+	}
+
+	p := &problem{
+		Type:   problemType(e.Code),
+		Title:  e.Message,
+		Status: e.Status,
+		Code:   e.Code,
+	}
+	if detailed != nil {
+		p.Detail = detailed.Detail
+		p.Errors = detailed.Fields
+	} else if msg := err.Error(); msg != e.Message {
+		// errors.Wrap 으로 덧붙인 설명
+		p.Detail = msg
+	}
+	if conflict, ok := reservation.AsConflict(err); ok {
+		p.Conflicts = conflict.Conflicts
+	}
+	return p
+}
+
+func problemType(code exception.Code) string {
+	return "urn:reservation:error:" + strings.ToLower(string(code))
+}
+
+// invalid 는 요청 binding 실패를 잘못된 요청으로 바꿈
+func invalid(err error) error {
+	return exception.InvalidRequest.WithDetail(err.Error())
+}
@@ -0,0 +1,72 @@
+package controller
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rutesun/reservation/calendar"
+	"github.com/rutesun/reservation/exception"
+	"github.com/rutesun/reservation/reservation"
+)
+
+// maxImportSize 는 가져올 수 있는 iCalendar 파일의 최대 크기
+const maxImportSize = 10 << 20
+
+// ImportController 는 iCalendar 파일의 일정을 예약으로 가져오고 가져온, 건너뛴, 겹치는 일정을 응답
+// 파일은 multipart 의 file 항목 or Content-Type: text/calendar 본문으로 받음
+// 모든 일정은 ORGANIZER 와 관계없이 로그인한 사용자로 예약
+// ?dry_run=true 이면 예약하지 않고 확인만 하며, timezone 은 시간대가 없는 시간의 기준 (없으면 서버의 지역 시간)
+func ImportController(s *reservation.Service) func(context *gin.Context) {
+	return func(c *gin.Context) {
+		user, err := userName(c)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize)
+
+		var body io.Reader = c.Request.Body
+		if file, err := c.FormFile("file"); err == nil {
+			f, err := file.Open()
+			if err != nil {
+				c.Error(err)
+				return
+			}
+			defer f.Close()
+			body = f
+		} else if err != http.ErrNotMultipart && err != http.ErrMissingFile {
+			c.Error(exception.Invalid("file", "파일을 읽을 수 없습니다"))
+			return
+		}
+
+		loc := time.Local
+		if timezone := c.Query("timezone"); timezone != "" {
+			if loc, err = time.LoadLocation(timezone); err != nil {
+				c.Error(exception.Invalid("timezone", "잘못된 timezone 입니다: %s", timezone))
+				return
+			}
+		}
+		dryRun, _ := strconv.ParseBool(c.Query("dry_run"))
+
+		events, err := calendar.Parse(body, loc)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		opt := calendar.ImportOptions{Owner: user, DryRun: dryRun}
+		if res, err := calendar.Import(c.Request.Context(), s, events, opt); err == nil {
+			c.JSON(http.StatusOK, gin.H{
+				"result": res,
+			})
+			return
+		} else {
+			c.Error(err)
+			return
+		}
+	}
+}
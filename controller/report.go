@@ -0,0 +1,141 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/rutesun/reservation/exception"
+	"github.com/rutesun/reservation/reservation"
+)
+
+// NoShowController 는 from ~ to (날짜, to 포함) 동안 시작한 예약의 사용자별 no_show 횟수를 응답
+// ex) /reports/no-shows?from=2018-08-01&to=2018-08-31&timezone=Asia/Seoul
+func NoShowController(s *reservation.Service) func(context *gin.Context) {
+	return func(c *gin.Context) {
+		from, to, _, err := bindReportRange(c)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		if res, err := s.NoShows(c.Request.Context(), from, to.AddDate(0, 0, 1)); err == nil {
+			c.JSON(http.StatusOK, gin.H{
+				"result": res,
+			})
+			return
+		} else {
+			c.Error(err)
+			return
+		}
+	}
+}
+
+// bindReportRange 는 from, to 날짜를 timezone (없으면 서버의 지역 시간) 기준으로 해석
+func bindReportRange(c *gin.Context) (time.Time, time.Time, *time.Location, error) {
+	loc := time.Local
+	if timezone := c.Query("timezone"); timezone != "" {
+		var err error
+		if loc, err = time.LoadLocation(timezone); err != nil {
+			return time.Time{}, time.Time{}, nil, exception.Invalid("timezone", "잘못된 timezone 입니다: %s", timezone)
+		}
+	}
+
+	from, err := time.ParseInLocation(dateFormat, c.Query("from"), loc)
+	if err != nil {
+		return time.Time{}, time.Time{}, nil, exception.Invalid("from", "잘못된 날짜 형식입니다 (ex: yyyy-MM-dd)")
+	}
+	to, err := time.ParseInLocation(dateFormat, c.Query("to"), loc)
+	if err != nil {
+		return time.Time{}, time.Time{}, nil, exception.Invalid("to", "잘못된 날짜 형식입니다 (ex: yyyy-MM-dd)")
+	}
+	return from, to, loc, nil
+}
+
+// UtilizationController 는 from ~ to (날짜, to 포함) 동안의 회의실 이용률을 groupBy(room, day, hour) 별로 응답
+// ex) /reports/utilization?from=2018-08-01&to=2018-08-31&groupBy=day&timezone=Asia/Seoul&format=csv
+// groupBy 를 주지 않으면 room, timezone 을 주지 않으면 서버의 지역 시간, format=csv 이면 CSV 로 응답
+func UtilizationController(s *reservation.Service) func(context *gin.Context) {
+	return func(c *gin.Context) {
+		from, to, loc, err := bindReportRange(c)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		groupBy := reservation.GroupBy(c.DefaultQuery("groupBy", string(reservation.GroupByRoom)))
+		res, err := s.Utilization(c.Request.Context(), from, to.AddDate(0, 0, 1), groupBy, loc)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		switch c.DefaultQuery("format", "json") {
+		case "json":
+			c.JSON(http.StatusOK, gin.H{
+				"result": res,
+			})
+		case "csv":
+			filename := fmt.Sprintf("utilization-%s-%s.csv", c.Query("from"), c.Query("to"))
+			c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+			data, err := utilizationCSV(res)
+			if err != nil {
+				c.Error(err)
+				return
+			}
+			c.Data(http.StatusOK, "text/csv; charset=utf-8", data)
+		default:
+			c.Error(exception.Invalid("format", "format 은 json, csv 중 하나입니다"))
+		}
+	}
+}
+
+// utilizationCSV 는 groupBy 별 이용률 뒤에 전체(total) 를 한 줄로 씀
+func utilizationCSV(res *reservation.Utilization) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	w := csv.NewWriter(buf)
+	w.Write([]string{
+		string(res.GroupBy), "room_id", "booked_hours", "available_hours", "utilization",
+		"meetings", "average_minutes", "repeat_ratio", "peak_hour",
+	})
+	for _, row := range append(res.Rows, res.Total) {
+		roomID, peakHour := "", ""
+		if row.RoomID != 0 {
+			roomID = strconv.FormatInt(row.RoomID, 10)
+		}
+		if row.PeakHour != nil {
+			peakHour = strconv.Itoa(*row.PeakHour)
+		}
+		w.Write([]string{
+			csvText(row.Key),
+			roomID,
+			strconv.FormatFloat(row.BookedHours, 'f', -1, 64),
+			strconv.FormatFloat(row.AvailableHours, 'f', -1, 64),
+			strconv.FormatFloat(row.Utilization, 'f', -1, 64),
+			strconv.Itoa(row.Meetings),
+			strconv.FormatFloat(row.AverageMinutes, 'f', -1, 64),
+			strconv.FormatFloat(row.RepeatRatio, 'f', -1, 64),
+			peakHour,
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return buf.Bytes(), nil
+}
+
+// csvText 는 사용자가 정한 값(회의실 이름 등) 이 스프레드시트에서 수식으로 실행되지 않도록
+// =, +, -, @ 등으로 시작하면 앞에 ' 를 붙임
+func csvText(value string) string {
+	if value != "" && strings.ContainsAny(value[:1], "=+-@\t\r") {
+		return "'" + value
+	}
+	return value
+}
@@ -1,7 +1,6 @@
 package controller
 
 import (
-	"fmt"
 	"net/http"
 	"time"
 
@@ -9,27 +8,49 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
+	"github.com/rutesun/reservation/exception"
 	"github.com/rutesun/reservation/log"
 	"github.com/rutesun/reservation/reservation"
 )
 
 const dateFormat = "2006-01-02"
 
+// RoomsController 는 ?minCapacity=8&equipment=vc 처럼 수용 인원과 장비로 회의실을 거를 수 있음
+// equipment 는 여러번 주거나 ',' 로 이어서 줄 수 있으며 모든 장비를 갖춘 회의실만 조회
 func RoomsController(s *reservation.Service) func(context *gin.Context) {
 	return func(c *gin.Context) {
-		if res, err := s.RoomList(); err == nil {
+		filter, err := bindRoomFilter(c)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		if res, err := s.RoomList(c.Request.Context(), filter); err == nil {
 			c.JSON(http.StatusOK, gin.H{
 				"result": res,
 			})
 			return
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			c.Error(err)
 			return
 		}
 
 	}
 }
 
+// bindRoomFilter 는 minCapacity, equipment query 로 회의실 조회 조건을 만듦
+func bindRoomFilter(c *gin.Context) (reservation.RoomFilter, error) {
+	filter := reservation.RoomFilter{Equipment: c.QueryArray("equipment")}
+	if minCapacity := c.Query("minCapacity"); minCapacity != "" {
+		n, err := strconv.Atoi(minCapacity)
+		if err != nil || n < 0 {
+			return filter, exception.Invalid("minCapacity", "잘못된 minCapacity 형식입니다: %s", minCapacity)
+		}
+		filter.MinCapacity = n
+	}
+	return filter, nil
+}
+
 func ListController(s *reservation.Service) func(context *gin.Context) {
 	return func(c *gin.Context) {
 		startStr := c.Query("startDate")
@@ -37,70 +58,190 @@ func ListController(s *reservation.Service) func(context *gin.Context) {
 
 		loc, _ := time.LoadLocation("Local")
 		startDate, err := time.ParseInLocation(dateFormat, startStr, loc)
+		if err != nil {
+			c.Error(exception.Invalid("startDate", "잘못된 날짜 형식입니다 (ex: yyyy-MM-dd)"))
+			return
+		}
 		endDate, err := time.ParseInLocation(dateFormat, endStr, loc)
-
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "잘못된 날짜 형식입니다 (ex: yyyy-MM-dd)"})
+			c.Error(exception.Invalid("endDate", "잘못된 날짜 형식입니다 (ex: yyyy-MM-dd)"))
 			return
 		}
 
-		if res, err := s.List(startDate, endDate); err == nil {
+		if res, err := s.List(c.Request.Context(), startDate, endDate); err == nil {
 			c.JSON(http.StatusOK, gin.H{
 				"result": res,
 			})
 			return
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			c.Error(err)
+			return
+		}
+
+	}
+}
+
+// FindController 는 예약 하나를 status 와 함께 응답하며 거절, 취소된 예약도 조회 가능
+func FindController(s *reservation.Service) func(context *gin.Context) {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.Error(exception.Invalid("id", "잘못된 id 형식입니다."))
 			return
 		}
 
+		if res, err := s.Find(c.Request.Context(), int64(id)); err == nil {
+			c.JSON(http.StatusOK, gin.H{
+				"result": res,
+			})
+			return
+		} else {
+			c.Error(err)
+			return
+		}
 	}
 }
 
 type reservationRequest struct {
 	RoomID    string    `form:"room_id" binding:"required"`
-	UserName  string    `form:"user_name" binding:"required"`
 	Repeat    string    `form:"repeat"`
+	RRule     string    `form:"rrule"`
+	Timezone  string    `form:"timezone"`
+	Partial   bool      `form:"partial"`
+	DryRun    bool      `form:"dry_run"`
+	Memo      string    `form:"memo"`
 	StartTime time.Time `form:"start_time" binding:"required" time_format:"2006-01-02T15:04:05Z07:00"`
 	EndTime   time.Time `form:"end_time" binding:"required" time_format:"2006-01-02T15:04:05Z07:00"`
 	//StartTime time.Time `form:"start_time" binding:"required" time_format:"2006-01-02T15:04"`
 	//EndTime   time.Time `form:"end_time" binding:"required" time_format:"2006-01-02T15:04"`
 }
 
+// MakeController 는 로그인한 사용자로 예약하며 partial=true 이면 겹치는 회차를 빼고 예약하고,
+// dry_run=true 이면 예약하지 않고 결과만 반환
 func MakeController(s *reservation.Service) func(context *gin.Context) {
 	return func(c *gin.Context) {
+		user, err := userName(c)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
 		req := reservationRequest{}
-		err := c.ShouldBindWith(&req, binding.Form)
+		err = c.ShouldBindWith(&req, binding.Form)
 		if err != nil {
 			log.Error(err.Error())
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			c.Error(invalid(err))
 			return
 		}
 
-		fmt.Println(req)
 		roomId, err := strconv.Atoi(req.RoomID)
 		if err != nil {
 			log.Error(err.Error())
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			c.Error(exception.Invalid("room_id", "잘못된 room_id 형식입니다: %s", req.RoomID))
+			return
+		}
+
+		extra := reservation.ExtraInfo{Memo: req.Memo, Partial: req.Partial, DryRun: req.DryRun}
+		if req.Repeat != "" {
+			if extra.Repeat, err = strconv.Atoi(req.Repeat); err != nil {
+				log.Error(err.Error())
+				c.Error(exception.Invalid("repeat", "잘못된 repeat 형식입니다: %s", req.Repeat))
+				return
+			}
+		}
+
+		if req.RRule != "" {
+			if extra.Rule, err = bindRule(req.RRule, req.Timezone); err != nil {
+				c.Error(err)
+				return
+			}
+		}
+
+		if res, err := s.Make(c.Request.Context(), int64(roomId), user, req.StartTime, req.EndTime, extra); err == nil {
+			c.JSON(http.StatusOK, gin.H{"result": "OK", "reservation": res})
+			return
+		} else {
+			c.Error(err)
 			return
 		}
 
-		repeat, err := strconv.Atoi(req.Repeat)
+	}
+}
+
+// bindRule 은 rrule 을 timezone(ex: Asia/Seoul) 기준으로 해석하며 timezone 이 없으면 서버의 지역 시간 사용
+// 일광 절약 시간이 있는 지역이면 timezone 을 주어야 매번 같은 시각으로 예약됨
+func bindRule(rrule, timezone string) (*reservation.Rule, error) {
+	loc := time.Local
+	if timezone != "" {
+		var err error
+		if loc, err = time.LoadLocation(timezone); err != nil {
+			return nil, exception.Invalid("timezone", "잘못된 timezone 입니다: %s", timezone)
+		}
+	}
+	return reservation.ParseRule(rrule, loc)
+}
+
+// ModifyController 는 PUT 이면 room_id, start_time, end_time 이 모두 필요하고
+// PATCH 이면 전달된 항목만 변경
+func ModifyController(s *reservation.Service) func(context *gin.Context) {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.Error(exception.Invalid("id", "잘못된 id 형식입니다."))
+			return
+		}
+
+		m, err := bindModification(c, c.Request.Method == http.MethodPut)
 		if err != nil {
 			log.Error(err.Error())
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			c.Error(err)
 			return
 		}
 
-		if err := s.Make(int64(roomId), req.UserName, req.StartTime, req.EndTime, reservation.ExtraInfo{Repeat: repeat}); err == nil {
-			c.JSON(http.StatusOK, gin.H{"result": "OK"})
+		if res, err := s.Modify(c.Request.Context(), int64(id), m); err == nil {
+			c.JSON(http.StatusOK, gin.H{
+				"result": res,
+			})
 			return
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			c.Error(err)
 			return
 		}
+	}
+}
+
+func bindModification(c *gin.Context, required bool) (reservation.Modification, error) {
+	m := reservation.Modification{}
+
+	if v, ok := c.GetPostForm("room_id"); ok {
+		roomID, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return m, exception.Invalid("room_id", "잘못된 room_id 형식입니다: %s", v)
+		}
+		m.RoomID = &roomID
+	} else if required {
+		return m, exception.Invalid("room_id", "room_id 가 필요합니다")
+	}
+
+	for _, field := range []struct {
+		name string
+		dst  **time.Time
+	}{{"start_time", &m.Start}, {"end_time", &m.End}} {
+		if v, ok := c.GetPostForm(field.name); ok {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return m, exception.Invalid(field.name, "잘못된 %s 형식입니다 (ex: 2006-01-02T15:04:05+09:00)", field.name)
+			}
+			*field.dst = &t
+		} else if required {
+			return m, exception.Invalid(field.name, "%s 가 필요합니다", field.name)
+		}
+	}
 
+	if v, ok := c.GetPostForm("memo"); ok || required {
+		m.Memo = &v
 	}
+	return m, nil
 }
 
 func CancelController(s *reservation.Service) func(context *gin.Context) {
@@ -109,19 +250,40 @@ func CancelController(s *reservation.Service) func(context *gin.Context) {
 
 		id, err := strconv.Atoi(idStr)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "잘못된 id 형식입니다."})
+			c.Error(exception.Invalid("id", "잘못된 id 형식입니다."))
 			return
 		}
 
-		if res, err := s.Cancel(int64(id)); err == nil {
+		if res, err := s.Cancel(c.Request.Context(), int64(id)); err == nil {
 			c.JSON(http.StatusOK, gin.H{
 				"result": res,
 			})
 			return
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			c.Error(err)
+			return
+		}
+
+	}
+}
+
+// CheckInController 는 예약에 체크인하며 시작 후 정해진 시간 안에 체크인하지 않은 예약은 no_show 로 바뀜
+func CheckInController(s *reservation.Service) func(context *gin.Context) {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.Error(exception.Invalid("id", "잘못된 id 형식입니다."))
 			return
 		}
 
+		if res, err := s.CheckIn(c.Request.Context(), int64(id)); err == nil {
+			c.JSON(http.StatusOK, gin.H{
+				"result": res,
+			})
+			return
+		} else {
+			c.Error(err)
+			return
+		}
 	}
 }
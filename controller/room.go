@@ -0,0 +1,110 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/rutesun/reservation/exception"
+	"github.com/rutesun/reservation/log"
+	"github.com/rutesun/reservation/reservation"
+)
+
+type roomRequest struct {
+	Name             string   `form:"name" binding:"required"`
+	Capacity         int      `form:"capacity"`
+	Building         string   `form:"building"`
+	Floor            string   `form:"floor"`
+	Equipment        []string `form:"equipment"`
+	Policy           string   `form:"policy"`
+	Group            string   `form:"group"`
+	RequiresApproval bool     `form:"requires_approval"`
+}
+
+func (r roomRequest) room() reservation.Room {
+	return reservation.Room{
+		Name:             r.Name,
+		Capacity:         r.Capacity,
+		Building:         r.Building,
+		Floor:            r.Floor,
+		Equipment:        r.Equipment,
+		Policy:           reservation.Policy(r.Policy),
+		Group:            r.Group,
+		RequiresApproval: r.RequiresApproval,
+	}
+}
+
+// CreateRoomController 는 policy(open, group, readonly) 와 group 으로 예약할 수 있는 사용자를 제한할 수 있음
+// requires_approval=true 이면 관리자의 승인을 받아야 하는 회의실
+func CreateRoomController(s *reservation.Service) func(context *gin.Context) {
+	return func(c *gin.Context) {
+		req := roomRequest{}
+		if err := c.ShouldBindWith(&req, binding.Form); err != nil {
+			log.Error(err.Error())
+			c.Error(invalid(err))
+			return
+		}
+
+		if res, err := s.CreateRoom(c.Request.Context(), req.room()); err == nil {
+			c.JSON(http.StatusCreated, gin.H{
+				"result": res,
+			})
+			return
+		} else {
+			c.Error(err)
+			return
+		}
+	}
+}
+
+func UpdateRoomController(s *reservation.Service) func(context *gin.Context) {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.Error(exception.Invalid("id", "잘못된 id 형식입니다."))
+			return
+		}
+
+		req := roomRequest{}
+		if err := c.ShouldBindWith(&req, binding.Form); err != nil {
+			log.Error(err.Error())
+			c.Error(invalid(err))
+			return
+		}
+
+		if res, err := s.UpdateRoom(c.Request.Context(), int64(id), req.room()); err == nil {
+			c.JSON(http.StatusOK, gin.H{
+				"result": res,
+			})
+			return
+		} else {
+			c.Error(err)
+			return
+		}
+	}
+}
+
+// ArchiveRoomController 는 앞으로 예정된 예약이 있으면 실패하며 ?cascade=true 이면 해당 예약을 취소하고 보관
+func ArchiveRoomController(s *reservation.Service) func(context *gin.Context) {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.Error(exception.Invalid("id", "잘못된 id 형식입니다."))
+			return
+		}
+
+		cascade, _ := strconv.ParseBool(c.Query("cascade"))
+
+		if canceled, err := s.ArchiveRoom(c.Request.Context(), int64(id), cascade); err == nil {
+			c.JSON(http.StatusOK, gin.H{
+				"result":   true,
+				"canceled": canceled,
+			})
+			return
+		} else {
+			c.Error(err)
+			return
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rutesun/reservation/exception"
+	"github.com/rutesun/reservation/log"
+	"github.com/rutesun/reservation/reservation"
+)
+
+func SeriesController(s *reservation.Service) func(context *gin.Context) {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.Error(exception.Invalid("id", "잘못된 id 형식입니다."))
+			return
+		}
+
+		if res, err := s.FindSeries(c.Request.Context(), int64(id)); err == nil {
+			c.JSON(http.StatusOK, gin.H{
+				"result": res,
+			})
+			return
+		} else {
+			c.Error(err)
+			return
+		}
+	}
+}
+
+// CancelSeriesController 는 반복 예약 전체를 취소하며 ?from=n 이면 n 번째와 그 이후 회차만 취소
+func CancelSeriesController(s *reservation.Service) func(context *gin.Context) {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.Error(exception.Invalid("id", "잘못된 id 형식입니다."))
+			return
+		}
+
+		var canceled int64
+		if from := c.Query("from"); from != "" {
+			occurrence, err := strconv.Atoi(from)
+			if err != nil {
+				c.Error(exception.Invalid("from", "잘못된 from 형식입니다."))
+				return
+			}
+			canceled, err = s.CancelFollowing(c.Request.Context(), int64(id), occurrence)
+		} else {
+			canceled, err = s.CancelSeries(c.Request.Context(), int64(id))
+		}
+
+		if err == nil {
+			c.JSON(http.StatusOK, gin.H{
+				"result":   true,
+				"canceled": canceled,
+			})
+			return
+		} else {
+			c.Error(err)
+			return
+		}
+	}
+}
+
+// ModifyOccurrenceController 는 반복 예약의 한 회차만 변경하며 항목은 ModifyController 와 같음
+func ModifyOccurrenceController(s *reservation.Service) func(context *gin.Context) {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.Error(exception.Invalid("id", "잘못된 id 형식입니다."))
+			return
+		}
+		occurrence, err := strconv.Atoi(c.Param("occurrence"))
+		if err != nil {
+			c.Error(exception.Invalid("회차", "잘못된 회차 형식입니다."))
+			return
+		}
+
+		m, err := bindModification(c, c.Request.Method == http.MethodPut)
+		if err != nil {
+			log.Error(err.Error())
+			c.Error(err)
+			return
+		}
+
+		if res, err := s.ModifyOccurrence(c.Request.Context(), int64(id), occurrence, m); err == nil {
+			c.JSON(http.StatusOK, gin.H{
+				"result": res,
+			})
+			return
+		} else {
+			c.Error(err)
+			return
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/rutesun/reservation/exception"
+	"github.com/rutesun/reservation/reservation"
+)
+
+type waitlistRequest struct {
+	RoomID    string    `form:"room_id" binding:"required"`
+	Memo      string    `form:"memo"`
+	StartTime time.Time `form:"start_time" binding:"required" time_format:"2006-01-02T15:04:05Z07:00"`
+	EndTime   time.Time `form:"end_time" binding:"required" time_format:"2006-01-02T15:04:05Z07:00"`
+}
+
+// JoinWaitlistController 는 예약할 수 없는 시간에 로그인한 사용자로 대기를 추가
+// 겹치는 예약이 취소되면 먼저 들어온 대기부터 자동으로 예약됨
+func JoinWaitlistController(s *reservation.Service) func(context *gin.Context) {
+	return func(c *gin.Context) {
+		user, err := userName(c)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		req := waitlistRequest{}
+		if err := c.ShouldBindWith(&req, binding.Form); err != nil {
+			c.Error(invalid(err))
+			return
+		}
+		roomID, err := strconv.Atoi(req.RoomID)
+		if err != nil {
+			c.Error(exception.Invalid("room_id", "잘못된 room_id 형식입니다: %s", req.RoomID))
+			return
+		}
+
+		if res, err := s.JoinWaitlist(c.Request.Context(), int64(roomID), user, req.StartTime, req.EndTime, req.Memo); err == nil {
+			c.JSON(http.StatusCreated, gin.H{
+				"result": res,
+			})
+			return
+		} else {
+			c.Error(err)
+			return
+		}
+	}
+}
+
+// WaitlistController 는 로그인한 사용자의 대기 목록을 응답
+func WaitlistController(s *reservation.Service) func(context *gin.Context) {
+	return func(c *gin.Context) {
+		user, err := userName(c)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		if res, err := s.Waitlist(c.Request.Context(), user); err == nil {
+			c.JSON(http.StatusOK, gin.H{
+				"result": res,
+			})
+			return
+		} else {
+			c.Error(err)
+			return
+		}
+	}
+}
+
+// LeaveWaitlistController 는 대기를 지움
+func LeaveWaitlistController(s *reservation.Service) func(context *gin.Context) {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.Error(exception.Invalid("id", "잘못된 id 형식입니다."))
+			return
+		}
+
+		if err := s.LeaveWaitlist(c.Request.Context(), int64(id)); err == nil {
+			c.JSON(http.StatusOK, gin.H{
+				"result": true,
+			})
+			return
+		} else {
+			c.Error(err)
+			return
+		}
+	}
+}
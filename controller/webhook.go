@@ -0,0 +1,178 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/rutesun/reservation/exception"
+	"github.com/rutesun/reservation/webhook"
+)
+
+type webhookRequest struct {
+	URL    string   `form:"url" binding:"required"`
+	Secret string   `form:"secret"`
+	Events []string `form:"events"`
+	RoomID string   `form:"room_id"`
+}
+
+// CreateWebhookController 는 events(쉼표 구분 or 여러 번) 와 room_id 로 보낼 Event 를 제한할 수 있음
+// secret 이 없으면 만들어 응답하며 이후에는 조회할 수 없음
+func CreateWebhookController(s *webhook.Service) func(context *gin.Context) {
+	return func(c *gin.Context) {
+		req := webhookRequest{}
+		if err := c.ShouldBindWith(&req, binding.Form); err != nil {
+			c.Error(invalid(err))
+			return
+		}
+		var roomID *int64
+		if req.RoomID != "" {
+			id, err := strconv.ParseInt(req.RoomID, 10, 64)
+			if err != nil {
+				c.Error(exception.Invalid("room_id", "잘못된 room_id 형식입니다: %s", req.RoomID))
+				return
+			}
+			roomID = &id
+		}
+
+		if res, err := s.Subscribe(c.Request.Context(), req.URL, req.Secret, req.Events, roomID); err == nil {
+			c.JSON(http.StatusCreated, gin.H{
+				"result": res,
+			})
+			return
+		} else {
+			c.Error(err)
+			return
+		}
+	}
+}
+
+func WebhooksController(s *webhook.Service) func(context *gin.Context) {
+	return func(c *gin.Context) {
+		if res, err := s.Subscriptions(c.Request.Context()); err == nil {
+			c.JSON(http.StatusOK, gin.H{
+				"result": res,
+			})
+			return
+		} else {
+			c.Error(err)
+			return
+		}
+	}
+}
+
+func WebhookController(s *webhook.Service) func(context *gin.Context) {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.Error(exception.Invalid("id", "잘못된 id 형식입니다."))
+			return
+		}
+
+		if res, err := s.FindSubscription(c.Request.Context(), int64(id)); err == nil {
+			c.JSON(http.StatusOK, gin.H{
+				"result": res,
+			})
+			return
+		} else {
+			c.Error(err)
+			return
+		}
+	}
+}
+
+// DeleteWebhookController 는 webhook 과 전달 기록을 지움
+func DeleteWebhookController(s *webhook.Service) func(context *gin.Context) {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.Error(exception.Invalid("id", "잘못된 id 형식입니다."))
+			return
+		}
+
+		if err := s.Unsubscribe(c.Request.Context(), int64(id)); err == nil {
+			c.JSON(http.StatusOK, gin.H{
+				"result": true,
+			})
+			return
+		} else {
+			c.Error(err)
+			return
+		}
+	}
+}
+
+// DeliveriesController 는 webhook 의 전달 기록을 최근 순서로 응답하며 ?status=failed 처럼 상태로 조회할 수 있음
+func DeliveriesController(s *webhook.Service) func(context *gin.Context) {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.Error(exception.Invalid("id", "잘못된 id 형식입니다."))
+			return
+		}
+
+		if res, err := s.Deliveries(c.Request.Context(), int64(id), c.Query("status")); err == nil {
+			c.JSON(http.StatusOK, gin.H{
+				"result": res,
+			})
+			return
+		} else {
+			c.Error(err)
+			return
+		}
+	}
+}
+
+func DeliveryController(s *webhook.Service) func(context *gin.Context) {
+	return func(c *gin.Context) {
+		id, deliveryID, err := deliveryParams(c)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		if res, err := s.FindDelivery(c.Request.Context(), id, deliveryID); err == nil {
+			c.JSON(http.StatusOK, gin.H{
+				"result": res,
+			})
+			return
+		} else {
+			c.Error(err)
+			return
+		}
+	}
+}
+
+// ReplayDeliveryController 는 같은 payload 를 새 전달로 다시 보내고 새 전달 기록을 응답
+func ReplayDeliveryController(s *webhook.Service) func(context *gin.Context) {
+	return func(c *gin.Context) {
+		id, deliveryID, err := deliveryParams(c)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		if res, err := s.Replay(c.Request.Context(), id, deliveryID); err == nil {
+			c.JSON(http.StatusAccepted, gin.H{
+				"result": res,
+			})
+			return
+		} else {
+			c.Error(err)
+			return
+		}
+	}
+}
+
+func deliveryParams(c *gin.Context) (int64, int64, error) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return 0, 0, exception.Invalid("id", "잘못된 id 형식입니다.")
+	}
+	deliveryID, err := strconv.Atoi(c.Param("delivery"))
+	if err != nil {
+		return 0, 0, exception.Invalid("delivery", "잘못된 delivery 형식입니다.")
+	}
+	return int64(id), int64(deliveryID), nil
+}
@@ -1,9 +1,129 @@
 package exception
 
-import "github.com/pkg/errors"
+import (
+	"fmt"
+	"net/http"
+)
+
+// Code 는 클라이언트가 오류 종류를 구분하는 값
+type Code string
+
+const (
+	CodeUnavailable      Code = "UNAVAILABLE"
+	CodeInvalidCondition Code = "INVALID_CONDITION"
+	CodeInvalidRequest   Code = "INVALID_REQUEST"
+	CodeNotFound         Code = "NOT_FOUND"
+	CodeRoomNotFound     Code = "ROOM_NOT_FOUND"
+	CodeRoomInUse        Code = "ROOM_IN_USE"
+	CodeSeriesNotFound   Code = "SERIES_NOT_FOUND"
+	CodeInvalidToken     Code = "INVALID_TOKEN"
+	CodeUnauthorized     Code = "UNAUTHORIZED"
+	CodeForbidden        Code = "FORBIDDEN"
+	CodeUserExists       Code = "USER_EXISTS"
+	CodeUserNotFound     Code = "USER_NOT_FOUND"
+	CodeNotPending       Code = "NOT_PENDING"
+	CodeWaitlistNotFound Code = "WAITLIST_NOT_FOUND"
+	CodeWebhookNotFound  Code = "WEBHOOK_NOT_FOUND"
+	CodeDeliveryNotFound Code = "DELIVERY_NOT_FOUND"
+	CodeInternal         Code = "INTERNAL"
+)
 
 var (
-	Unavailable      = errors.New("예약이 불가능합니다")
-	InvalidCondition = errors.New("잘못된 요청입니다")
-	InvalidRequest   = errors.New("잘못된 요청입니다")
+	Unavailable      = newError(CodeUnavailable, http.StatusConflict, "예약이 불가능합니다")
+	InvalidCondition = newError(CodeInvalidCondition, http.StatusUnprocessableEntity, "예약 조건이 맞지 않습니다")
+	InvalidRequest   = newError(CodeInvalidRequest, http.StatusBadRequest, "잘못된 요청입니다")
+	NotFound         = newError(CodeNotFound, http.StatusNotFound, "예약을 찾을 수 없습니다")
+	RoomNotFound     = newError(CodeRoomNotFound, http.StatusNotFound, "회의실을 찾을 수 없습니다")
+	RoomInUse        = newError(CodeRoomInUse, http.StatusConflict, "앞으로 예정된 예약이 있는 회의실입니다")
+	SeriesNotFound   = newError(CodeSeriesNotFound, http.StatusNotFound, "반복 예약을 찾을 수 없습니다")
+	InvalidToken     = newError(CodeInvalidToken, http.StatusForbidden, "잘못된 구독 token 입니다")
+	Unauthorized     = newError(CodeUnauthorized, http.StatusUnauthorized, "로그인이 필요합니다")
+	Forbidden        = newError(CodeForbidden, http.StatusForbidden, "권한이 없습니다")
+	UserExists       = newError(CodeUserExists, http.StatusConflict, "이미 있는 사용자입니다")
+	UserNotFound     = newError(CodeUserNotFound, http.StatusNotFound, "사용자를 찾을 수 없습니다")
+	NotPending       = newError(CodeNotPending, http.StatusConflict, "승인 대기 중인 예약이 아닙니다")
+	WaitlistNotFound = newError(CodeWaitlistNotFound, http.StatusNotFound, "대기를 찾을 수 없습니다")
+	WebhookNotFound  = newError(CodeWebhookNotFound, http.StatusNotFound, "webhook 을 찾을 수 없습니다")
+	DeliveryNotFound = newError(CodeDeliveryNotFound, http.StatusNotFound, "webhook 전달 기록을 찾을 수 없습니다")
 )
+
+// Error 는 Code 와 응답할 HTTP 상태(Status) 를 가진 오류
+// 위의 값들을 그대로 반환하므로 errors.Cause(err) == exception.NotFound 처럼 비교
+type Error struct {
+	Code    Code
+	Status  int
+	Message string
+}
+
+func newError(code Code, status int, message string) *Error {
+	return &Error{Code: code, Status: status, Message: message}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// WithDetail 은 이번 요청에서 무엇이 잘못되었는지를 덧붙임. errors.Cause 는 여전히 e
+func (e *Error) WithDetail(detail string, fields ...Field) error {
+	return &Detailed{Err: e, Detail: detail, Fields: fields}
+}
+
+// Field 는 요청의 어느 항목(Name)이 왜 잘못되었는지
+type Field struct {
+	Name    string `json:"name"`
+	Message string `json:"message"`
+}
+
+// Detailed 는 Error 에 설명(Detail) 과 잘못된 항목(Fields) 을 덧붙인 오류
+// 메시지는 Err 와 같으므로 기존 메시지 비교도 그대로 동작
+type Detailed struct {
+	Err    *Error
+	Detail string
+	Fields []Field
+}
+
+func (d *Detailed) Error() string {
+	return d.Err.Message
+}
+
+func (d *Detailed) Cause() error {
+	return d.Err
+}
+
+func (d *Detailed) Unwrap() error {
+	return d.Err
+}
+
+// Invalid 는 name 항목이 잘못된 InvalidRequest
+func Invalid(name, format string, args ...interface{}) error {
+	message := fmt.Sprintf(format, args...)
+	return InvalidRequest.WithDetail(message, Field{Name: name, Message: message})
+}
+
+// Mismatch 는 name 항목이 예약 조건에 맞지 않는 InvalidCondition
+func Mismatch(name, format string, args ...interface{}) error {
+	message := fmt.Sprintf(format, args...)
+	return InvalidCondition.WithDetail(message, Field{Name: name, Message: message})
+}
+
+// Find 는 err 가 감싸고 있는 Error 와 덧붙인 설명을 찾음
+// Error 가 없으면 nil, 덧붙인 설명이 없으면 Detailed 는 nil
+func Find(err error) (*Error, *Detailed) {
+	var detailed *Detailed
+	for err != nil {
+		switch e := err.(type) {
+		case *Error:
+			return e, detailed
+		case *Detailed:
+			if detailed == nil {
+				detailed = e
+			}
+		}
+		causer, ok := err.(interface{ Cause() error })
+		if !ok {
+			return nil, nil
+		}
+		err = causer.Cause()
+	}
+	return nil, nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rutesun/reservation/calendar"
+	"github.com/rutesun/reservation/reservation"
+)
+
+// runImport 는 iCalendar 파일의 일정을 예약으로 가져오고 결과를 출력
+//
+//	import-ics [-dry-run] [-user <name>] [-timezone <tz>] <file.ics>
+func runImport(s *reservation.Service, args []string) error {
+	flags := flag.NewFlagSet("import-ics", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "예약하지 않고 가져올 수 있는지만 확인")
+	user := flags.String("user", "", "ORGANIZER 가 없는 일정의 사용자")
+	timezone := flags.String("timezone", "", "시간대가 없는 시간의 기준 (ex: Asia/Seoul)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New("사용법: import-ics [-dry-run] [-user <name>] [-timezone <tz>] <file.ics>")
+	}
+
+	loc := time.Local
+	if *timezone != "" {
+		var err error
+		if loc, err = time.LoadLocation(*timezone); err != nil {
+			return errors.Wrapf(err, "잘못된 timezone 입니다: %s", *timezone)
+		}
+	}
+
+	f, err := os.Open(flags.Arg(0))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer f.Close()
+
+	events, err := calendar.Parse(f, loc)
+	if err != nil {
+		return err
+	}
+	report, err := calendar.Import(context.Background(), s, events, calendar.ImportOptions{User: *user, DryRun: *dryRun})
+	if report != nil {
+		printReport(report)
+	}
+	return err
+}
+
+func printReport(report *calendar.Report) {
+	for _, e := range report.Imported {
+		fmt.Printf("imported     %s  %s  %s  %s\n", e.Start.Format(time.RFC3339), e.Location, e.Summary, e.UID)
+	}
+	for _, e := range report.Conflicting {
+		fmt.Printf("conflicting  %s  %s  %s  %s (%d 건 겹침)\n", e.Start.Format(time.RFC3339), e.Location, e.Summary, e.UID, len(e.Conflicts))
+	}
+	for _, e := range report.Skipped {
+		fmt.Printf("skipped      %s  %s  %s  %s: %s\n", e.Start.Format(time.RFC3339), e.Location, e.Summary, e.UID, e.Reason)
+	}
+
+	suffix := ""
+	if report.DryRun {
+		suffix = " (dry-run, 예약하지 않음)"
+	}
+	fmt.Printf("가져옴 %d, 겹침 %d, 건너뜀 %d%s\n", len(report.Imported), len(report.Conflicting), len(report.Skipped), suffix)
+}
@@ -1,20 +1,40 @@
 package integration
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
 	"testing"
 	"time"
 
 	"reflect"
 
+	"github.com/pkg/errors"
+	"github.com/rutesun/reservation/account"
 	"github.com/rutesun/reservation/config"
 	"github.com/rutesun/reservation/exception"
 	"github.com/rutesun/reservation/mariadb"
+	"github.com/rutesun/reservation/memory"
+	"github.com/rutesun/reservation/migration"
+	"github.com/rutesun/reservation/postgres"
 	"github.com/rutesun/reservation/reservation"
+	"github.com/rutesun/reservation/sqlite"
+	"github.com/rutesun/reservation/webhook"
 	"github.com/stretchr/testify/assert"
 )
 
-var service *reservation.Service
+var (
+	service *reservation.Service
+	hooks   *webhook.Service
+)
+
+// webhookAttempts 는 테스트에서 webhook 전달을 failed 로 만들 때까지 보내는 횟수
+const webhookAttempts = 2
 
 func init() {
 	con, err := config.Parse()
@@ -23,22 +43,49 @@ func init() {
 	}
 	fmt.Printf("\n%+v\n", con)
 	setting, err := config.Make(con)
-	if err = setting.DB.Ping(); err != nil {
+	if err != nil {
 		panic(err)
 	}
-	mariadb := mariadb.New(setting.DB)
-	service = reservation.New(mariadb)
+
+	if setting.Driver != config.Memory {
+		m, err := migration.New(setting.DB, setting.Driver)
+		if err != nil {
+			panic(err)
+		}
+		if err = m.Up(); err != nil {
+			panic(err)
+		}
+	}
+
+	// DATABASE_DRIVER=memory 로 실행하면 DB 없이 테스트 가능
+	switch setting.Driver {
+	case config.Memory:
+		repo := memory.New(setting.Rooms...)
+		service, hooks = reservation.New(repo), webhook.New(repo, webhookAttempts, time.Minute)
+	case config.Postgres:
+		repo := postgres.New(setting.DB, setting.QueryTimeout)
+		service, hooks = reservation.New(repo), webhook.New(repo, webhookAttempts, time.Minute)
+	case config.SQLite:
+		repo := sqlite.New(setting.DB, setting.QueryTimeout)
+		service, hooks = reservation.New(repo), webhook.New(repo, webhookAttempts, time.Minute)
+	default:
+		repo := mariadb.New(setting.DB, setting.QueryTimeout)
+		service, hooks = reservation.New(repo), webhook.New(repo, webhookAttempts, time.Minute)
+	}
 }
 
 var (
+	ctx      = context.Background()
 	roomID   = int64(1)
 	userName = "Ted"
+	// amy 는 Ted 의 예약에 권한이 없는 사용자의 요청
+	amy = account.WithUser(ctx, &account.User{Name: "Amy", Role: account.Member})
 
 	date, _ = time.Parse(time.RFC3339, "2018-08-07T0:00:00+09:00")
 )
 
 func TestReservation_RoomList(t *testing.T) {
-	rooms, err := service.RoomList()
+	rooms, err := service.RoomList(ctx, reservation.RoomFilter{})
 	assert.NoError(t, err)
 
 	for _, r := range rooms {
@@ -49,7 +96,7 @@ func TestReservation_RoomList(t *testing.T) {
 func TestReservation_List(t *testing.T) {
 	st, _ := time.Parse(time.RFC3339, "2018-08-04T18:00:00+09:00")
 	et, _ := time.Parse(time.RFC3339, "2018-08-30T18:00:00+09:00")
-	_, err := service.List(st, et)
+	_, err := service.List(ctx, st, et)
 	assert.NoError(t, err)
 }
 
@@ -57,33 +104,33 @@ func TestReservation_Available(t *testing.T) {
 	st, _ := time.Parse(time.RFC3339, "2018-08-05T16:00:00+09:00")
 	et, _ := time.Parse(time.RFC3339, "2018-08-05T00:00:00+09:00")
 
-	_, err := service.Available(1, st, et)
-	assert.EqualError(t, err, exception.InvalidRequest.Error())
+	_, err := service.Available(ctx, 1, st, et)
+	assert.EqualError(t, err, exception.InvalidCondition.Error())
 }
 
 func TestReservation_Make(t *testing.T) {
 	st, _ := time.Parse(time.RFC3339, "2018-08-07T16:00:00+09:00")
 
-	t.Run("Invalid Request: 끝나는 시간이 시작 시간 보다 앞설 때 ", func(t *testing.T) {
+	t.Run("Invalid Condition: 끝나는 시간이 시작 시간 보다 앞설 때 ", func(t *testing.T) {
 		et, _ := time.Parse(time.RFC3339, "2018-08-07T00:00:00+09:00")
 
-		err := service.Make(roomID, userName, st, et, reservation.ExtraInfo{})
-		assert.EqualError(t, err, exception.InvalidRequest.Error())
+		_, err := service.Make(ctx, roomID, userName, st, et, reservation.ExtraInfo{})
+		assert.EqualError(t, err, exception.InvalidCondition.Error())
 
 	})
 
-	t.Run("Invalid Request: 정시, 30분 단위가 아닐 때", func(t *testing.T) {
+	t.Run("Invalid Condition: 정시, 30분 단위가 아닐 때", func(t *testing.T) {
 		et, _ := time.Parse(time.RFC3339, "2018-08-08T16:10:00+09:00")
 
-		err := service.Make(roomID, userName, st, et, reservation.ExtraInfo{})
-		assert.EqualError(t, err, exception.InvalidRequest.Error())
+		_, err := service.Make(ctx, roomID, userName, st, et, reservation.ExtraInfo{})
+		assert.EqualError(t, err, exception.InvalidCondition.Error())
 
 	})
 
 	t.Run("정상 예약", func(t *testing.T) {
 		et, _ := time.Parse(time.RFC3339, "2018-08-07T19:00:00+09:00")
 
-		err := service.Make(roomID, userName, st, et, reservation.ExtraInfo{})
+		_, err := service.Make(ctx, roomID, userName, st, et, reservation.ExtraInfo{})
 		if err != nil {
 			assert.EqualError(t, err, exception.Unavailable.Error())
 		}
@@ -93,7 +140,7 @@ func TestReservation_Make(t *testing.T) {
 		st, _ := time.Parse(time.RFC3339, "2018-08-07T14:00:00+09:00")
 		et, _ := time.Parse(time.RFC3339, "2018-08-07T16:00:00+09:00")
 
-		err := service.Make(roomID, userName, st, et, reservation.ExtraInfo{})
+		_, err := service.Make(ctx, roomID, userName, st, et, reservation.ExtraInfo{})
 		if err != nil {
 			assert.EqualError(t, err, exception.Unavailable.Error())
 		}
@@ -103,7 +150,7 @@ func TestReservation_Make(t *testing.T) {
 		st, _ := time.Parse(time.RFC3339, "2018-08-07T12:00:00+09:00")
 		et, _ := time.Parse(time.RFC3339, "2018-08-07T14:00:00+09:00")
 
-		err := service.Make(roomID, userName, st, et, reservation.ExtraInfo{Repeat: 10})
+		_, err := service.Make(ctx, roomID, userName, st, et, reservation.ExtraInfo{Repeat: 10})
 		if err != nil {
 			assert.EqualError(t, err, exception.Unavailable.Error())
 		}
@@ -115,7 +162,7 @@ func TestReservation_Integration(t *testing.T) {
 	st, _ := time.Parse(time.RFC3339, "2018-08-07T10:00:00+09:00")
 	et, _ := time.Parse(time.RFC3339, "2018-08-07T12:00:00+09:00")
 
-	err := service.Make(roomID, userName, st, et, reservation.ExtraInfo{})
+	_, err := service.Make(ctx, roomID, userName, st, et, reservation.ExtraInfo{})
 	if err != nil {
 		assert.EqualError(t, err, exception.Unavailable.Error())
 	}
@@ -123,10 +170,10 @@ func TestReservation_Integration(t *testing.T) {
 	st, _ = time.Parse(time.RFC3339, "2018-08-07T11:00:00+09:00")
 	et, _ = time.Parse(time.RFC3339, "2018-08-07T12:00:00+09:00")
 
-	err = service.Make(roomID, userName, st, et, reservation.ExtraInfo{})
+	_, err = service.Make(ctx, roomID, userName, st, et, reservation.ExtraInfo{})
 	assert.EqualError(t, err, exception.Unavailable.Error())
 
-	reservedMap, err := service.List(st, st.AddDate(0, 0, 1))
+	reservedMap, err := service.List(ctx, st, st.AddDate(0, 0, 1))
 	assert.NoError(t, err)
 
 	list, ok := reservedMap[roomID]
@@ -138,11 +185,910 @@ func TestReservation_Integration(t *testing.T) {
 	assert.True(t, detail.Room.ID > 0)
 
 	for _, detail := range list {
-		_, err = service.Cancel(detail.ID)
+		_, err = service.Cancel(ctx, detail.ID)
 		assert.NoError(t, err)
 	}
 
-	reservedMap, err = service.List(st, st.AddDate(0, 0, 1))
+	reservedMap, err = service.List(ctx, st, st.AddDate(0, 0, 1))
 	keys := reflect.ValueOf(reservedMap).MapKeys()
 	assert.Equal(t, len(keys), 0)
 }
+
+func TestReservation_Modify(t *testing.T) {
+	st, _ := time.Parse(time.RFC3339, "2018-08-09T10:00:00+09:00")
+	et, _ := time.Parse(time.RFC3339, "2018-08-09T11:00:00+09:00")
+
+	_, err := service.Make(ctx, roomID, userName, st, et, reservation.ExtraInfo{})
+	assert.NoError(t, err)
+	_, err = service.Make(ctx, roomID, userName, et.Add(time.Hour), et.Add(2*time.Hour), reservation.ExtraInfo{})
+	assert.NoError(t, err)
+
+	reservedMap, err := service.List(ctx, st, st.AddDate(0, 0, 1))
+	assert.NoError(t, err)
+	list := reservedMap[roomID]
+	assert.Len(t, list, 2)
+	target := list[0]
+	if list[1].Start.Equal(st) {
+		target = list[1]
+	}
+
+	t.Run("자기 자신과 겹치는 시간으로 이동", func(t *testing.T) {
+		newStart, newEnd := st.Add(30*time.Minute), et.Add(30*time.Minute)
+		memo := "30분 연기"
+		detail, err := service.Modify(ctx, target.ID, reservation.Modification{Start: &newStart, End: &newEnd, Memo: &memo})
+		assert.NoError(t, err)
+		assert.Equal(t, target.ID, detail.ID)
+		assert.True(t, newStart.Equal(detail.Start))
+		assert.Equal(t, memo, detail.Memo)
+		assert.Equal(t, userName, detail.User)
+	})
+
+	t.Run("다른 예약과 겹칠 때", func(t *testing.T) {
+		newEnd := et.Add(90 * time.Minute)
+		_, err := service.Modify(ctx, target.ID, reservation.Modification{End: &newEnd})
+		assert.EqualError(t, err, exception.Unavailable.Error())
+	})
+
+	t.Run("Invalid Condition: 정시, 30분 단위가 아닐 때", func(t *testing.T) {
+		newStart := st.Add(10 * time.Minute)
+		_, err := service.Modify(ctx, target.ID, reservation.Modification{Start: &newStart})
+		assert.EqualError(t, err, exception.InvalidCondition.Error())
+	})
+
+	t.Run("없는 예약", func(t *testing.T) {
+		_, err := service.Modify(ctx, -1, reservation.Modification{})
+		assert.EqualError(t, err, exception.NotFound.Error())
+	})
+
+	t.Run("없는 예약 취소", func(t *testing.T) {
+		_, err := service.Cancel(ctx, -1)
+		assert.Equal(t, exception.NotFound, errors.Cause(err))
+	})
+
+	t.Run("다른 사용자의 예약", func(t *testing.T) {
+		memo := "남의 예약"
+		_, err := service.Modify(amy, target.ID, reservation.Modification{Memo: &memo})
+		assert.Equal(t, exception.Forbidden, errors.Cause(err))
+
+		_, err = service.Cancel(amy, target.ID)
+		assert.Equal(t, exception.Forbidden, errors.Cause(err))
+
+		detail, err := service.Find(ctx, target.ID)
+		assert.NoError(t, err)
+		assert.NotEqual(t, memo, detail.Memo)
+	})
+
+	for _, detail := range list {
+		_, err = service.Cancel(ctx, detail.ID)
+		assert.NoError(t, err)
+	}
+}
+
+func TestReservation_Room(t *testing.T) {
+	room, err := service.CreateRoom(ctx, reservation.Room{Name: "임시 회의실"})
+	assert.NoError(t, err)
+	assert.True(t, room.ID > 0)
+
+	room, err = service.UpdateRoom(ctx, room.ID, reservation.Room{Name: "보관될 회의실"})
+	assert.NoError(t, err)
+	assert.Equal(t, "보관될 회의실", room.Name)
+
+	past, _ := time.Parse(time.RFC3339, "2018-08-10T10:00:00+09:00")
+	_, err = service.Make(ctx, room.ID, userName, past, past.Add(time.Hour), reservation.ExtraInfo{})
+	assert.NoError(t, err)
+
+	future := time.Now().Truncate(time.Hour).AddDate(0, 0, 1)
+	_, err = service.Make(ctx, room.ID, userName, future, future.Add(time.Hour), reservation.ExtraInfo{})
+	assert.NoError(t, err)
+
+	t.Run("예정된 예약이 있으면 보관 불가", func(t *testing.T) {
+		_, err := service.ArchiveRoom(ctx, room.ID, false)
+		assert.EqualError(t, err, exception.RoomInUse.Error())
+	})
+
+	t.Run("예정된 예약을 취소하고 보관", func(t *testing.T) {
+		_, err := service.JoinWaitlist(ctx, room.ID, "Amy", future, future.Add(time.Hour), "")
+		assert.NoError(t, err)
+
+		notified := &recorder{}
+		service.SetNotifier(notified)
+
+		canceled, err := service.ArchiveRoom(ctx, room.ID, true)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), canceled)
+		if events := notified.of(reservation.EventCancelled); assert.Len(t, events, 1) {
+			assert.Equal(t, room.ID, events[0].Reservation.Room.ID)
+		}
+
+		waiting, err := service.Waitlist(ctx, "Amy")
+		assert.NoError(t, err)
+		assert.Empty(t, waiting)
+
+		rooms, err := service.RoomList(ctx, reservation.RoomFilter{})
+		assert.NoError(t, err)
+		for _, r := range rooms {
+			assert.NotEqual(t, room.ID, r.ID)
+		}
+
+		_, err = service.Make(ctx, room.ID, userName, future, future.Add(time.Hour), reservation.ExtraInfo{})
+		assert.EqualError(t, err, exception.RoomNotFound.Error())
+	})
+
+	t.Run("지난 예약은 보관된 회의실 이름으로 조회", func(t *testing.T) {
+		reservedMap, err := service.List(ctx, past, past.AddDate(0, 0, 1))
+		assert.NoError(t, err)
+		list := reservedMap[room.ID]
+		assert.Len(t, list, 1)
+		assert.Equal(t, "보관될 회의실", list[0].Room.Name)
+
+		_, err = service.Cancel(ctx, list[0].ID)
+		assert.NoError(t, err)
+	})
+}
+
+func TestReservation_Policy(t *testing.T) {
+	room, err := service.CreateRoom(ctx, reservation.Room{Name: "디자인팀 회의실", Policy: reservation.PolicyGroup, Group: "design"})
+	assert.NoError(t, err)
+	defer service.ArchiveRoom(ctx, room.ID, true)
+
+	start, _ := time.Parse(time.RFC3339, "2031-03-03T10:00:00+09:00")
+	member := &account.User{Name: "Amy", Role: account.Member}
+	as := func(user *account.User) context.Context { return account.WithUser(ctx, user) }
+
+	t.Run("group 에 속하지 않은 사용자는 예약 불가", func(t *testing.T) {
+		_, err := service.Make(as(member), room.ID, "Amy", start, start.Add(time.Hour), reservation.ExtraInfo{})
+		assert.Equal(t, exception.Forbidden, errors.Cause(err))
+	})
+
+	t.Run("group 에 속한 사용자는 자신의 이름으로만 예약", func(t *testing.T) {
+		designer := &account.User{Name: "Amy", Role: account.Member, Groups: []string{"design"}}
+		_, err := service.Make(as(designer), room.ID, userName, start, start.Add(time.Hour), reservation.ExtraInfo{})
+		assert.Equal(t, exception.Forbidden, errors.Cause(err))
+
+		r, err := service.Make(as(designer), room.ID, "Amy", start, start.Add(time.Hour), reservation.ExtraInfo{})
+		assert.NoError(t, err)
+		_, err = service.Cancel(as(designer), r.ID)
+		assert.NoError(t, err)
+	})
+
+	t.Run("readonly 회의실은 관리자만 예약", func(t *testing.T) {
+		_, err := service.UpdateRoom(as(member), room.ID, reservation.Room{Name: room.Name, Policy: reservation.PolicyReadOnly})
+		assert.Equal(t, exception.Forbidden, errors.Cause(err))
+
+		manager := &account.User{Name: "Kim", Role: account.FacilityManager}
+		_, err = service.UpdateRoom(as(manager), room.ID, reservation.Room{Name: room.Name, Policy: reservation.PolicyReadOnly})
+		assert.NoError(t, err)
+
+		designer := &account.User{Name: "Amy", Role: account.Member, Groups: []string{"design"}}
+		_, err = service.Make(as(designer), room.ID, "Amy", start, start.Add(time.Hour), reservation.ExtraInfo{})
+		assert.Equal(t, exception.Forbidden, errors.Cause(err))
+
+		r, err := service.Make(as(manager), room.ID, "Amy", start, start.Add(time.Hour), reservation.ExtraInfo{})
+		assert.NoError(t, err)
+		detail, err := service.Find(ctx, r.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, "Amy", detail.User)
+	})
+
+	t.Run("guest 는 조회만 가능", func(t *testing.T) {
+		guest := as(account.Anonymous())
+		_, err := service.Make(guest, room.ID, "", start, start.Add(time.Hour), reservation.ExtraInfo{})
+		assert.Equal(t, exception.Forbidden, errors.Cause(err))
+		_, err = service.Utilization(guest, start, start.AddDate(0, 0, 1), reservation.GroupByRoom, time.UTC)
+		assert.Equal(t, exception.Forbidden, errors.Cause(err))
+
+		_, err = service.RoomList(guest, reservation.RoomFilter{})
+		assert.NoError(t, err)
+	})
+}
+
+func TestReservation_Approval(t *testing.T) {
+	room, err := service.CreateRoom(ctx, reservation.Room{Name: "대회의실", RequiresApproval: true})
+	assert.NoError(t, err)
+	defer service.ArchiveRoom(ctx, room.ID, true)
+
+	start, _ := time.Parse(time.RFC3339, "2031-04-07T10:00:00+09:00")
+	member := account.WithUser(ctx, &account.User{Name: "Amy", Role: account.Member})
+	manager := account.WithUser(ctx, &account.User{Name: "Kim", Role: account.FacilityManager})
+
+	result, err := service.Make(member, room.ID, "Amy", start, start.Add(time.Hour), reservation.ExtraInfo{})
+	assert.NoError(t, err)
+	assert.Equal(t, reservation.StatusPending, result.Status)
+	id := result.ID
+
+	t.Run("승인 대기 중인 예약도 시간을 차지", func(t *testing.T) {
+		_, err := service.Make(manager, room.ID, "Kim", start, start.Add(time.Hour), reservation.ExtraInfo{})
+		assert.Equal(t, exception.Unavailable, errors.Cause(err))
+
+		reservedMap, err := service.List(ctx, start.AddDate(0, 0, -1), start.AddDate(0, 0, 1))
+		assert.NoError(t, err)
+		if assert.Len(t, reservedMap[room.ID], 1) {
+			assert.Equal(t, reservation.StatusPending, reservedMap[room.ID][0].Status)
+		}
+	})
+
+	t.Run("관리자만 승인", func(t *testing.T) {
+		_, err := service.Approve(member, id)
+		assert.Equal(t, exception.Forbidden, errors.Cause(err))
+
+		detail, err := service.Approve(manager, id)
+		assert.NoError(t, err)
+		assert.Equal(t, reservation.StatusApproved, detail.Status)
+
+		_, err = service.Reject(manager, id)
+		assert.Equal(t, exception.NotPending, errors.Cause(err))
+	})
+
+	t.Run("시간을 바꾸면 다시 승인 대기", func(t *testing.T) {
+		memo := "안건 추가"
+		detail, err := service.Modify(member, id, reservation.Modification{Memo: &memo})
+		assert.NoError(t, err)
+		assert.Equal(t, reservation.StatusApproved, detail.Status)
+
+		later := start.Add(time.Hour)
+		end := later.Add(time.Hour)
+		detail, err = service.Modify(member, id, reservation.Modification{Start: &later, End: &end})
+		assert.NoError(t, err)
+		assert.Equal(t, reservation.StatusPending, detail.Status)
+	})
+
+	t.Run("거절된 예약의 시간은 다시 예약 가능", func(t *testing.T) {
+		detail, err := service.Reject(manager, id)
+		assert.NoError(t, err)
+		assert.Equal(t, reservation.StatusRejected, detail.Status)
+
+		_, err = service.Cancel(member, id)
+		assert.Equal(t, exception.NotFound, errors.Cause(err))
+
+		result, err := service.Make(manager, room.ID, "Kim", detail.Start, detail.End, reservation.ExtraInfo{})
+		assert.NoError(t, err)
+		assert.Equal(t, reservation.StatusApproved, result.Status, "관리자의 예약은 바로 승인")
+	})
+
+	t.Run("사용자 없이 가져온 예약도 승인 대기", func(t *testing.T) {
+		later := start.AddDate(0, 0, 1)
+		result, err := service.Make(ctx, room.ID, "Amy", later, later.Add(time.Hour), reservation.ExtraInfo{})
+		assert.NoError(t, err)
+		assert.Equal(t, reservation.StatusPending, result.Status)
+	})
+}
+
+// recorder 는 알림을 기록하는 Notifier
+type recorder struct {
+	mu     sync.Mutex
+	events []reservation.Event
+}
+
+func (r *recorder) Notify(ctx context.Context, event reservation.Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+// of 는 eventType 인 알림만
+func (r *recorder) of(eventType reservation.EventType) []reservation.Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var events []reservation.Event
+	for _, e := range r.events {
+		if e.Type == eventType {
+			events = append(events, e)
+		}
+	}
+	return events
+}
+
+func TestReservation_Waitlist(t *testing.T) {
+	room, err := service.CreateRoom(ctx, reservation.Room{Name: "소회의실"})
+	assert.NoError(t, err)
+	defer service.ArchiveRoom(ctx, room.ID, true)
+
+	notified := &recorder{}
+	service.SetNotifier(notified)
+
+	start, _ := time.Parse(time.RFC3339, "2031-05-12T10:00:00+09:00")
+	end := start.Add(time.Hour)
+	bob := account.WithUser(ctx, &account.User{Name: "Bob", Role: account.Member})
+
+	result, err := service.Make(ctx, room.ID, userName, start, end, reservation.ExtraInfo{})
+	assert.NoError(t, err)
+
+	_, err = service.Make(amy, room.ID, "Amy", start, end, reservation.ExtraInfo{})
+	assert.Equal(t, exception.Unavailable, errors.Cause(err))
+
+	waiting, err := service.JoinWaitlist(amy, room.ID, "Amy", start, end, "대기")
+	assert.NoError(t, err)
+	assert.Equal(t, room.Name, waiting.Room.Name)
+	_, err = service.JoinWaitlist(bob, room.ID, "Bob", start, end.Add(time.Hour), "")
+	assert.NoError(t, err)
+
+	t.Run("대기 확인", func(t *testing.T) {
+		_, err := service.JoinWaitlist(amy, room.ID, "Amy", end.Add(time.Hour), end.Add(2*time.Hour), "")
+		assert.Equal(t, exception.InvalidCondition, errors.Cause(err), "바로 예약할 수 있는 시간")
+
+		_, err = service.JoinWaitlist(amy, room.ID, userName, start, end, "")
+		assert.Equal(t, exception.Forbidden, errors.Cause(err))
+
+		assert.Equal(t, exception.Forbidden, errors.Cause(service.LeaveWaitlist(bob, waiting.ID)))
+
+		list, err := service.Waitlist(ctx, "Amy")
+		assert.NoError(t, err)
+		assert.Len(t, list, 1)
+	})
+
+	t.Run("취소하면 먼저 들어온 대기를 예약", func(t *testing.T) {
+		_, err := service.Cancel(ctx, result.ID)
+		assert.NoError(t, err)
+
+		reservedMap, err := service.List(ctx, start.AddDate(0, 0, -1), start.AddDate(0, 0, 1))
+		assert.NoError(t, err)
+		if assert.Len(t, reservedMap[room.ID], 1) {
+			assert.Equal(t, "Amy", reservedMap[room.ID][0].User)
+			assert.Equal(t, "대기", reservedMap[room.ID][0].Memo)
+		}
+
+		if cancelled := notified.of(reservation.EventCancelled); assert.Len(t, cancelled, 1) {
+			assert.Equal(t, result.ID, cancelled[0].Reservation.ID)
+			assert.Equal(t, reservation.StatusCancelled, cancelled[0].Reservation.Status)
+		}
+		if promoted := notified.of(reservation.EventPromoted); assert.Len(t, promoted, 1) {
+			assert.Equal(t, "Amy", promoted[0].Reservation.User)
+		}
+
+		list, err := service.Waitlist(ctx, "Amy")
+		assert.NoError(t, err)
+		assert.Empty(t, list)
+
+		// Amy 의 예약과 겹치는 Bob 의 대기는 남아 있음
+		list, err = service.Waitlist(ctx, "Bob")
+		assert.NoError(t, err)
+		if assert.Len(t, list, 1) {
+			assert.NoError(t, service.LeaveWaitlist(bob, list[0].ID))
+		}
+	})
+}
+
+func TestReservation_CheckIn(t *testing.T) {
+	first, err := service.CreateRoom(ctx, reservation.Room{Name: "체크인1"})
+	assert.NoError(t, err)
+	defer service.ArchiveRoom(ctx, first.ID, true)
+	second, err := service.CreateRoom(ctx, reservation.Room{Name: "체크인2"})
+	assert.NoError(t, err)
+	defer service.ArchiveRoom(ctx, second.ID, true)
+
+	service.SetNoShowGrace(30 * time.Minute)
+	defer service.SetNoShowGrace(reservation.DefaultNoShowGrace)
+	notified := &recorder{}
+	service.SetNotifier(notified)
+
+	// 진행 중인 예약
+	start := time.Now().Truncate(30 * time.Minute)
+	end := start.Add(time.Hour)
+	checkedIn, err := service.Make(amy, first.ID, "Amy", start, end, reservation.ExtraInfo{})
+	assert.NoError(t, err)
+	absent, err := service.Make(ctx, second.ID, userName, start, end, reservation.ExtraInfo{})
+	assert.NoError(t, err)
+
+	t.Run("체크인", func(t *testing.T) {
+		_, err := service.CheckIn(amy, absent.ID)
+		assert.Equal(t, exception.Forbidden, errors.Cause(err))
+
+		detail, err := service.CheckIn(amy, checkedIn.ID)
+		assert.NoError(t, err)
+		assert.NotNil(t, detail.CheckedInAt)
+
+		_, err = service.CheckIn(amy, checkedIn.ID)
+		assert.NoError(t, err, "이미 체크인한 예약")
+
+		tomorrow, err := service.Make(amy, first.ID, "Amy", start.AddDate(0, 0, 1), end.AddDate(0, 0, 1), reservation.ExtraInfo{})
+		assert.NoError(t, err)
+		_, err = service.CheckIn(amy, tomorrow.ID)
+		assert.Equal(t, exception.InvalidCondition, errors.Cause(err))
+		_, err = service.Cancel(amy, tomorrow.ID)
+		assert.NoError(t, err)
+	})
+
+	t.Run("체크인하지 않은 예약은 no_show", func(t *testing.T) {
+		released, err := service.ReleaseNoShows(ctx, end.Add(-time.Second))
+		assert.NoError(t, err)
+		assert.Contains(t, released, absent.ID)
+		assert.NotContains(t, released, checkedIn.ID)
+
+		detail, err := service.Find(ctx, absent.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, reservation.StatusNoShow, detail.Status)
+
+		able, err := service.Available(ctx, second.ID, start, end)
+		assert.NoError(t, err)
+		assert.True(t, able, "no_show 인 예약의 시간은 다시 예약 가능")
+
+		noShows := notified.of(reservation.EventNoShow)
+		assert.NotEmpty(t, noShows)
+		for _, e := range noShows {
+			assert.NotEqual(t, checkedIn.ID, e.Reservation.ID)
+		}
+
+		_, err = service.NoShows(amy, start, end)
+		assert.Equal(t, exception.Forbidden, errors.Cause(err))
+		counts, err := service.NoShows(ctx, start, end)
+		assert.NoError(t, err)
+		assert.Contains(t, counts, reservation.NoShowCount{User: userName, Count: 1})
+	})
+}
+
+// hookReceiver 는 webhook 을 받아 서명을 확인하고 기록하는 서버
+type hookReceiver struct {
+	mu       sync.Mutex
+	secret   string
+	status   int
+	received []reservation.EventType
+	invalid  int
+}
+
+func (h *hookReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	body, _ := ioutil.ReadAll(r.Body)
+	timestamp, _ := strconv.ParseInt(r.Header.Get("X-Webhook-Timestamp"), 10, 64)
+	if r.Header.Get("X-Webhook-Signature") != webhook.Sign(h.secret, timestamp, body) {
+		h.invalid++
+	}
+	var payload struct {
+		Type reservation.EventType `json:"type"`
+	}
+	json.Unmarshal(body, &payload)
+	h.received = append(h.received, payload.Type)
+	w.WriteHeader(h.status)
+}
+
+func TestReservation_Webhook(t *testing.T) {
+	room, err := service.CreateRoom(ctx, reservation.Room{Name: "webhook 회의실"})
+	assert.NoError(t, err)
+	defer service.ArchiveRoom(ctx, room.ID, true)
+	other, err := service.CreateRoom(ctx, reservation.Room{Name: "webhook 다른 회의실"})
+	assert.NoError(t, err)
+	defer service.ArchiveRoom(ctx, other.ID, true)
+
+	receiver := &hookReceiver{secret: "secret", status: http.StatusOK}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	_, err = hooks.Subscribe(amy, server.URL, "", nil, nil)
+	assert.Equal(t, exception.Forbidden, errors.Cause(err), "관리자만 등록")
+	_, err = hooks.Subscribe(ctx, server.URL, "", []string{"reservation.unknown"}, nil)
+	assert.Equal(t, exception.InvalidRequest, errors.Cause(err))
+
+	subscription, err := hooks.Subscribe(ctx, server.URL, receiver.secret, []string{"reservation.created,reservation.cancelled"}, &room.ID)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer hooks.Unsubscribe(ctx, subscription.ID)
+	assert.Equal(t, []reservation.EventType{reservation.EventCancelled, reservation.EventCreated}, subscription.Events)
+	service.SetNotifier(hooks)
+
+	start, _ := time.Parse(time.RFC3339, "2031-06-02T10:00:00+09:00")
+	end := start.Add(time.Hour)
+	deliver := func(now time.Time) {
+		_, err := hooks.Deliver(ctx, now)
+		assert.NoError(t, err)
+	}
+
+	t.Run("회의실과 event 가 맞는 webhook 만 서명하여 전달", func(t *testing.T) {
+		result, err := service.Make(ctx, room.ID, userName, start, end, reservation.ExtraInfo{})
+		assert.NoError(t, err)
+		memo := "변경"
+		_, err = service.Modify(ctx, result.ID, reservation.Modification{Memo: &memo})
+		assert.NoError(t, err)
+		_, err = service.Make(ctx, other.ID, userName, start, end, reservation.ExtraInfo{})
+		assert.NoError(t, err)
+		deliver(time.Now())
+
+		receiver.mu.Lock()
+		assert.Equal(t, []reservation.EventType{reservation.EventCreated}, receiver.received)
+		assert.Zero(t, receiver.invalid)
+		receiver.mu.Unlock()
+
+		list, err := hooks.Deliveries(ctx, subscription.ID, "succeeded")
+		assert.NoError(t, err)
+		if assert.Len(t, list, 1) {
+			assert.Equal(t, 1, list[0].Attempts)
+			assert.Equal(t, http.StatusOK, list[0].ResponseStatus)
+			assert.NotNil(t, list[0].DeliveredAt)
+		}
+
+		found, err := hooks.FindSubscription(ctx, subscription.ID)
+		assert.NoError(t, err)
+		assert.Empty(t, found.Secret, "secret 은 등록할 때만 응답")
+	})
+
+	t.Run("실패하면 다시 보내고 replay", func(t *testing.T) {
+		receiver.mu.Lock()
+		receiver.status = http.StatusServiceUnavailable
+		receiver.mu.Unlock()
+
+		result, err := service.Make(ctx, room.ID, userName, start.Add(2*time.Hour), end.Add(2*time.Hour), reservation.ExtraInfo{})
+		assert.NoError(t, err)
+		deliver(time.Now())
+
+		list, err := hooks.Deliveries(ctx, subscription.ID, "pending")
+		assert.NoError(t, err)
+		if !assert.Len(t, list, 1) || !assert.NotNil(t, list[0].NextAttemptAt) {
+			return
+		}
+		assert.Equal(t, http.StatusServiceUnavailable, list[0].ResponseStatus)
+		assert.True(t, list[0].NextAttemptAt.After(time.Now()), "backoff 뒤 다시 보냄")
+
+		deliver(time.Now().Add(time.Hour))
+		failed, err := hooks.FindDelivery(ctx, subscription.ID, list[0].ID)
+		assert.NoError(t, err)
+		assert.Equal(t, webhook.DeliveryFailed, failed.Status)
+		assert.Equal(t, webhookAttempts, failed.Attempts)
+
+		receiver.mu.Lock()
+		receiver.status = http.StatusOK
+		receiver.mu.Unlock()
+
+		_, err = hooks.Replay(ctx, subscription.ID+1000, failed.ID)
+		assert.Equal(t, exception.DeliveryNotFound, errors.Cause(err))
+		replay, err := hooks.Replay(ctx, subscription.ID, failed.ID)
+		assert.NoError(t, err)
+		assert.JSONEq(t, string(failed.Payload), string(replay.Payload))
+		deliver(time.Now())
+
+		replayed, err := hooks.FindDelivery(ctx, subscription.ID, replay.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, webhook.DeliverySucceeded, replayed.Status)
+
+		_, err = service.Cancel(ctx, result.ID)
+		assert.NoError(t, err)
+		deliver(time.Now())
+		receiver.mu.Lock()
+		assert.Equal(t, reservation.EventCancelled, receiver.received[len(receiver.received)-1])
+		receiver.mu.Unlock()
+	})
+
+	t.Run("삭제하면 전달 기록도 삭제", func(t *testing.T) {
+		assert.NoError(t, hooks.Unsubscribe(ctx, subscription.ID))
+		_, err := hooks.Deliveries(ctx, subscription.ID, "")
+		assert.Equal(t, exception.WebhookNotFound, errors.Cause(err))
+	})
+}
+
+func TestReservation_RoomFilter(t *testing.T) {
+	room, err := service.CreateRoom(ctx, reservation.Room{
+		Name: "화상 회의실", Capacity: 10, Building: "별관", Floor: "2",
+		Equipment: []string{" VC", "screen,vc"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"screen", "vc"}, room.Equipment)
+
+	rooms, err := service.RoomList(ctx, reservation.RoomFilter{MinCapacity: 10, Equipment: []string{"Vc"}})
+	assert.NoError(t, err)
+	ids := []int64{}
+	for _, r := range rooms {
+		ids = append(ids, r.ID)
+	}
+	assert.Contains(t, ids, room.ID)
+
+	rooms, err = service.RoomList(ctx, reservation.RoomFilter{MinCapacity: 11})
+	assert.NoError(t, err)
+	for _, r := range rooms {
+		assert.NotEqual(t, room.ID, r.ID)
+	}
+
+	_, err = service.RoomList(ctx, reservation.RoomFilter{MinCapacity: -1})
+	assert.EqualError(t, err, exception.InvalidRequest.Error())
+
+	_, err = service.ArchiveRoom(ctx, room.ID, false)
+	assert.NoError(t, err)
+}
+
+func TestReservation_Search(t *testing.T) {
+	room, err := service.CreateRoom(ctx, reservation.Room{Name: "검색용 회의실", Capacity: 30})
+	assert.NoError(t, err)
+	defer service.ArchiveRoom(ctx, room.ID, true)
+
+	at := func(clock string) time.Time {
+		t, _ := time.Parse(time.RFC3339, "2018-09-03T"+clock+":00+09:00")
+		return t
+	}
+	for _, r := range [][2]string{{"11:30", "12:30"}, {"13:00", "14:00"}, {"15:30", "16:00"}} {
+		_, err := service.Make(ctx, room.ID, userName, at(r[0]), at(r[1]), reservation.ExtraInfo{})
+		assert.NoError(t, err)
+	}
+
+	result, err := service.Search(ctx, at("12:10"), at("17:00"), time.Hour, reservation.RoomFilter{MinCapacity: 30})
+	assert.NoError(t, err)
+	if assert.Len(t, result, 1) {
+		assert.Equal(t, room.ID, result[0].Room.ID)
+		assert.Equal(t, []reservation.Slot{
+			{Start: at("14:00"), End: at("15:00")},
+			{Start: at("14:30"), End: at("15:30")},
+			{Start: at("16:00"), End: at("17:00")},
+		}, result[0].Slots)
+	}
+
+	t.Run("30분 단위가 아닌 duration", func(t *testing.T) {
+		_, err := service.Search(ctx, at("12:00"), at("17:00"), 45*time.Minute, reservation.RoomFilter{})
+		assert.EqualError(t, err, exception.InvalidCondition.Error())
+	})
+}
+
+func TestReservation_MakeWithRule(t *testing.T) {
+	room, err := service.CreateRoom(ctx, reservation.Room{Name: "반복 회의실"})
+	assert.NoError(t, err)
+	defer service.ArchiveRoom(ctx, room.ID, true)
+
+	seoul, _ := time.LoadLocation("Asia/Seoul")
+	rule, err := reservation.ParseRule("FREQ=DAILY;BYDAY=MO,TU,WE,TH,FR;COUNT=5", seoul)
+	assert.NoError(t, err)
+
+	st, _ := time.Parse(time.RFC3339, "2018-09-07T10:00:00+09:00")
+	_, err = service.Make(ctx, room.ID, userName, st, st.Add(time.Hour), reservation.ExtraInfo{Rule: rule})
+	assert.NoError(t, err)
+
+	reservedMap, err := service.List(ctx, st, st.AddDate(0, 0, 8))
+	assert.NoError(t, err)
+	list := reservedMap[room.ID]
+	if assert.Len(t, list, 5) {
+		assert.Equal(t, time.Friday, list[0].Start.In(seoul).Weekday())
+		assert.Equal(t, time.Monday, list[1].Start.In(seoul).Weekday())
+	}
+}
+
+func TestReservation_Series(t *testing.T) {
+	room, err := service.CreateRoom(ctx, reservation.Room{Name: "시리즈 회의실"})
+	assert.NoError(t, err)
+	defer service.ArchiveRoom(ctx, room.ID, true)
+
+	notified := &recorder{}
+	service.SetNotifier(notified)
+
+	st, _ := time.Parse(time.RFC3339, "2031-10-06T10:00:00+09:00")
+	_, err = service.Make(ctx, room.ID, userName, st, st.Add(time.Hour), reservation.ExtraInfo{Repeat: 4, Memo: "주간회의"})
+	assert.NoError(t, err)
+	if created := notified.of(reservation.EventCreated); assert.Len(t, created, 1, "반복 예약은 한번만 알림") {
+		assert.Equal(t, 4, created[0].Occurrences)
+		assert.Equal(t, 1, created[0].Reservation.Occurrence)
+	}
+
+	reservedMap, err := service.List(ctx, st, st.AddDate(0, 1, 0))
+	assert.NoError(t, err)
+	list := reservedMap[room.ID]
+	if !assert.Len(t, list, 4) || !assert.NotNil(t, list[0].SeriesID) {
+		return
+	}
+	seriesID := *list[0].SeriesID
+	assert.Equal(t, "주간회의", list[0].Memo)
+
+	series, err := service.FindSeries(ctx, seriesID)
+	assert.NoError(t, err)
+	assert.Equal(t, "FREQ=WEEKLY;COUNT=4", series.Rule)
+
+	t.Run("한 회차만 변경", func(t *testing.T) {
+		memo := "이번 주는 오후"
+		start, end := st.AddDate(0, 0, 7).Add(4*time.Hour), st.AddDate(0, 0, 7).Add(5*time.Hour)
+		detail, err := service.ModifyOccurrence(ctx, seriesID, 2, reservation.Modification{Start: &start, End: &end, Memo: &memo})
+		assert.NoError(t, err)
+		assert.True(t, detail.Exception)
+		assert.Equal(t, 2, detail.Occurrence)
+
+		_, err = service.ModifyOccurrence(ctx, seriesID, 9, reservation.Modification{Memo: &memo})
+		assert.EqualError(t, err, exception.NotFound.Error())
+	})
+
+	t.Run("다른 사용자는 취소 불가", func(t *testing.T) {
+		_, err := service.CancelSeries(amy, seriesID)
+		assert.Equal(t, exception.Forbidden, errors.Cause(err))
+	})
+
+	t.Run("이후 회차 취소", func(t *testing.T) {
+		third := st.AddDate(0, 0, 14)
+		_, err := service.JoinWaitlist(amy, room.ID, "Amy", third, third.Add(time.Hour), "")
+		assert.NoError(t, err)
+
+		canceled, err := service.CancelFollowing(ctx, seriesID, 3)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), canceled)
+
+		if promoted := notified.of(reservation.EventPromoted); assert.Len(t, promoted, 1, "취소된 회차의 시간은 대기를 예약") {
+			assert.Equal(t, "Amy", promoted[0].Reservation.User)
+			assert.True(t, third.Equal(promoted[0].Reservation.Start))
+		}
+		waiting, err := service.Waitlist(ctx, "Amy")
+		assert.NoError(t, err)
+		assert.Empty(t, waiting)
+	})
+
+	t.Run("전체 취소", func(t *testing.T) {
+		canceled, err := service.CancelSeries(ctx, seriesID)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), canceled)
+		if cancelled := notified.of(reservation.EventCancelled); assert.Len(t, cancelled, 2) {
+			assert.Equal(t, 2, cancelled[1].Occurrences)
+			assert.Equal(t, 1, cancelled[1].Reservation.Occurrence)
+			assert.Equal(t, reservation.StatusCancelled, cancelled[1].Reservation.Status)
+		}
+
+		series, err := service.FindSeries(ctx, seriesID)
+		assert.NoError(t, err, "취소해도 반복 예약은 남음")
+		assert.Empty(t, series.Occurrences)
+	})
+}
+
+func TestReservation_MakePartial(t *testing.T) {
+	room, err := service.CreateRoom(ctx, reservation.Room{Name: "부분 예약 회의실"})
+	assert.NoError(t, err)
+	defer service.ArchiveRoom(ctx, room.ID, true)
+
+	st, _ := time.Parse(time.RFC3339, "2018-11-05T10:00:00+09:00")
+	et := st.Add(time.Hour)
+
+	// 3번째 회차와 겹치는 예약
+	taken, err := service.Make(ctx, room.ID, "다른 사람", st.AddDate(0, 0, 14), et.AddDate(0, 0, 14), reservation.ExtraInfo{})
+	assert.NoError(t, err)
+
+	t.Run("기본은 전체 실패", func(t *testing.T) {
+		_, err := service.Make(ctx, room.ID, userName, st, et, reservation.ExtraInfo{Repeat: 4})
+		assert.EqualError(t, err, exception.Unavailable.Error())
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		res, err := service.Make(ctx, room.ID, userName, st, et, reservation.ExtraInfo{Repeat: 4, DryRun: true})
+		assert.NoError(t, err)
+		assert.True(t, res.DryRun)
+		assert.Len(t, res.Booked, 3)
+		if assert.Len(t, res.Conflicts, 1) {
+			assert.Equal(t, 3, res.Conflicts[0].Occurrence)
+			assert.Equal(t, taken.ID, res.Conflicts[0].With.ID)
+		}
+
+		available, err := service.Available(ctx, room.ID, st, et)
+		assert.NoError(t, err)
+		assert.True(t, available, "dry run 은 예약하지 않아야 함")
+	})
+
+	t.Run("partial", func(t *testing.T) {
+		res, err := service.Make(ctx, room.ID, userName, st, et, reservation.ExtraInfo{Repeat: 4, Partial: true})
+		assert.NoError(t, err)
+		assert.Len(t, res.Conflicts, 1)
+
+		series, err := service.FindSeries(ctx, res.SeriesID)
+		assert.NoError(t, err)
+		occurrences := []int{}
+		for _, o := range series.Occurrences {
+			occurrences = append(occurrences, o.Occurrence)
+		}
+		assert.Equal(t, []int{1, 2, 4}, occurrences)
+	})
+
+	t.Run("partial 이어도 모두 겹치면 실패", func(t *testing.T) {
+		_, err := service.Make(ctx, room.ID, userName, st, et, reservation.ExtraInfo{Repeat: 2, Partial: true})
+		assert.EqualError(t, err, exception.Unavailable.Error())
+		conflict, ok := reservation.AsConflict(err)
+		if assert.True(t, ok) {
+			assert.Len(t, conflict.Conflicts, 2)
+		}
+	})
+}
+
+func TestReservation_ConflictError(t *testing.T) {
+	room, err := service.CreateRoom(ctx, reservation.Room{Name: "충돌 회의실"})
+	assert.NoError(t, err)
+	defer service.ArchiveRoom(ctx, room.ID, true)
+
+	st, _ := time.Parse(time.RFC3339, "2018-12-03T14:00:00+09:00")
+	taken, err := service.Make(ctx, room.ID, "Ted", st, st.Add(time.Hour), reservation.ExtraInfo{Memo: "인사 면담"})
+	assert.NoError(t, err)
+
+	t.Run("예약", func(t *testing.T) {
+		_, err := service.Make(ctx, room.ID, userName, st.Add(30*time.Minute), st.Add(90*time.Minute), reservation.ExtraInfo{})
+		assert.Equal(t, exception.Unavailable, errors.Cause(err))
+
+		conflict, ok := reservation.AsConflict(err)
+		if assert.True(t, ok) && assert.Len(t, conflict.Conflicts, 1) {
+			with := conflict.Conflicts[0].With
+			assert.Equal(t, taken.ID, with.ID)
+			assert.Equal(t, "Ted", with.User)
+			assert.True(t, st.Equal(with.Start))
+			assert.Empty(t, with.Memo, "다른 사람의 memo 는 노출하지 않음")
+		}
+	})
+
+	t.Run("변경", func(t *testing.T) {
+		other, err := service.Make(ctx, room.ID, userName, st.Add(2*time.Hour), st.Add(3*time.Hour), reservation.ExtraInfo{})
+		assert.NoError(t, err)
+
+		start, end := st.Add(30*time.Minute), st.Add(150*time.Minute)
+		_, err = service.Modify(ctx, other.ID, reservation.Modification{Start: &start, End: &end})
+		conflict, ok := reservation.AsConflict(err)
+		if assert.True(t, ok) && assert.Len(t, conflict.Conflicts, 1) {
+			assert.Equal(t, taken.ID, conflict.Conflicts[0].With.ID)
+		}
+	})
+}
+
+func TestReservation_Calendar(t *testing.T) {
+	room, err := service.CreateRoom(ctx, reservation.Room{Name: "구독 회의실"})
+	assert.NoError(t, err)
+	defer service.ArchiveRoom(ctx, room.ID, true)
+
+	st := time.Now().Truncate(24*time.Hour).AddDate(0, 0, 1).Add(10 * time.Hour)
+	_, err = service.Make(ctx, room.ID, "구독자", st, st.Add(time.Hour), reservation.ExtraInfo{})
+	assert.NoError(t, err)
+	_, err = service.Make(ctx, room.ID, userName, st.Add(2*time.Hour), st.Add(3*time.Hour), reservation.ExtraInfo{Repeat: 3})
+	assert.NoError(t, err)
+
+	cal, err := service.RoomCalendar(ctx, room.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "구독 회의실", cal.Name)
+	assert.Len(t, cal.Reservations, 1)
+	if assert.Len(t, cal.Series, 1) {
+		assert.Len(t, cal.Series[0].Occurrences, 3)
+	}
+
+	cal, err = service.UserCalendar(ctx, "구독자")
+	assert.NoError(t, err)
+	assert.Len(t, cal.Reservations, 1)
+	assert.Len(t, cal.Series, 0)
+
+	_, err = service.RoomCalendar(ctx, -1)
+	assert.Equal(t, exception.RoomNotFound, errors.Cause(err))
+}
+
+func TestReservation_Utilization(t *testing.T) {
+	room, err := service.CreateRoom(ctx, reservation.Room{Name: "이용률 회의실"})
+	assert.NoError(t, err)
+	defer service.ArchiveRoom(ctx, room.ID, true)
+
+	kst := time.FixedZone("KST", 9*60*60)
+	at := func(value string) time.Time {
+		t, _ := time.ParseInLocation("2006-01-02T15:04", value, kst)
+		return t
+	}
+	// 2030-01-07 은 월요일
+	for _, r := range []struct {
+		st, et string
+		repeat int
+	}{
+		{"2030-01-07T10:00", "2030-01-07T11:00", 0},
+		{"2030-01-07T17:30", "2030-01-07T19:00", 0},
+		{"2030-01-08T09:00", "2030-01-08T10:00", 2},
+	} {
+		_, err := service.Make(ctx, room.ID, userName, at(r.st), at(r.et), reservation.ExtraInfo{Repeat: r.repeat})
+		assert.NoError(t, err)
+	}
+
+	report, err := service.Utilization(ctx, at("2030-01-07T00:00"), at("2030-01-09T00:00"), reservation.GroupByRoom, kst)
+	assert.NoError(t, err)
+	var usage *reservation.Usage
+	for i, row := range report.Rows {
+		if row.RoomID == room.ID {
+			usage = &report.Rows[i]
+		}
+	}
+	if assert.NotNil(t, usage) {
+		assert.Equal(t, 18.0, usage.AvailableHours)
+		assert.Equal(t, 2.5, usage.BookedHours)
+		assert.Equal(t, 0.1389, usage.Utilization)
+		assert.Equal(t, 3, usage.Meetings)
+		assert.Equal(t, 70.0, usage.AverageMinutes)
+		assert.Equal(t, 0.3333, usage.RepeatRatio)
+		if assert.NotNil(t, usage.PeakHour) {
+			assert.Equal(t, 9, *usage.PeakHour)
+		}
+	}
+
+	report, err = service.Utilization(ctx, at("2030-01-07T00:00"), at("2030-01-09T00:00"), reservation.GroupByDay, kst)
+	assert.NoError(t, err)
+	if assert.Len(t, report.Rows, 2) {
+		assert.Equal(t, "2030-01-07", report.Rows[0].Key)
+		assert.Equal(t, 1.5, report.Rows[0].BookedHours)
+		assert.Equal(t, 1.0, report.Rows[1].BookedHours)
+	}
+	assert.Equal(t, []int{9, 10, 17}, report.PeakHours)
+
+	_, err = service.Utilization(ctx, at("2030-01-07T00:00"), at("2030-01-09T00:00"), "week", kst)
+	assert.Equal(t, exception.InvalidRequest, errors.Cause(err))
+}
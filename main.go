@@ -1,21 +1,25 @@
 package main
 
 import (
+	"context"
 	"net/http"
+	"os"
 
 	"github.com/gin-gonic/gin"
+	"github.com/rutesun/reservation/account"
+	"github.com/rutesun/reservation/calendar"
 	"github.com/rutesun/reservation/config"
 	"github.com/rutesun/reservation/controller"
+	"github.com/rutesun/reservation/log"
 	"github.com/rutesun/reservation/mariadb"
+	"github.com/rutesun/reservation/memory"
+	"github.com/rutesun/reservation/postgres"
 	"github.com/rutesun/reservation/reservation"
+	"github.com/rutesun/reservation/sqlite"
+	"github.com/rutesun/reservation/webhook"
 )
 
 func main() {
-	r := gin.Default()
-	r.Static("public", "public")
-
-	r.LoadHTMLGlob("public/*.html")
-
 	var setting *config.Setting
 	if conf, err := config.Parse(); err != nil {
 		panic(err)
@@ -25,8 +29,93 @@ func main() {
 		}
 	}
 
-	db := mariadb.New(setting.DB)
-	reservationService := reservation.New(db)
+	// ./app migrate [up|down [n]|to <version>|version]
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(setting, os.Args[2:]); err != nil {
+			log.Fatalf("%+v", err)
+		}
+		return
+	}
+
+	// ./app calendar-token [rooms <id>|users <name>]
+	if len(os.Args) > 1 && os.Args[1] == "calendar-token" {
+		if err := runCalendarToken(setting, os.Args[2:]); err != nil {
+			log.Fatalf("%+v", err)
+		}
+		return
+	}
+
+	if setting.Migrate && setting.Driver != config.Memory {
+		if err := runMigrate(setting, []string{"up"}); err != nil {
+			panic(err)
+		}
+	}
+
+	var (
+		reservationService *reservation.Service
+		accountService     *account.Service
+		webhookService     *webhook.Service
+	)
+	switch setting.Driver {
+	case config.Memory:
+		repo := memory.New(setting.Rooms...)
+		reservationService, accountService = reservation.New(repo), account.New(repo, setting.TokenTTL)
+		webhookService = webhook.New(repo, setting.WebhookAttempts, setting.WebhookBackoff)
+	case config.Postgres:
+		repo := postgres.New(setting.DB, setting.QueryTimeout)
+		reservationService, accountService = reservation.New(repo), account.New(repo, setting.TokenTTL)
+		webhookService = webhook.New(repo, setting.WebhookAttempts, setting.WebhookBackoff)
+	case config.SQLite:
+		repo := sqlite.New(setting.DB, setting.QueryTimeout)
+		reservationService, accountService = reservation.New(repo), account.New(repo, setting.TokenTTL)
+		webhookService = webhook.New(repo, setting.WebhookAttempts, setting.WebhookBackoff)
+	default:
+		repo := mariadb.New(setting.DB, setting.QueryTimeout)
+		reservationService, accountService = reservation.New(repo), account.New(repo, setting.TokenTTL)
+		webhookService = webhook.New(repo, setting.WebhookAttempts, setting.WebhookBackoff)
+	}
+
+	// ./app user-role <name> <role> [group...]
+	if len(os.Args) > 1 && os.Args[1] == "user-role" {
+		if err := runUserRole(accountService, os.Args[2:]); err != nil {
+			log.Fatalf("%+v", err)
+		}
+		return
+	}
+
+	// ./app import-ics [-dry-run] [-user <name>] [-timezone <tz>] <file.ics>
+	if len(os.Args) > 1 && os.Args[1] == "import-ics" {
+		if err := runImport(reservationService, os.Args[2:]); err != nil {
+			log.Fatalf("%+v", err)
+		}
+		return
+	}
+
+	// 시작 후 체크인하지 않은 예약은 주기적으로 no_show 로 바꿔 회의실 시간을 돌려줌
+	reservationService.SetNoShowGrace(setting.NoShowGrace)
+	if setting.NoShowGrace > 0 && setting.NoShowInterval > 0 {
+		go reservationService.WatchNoShows(context.Background(), setting.NoShowInterval)
+	}
+
+	// 알림은 Dispatcher 가 따로 보내므로 메일 서버가 느려도 예약 요청은 기다리지 않음
+	if notifier, err := newNotifier(setting.Notify); err != nil {
+		panic(err)
+	} else if notifier != nil {
+		reservationService.SetNotifier(notifier)
+	}
+	// 관리자가 등록한 webhook 은 전달을 저장만 하고 따로 보내며 실패하면 backoff 뒤 다시 보냄
+	reservationService.AddNotifier(webhookService)
+	if setting.WebhookInterval > 0 {
+		go webhookService.Watch(context.Background(), setting.WebhookInterval)
+	}
+
+	signer := calendar.NewSigner(setting.CalendarSecret)
+
+	r := gin.Default()
+	r.Use(controller.ErrorHandler(), controller.Authenticate(accountService))
+	r.Static("public", "public")
+
+	r.LoadHTMLGlob("public/*.html")
 
 	r.GET("/", func(c *gin.Context) {
 		c.HTML(http.StatusOK, "index.html", gin.H{})
@@ -37,10 +126,58 @@ func main() {
 		})
 	})
 
-	r.GET("/rooms", controller.RoomsController(reservationService))
-	r.GET("/reservations", controller.ListController(reservationService))
-	r.POST("/reservation", controller.MakeController(reservationService))
-	r.DELETE("/reservation/:id", controller.CancelController(reservationService))
+	r.POST("/users", controller.SignUpController(accountService))
+	r.POST("/login", controller.LoginController(accountService, setting.TokenTTL))
+	r.POST("/logout", controller.LogoutController(accountService))
+	read := r.Group("/")
+	if !setting.AnonymousRead {
+		read.Use(controller.RequireUser())
+	}
+
+	// 구독 주소는 calendar token 으로 보호하며 일정 앱은 로그인할 수 없으므로 로그인 없이 조회
+	// calendar secret 이 없으면 token 으로 보호할 수 없으므로 다른 조회와 같은 권한을 요구함
+	feed := r.Group("/")
+	if !signer.Protected() {
+		feed = read
+	}
+	feed.GET("/rooms/:id/calendar.ics", controller.RoomCalendarController(reservationService, signer))
+	feed.GET("/users/:name/calendar.ics", controller.UserCalendarController(reservationService, signer))
+
+	read.GET("/rooms", controller.RoomsController(reservationService))
+	read.GET("/reports/utilization", controller.UtilizationController(reservationService))
+	read.GET("/reports/no-shows", controller.NoShowController(reservationService))
+	read.GET("/availability/search", controller.SearchController(reservationService))
+	read.GET("/reservations", controller.ListController(reservationService))
+	read.GET("/reservation/:id", controller.FindController(reservationService))
+	read.GET("/series/:id", controller.SeriesController(reservationService))
+
+	write := r.Group("/", controller.RequireUser())
+	write.GET("/me", controller.MeController())
+	write.PUT("/users/:name", controller.UpdateUserController(accountService))
+	write.POST("/rooms", controller.CreateRoomController(reservationService))
+	write.PUT("/rooms/:id", controller.UpdateRoomController(reservationService))
+	write.DELETE("/rooms/:id", controller.ArchiveRoomController(reservationService))
+	write.POST("/reservation", controller.MakeController(reservationService))
+	write.PUT("/reservation/:id", controller.ModifyController(reservationService))
+	write.PATCH("/reservation/:id", controller.ModifyController(reservationService))
+	write.DELETE("/reservation/:id", controller.CancelController(reservationService))
+	write.POST("/reservation/:id/approve", controller.ApproveController(reservationService))
+	write.POST("/reservation/:id/reject", controller.RejectController(reservationService))
+	write.POST("/reservation/:id/checkin", controller.CheckInController(reservationService))
+	write.GET("/waitlist", controller.WaitlistController(reservationService))
+	write.POST("/waitlist", controller.JoinWaitlistController(reservationService))
+	write.DELETE("/waitlist/:id", controller.LeaveWaitlistController(reservationService))
+	write.GET("/webhooks", controller.WebhooksController(webhookService))
+	write.POST("/webhooks", controller.CreateWebhookController(webhookService))
+	write.GET("/webhooks/:id", controller.WebhookController(webhookService))
+	write.DELETE("/webhooks/:id", controller.DeleteWebhookController(webhookService))
+	write.GET("/webhooks/:id/deliveries", controller.DeliveriesController(webhookService))
+	write.GET("/webhooks/:id/deliveries/:delivery", controller.DeliveryController(webhookService))
+	write.POST("/webhooks/:id/deliveries/:delivery/replay", controller.ReplayDeliveryController(webhookService))
+	write.POST("/import/ics", controller.ImportController(reservationService))
+	write.DELETE("/series/:id", controller.CancelSeriesController(reservationService))
+	write.PUT("/series/:id/occurrences/:occurrence", controller.ModifyOccurrenceController(reservationService))
+	write.PATCH("/series/:id/occurrences/:occurrence", controller.ModifyOccurrenceController(reservationService))
 	r.Run() // listen and serve on 0.0.0.0:8080
 
 }
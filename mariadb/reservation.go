@@ -1,49 +1,66 @@
 package mariadb
 
 import (
+	"context"
 	"time"
 
-	"fmt"
-
 	"database/sql"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/pkg/errors"
 	"github.com/rutesun/reservation/exception"
-	"github.com/rutesun/reservation/log"
 	"github.com/rutesun/reservation/reservation"
 	sq "gopkg.in/Masterminds/squirrel.v1"
 )
 
+// active 는 회의실 시간을 차지하는(승인 대기, 승인된) 예약만 남기는 조건
+const active = "status IN ('pending', 'approved')"
+
 type db struct {
-	DB *sqlx.DB
+	DB      *sqlx.DB
+	timeout time.Duration
 }
 
-func New(d *sqlx.DB) *db {
-	return &db{DB: d}
+// New 는 query 하나당 queryTimeout 을 적용하는 저장소를 생성. 0 이면 timeout 없음
+func New(d *sqlx.DB, queryTimeout time.Duration) *db {
+	return &db{DB: d, timeout: queryTimeout}
 }
 
-func (db *db) RoomList() ([]*reservation.Room, error) {
+func (db *db) RoomList(ctx context.Context, filter reservation.RoomFilter) ([]*reservation.Room, error) {
 	rList := []*dtoRoom{}
 
 	builder := sq.Select(
 		"r.id",
 		"r.name",
+		"r.capacity",
+		"r.building",
+		"r.floor",
+		"r.policy",
+		"r.policy_group",
+		"r.requires_approval",
 	).
-		From("reservation_item AS r").Where("r.item_type = 'MEETING'")
+		From("reservation_item AS r").
+		Where("r.item_type = 'MEETING' AND r.archived_at IS NULL").
+		OrderBy("r.id")
+	builder = filterRoom(builder, filter)
 
-	err := db.Select(&rList, builder)
+	if err := db.Select(ctx, &rList, builder); err != nil {
+		return nil, errors.WithStack(err)
+	}
 
 	rooms := make([]*reservation.Room, len(rList))
 	for i, r := range rList {
 		rooms[i] = convertRoom(r)
 	}
 
-	return rooms, err
+	if err := db.loadEquipment(ctx, rooms); err != nil {
+		return nil, err
+	}
+	return rooms, nil
 }
 
-func (db *db) List(startDate, endDate time.Time) ([]*reservation.Detail, error) {
-	list, err := db.listAll(startDate, endDate)
+func (db *db) List(ctx context.Context, startDate, endDate time.Time) ([]*reservation.Detail, error) {
+	list, err := db.listAll(ctx, startDate, endDate)
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
@@ -56,10 +73,38 @@ func (db *db) List(startDate, endDate time.Time) ([]*reservation.Detail, error)
 	return details, nil
 }
 
-func (db *db) listAll(startDate, endDate time.Time) ([]*dtoReservation, error) {
+func (db *db) listAll(ctx context.Context, startDate, endDate time.Time) ([]*dtoReservation, error) {
 	reservations := []*dtoReservation{}
 
-	builder := sq.Select(
+	builder := selectReservation().
+		Where("r.start_time >= ? AND r.end_time < ?", startDate, endDate).
+		Where("r." + active)
+
+	err := db.Select(ctx, &reservations, builder)
+	return reservations, err
+}
+
+// ListOverlapping 은 [startTime, endTime) 와 조금이라도 겹치는 예약을 모두 조회
+func (db *db) ListOverlapping(ctx context.Context, startTime, endTime time.Time) ([]*reservation.Detail, error) {
+	reservations := []*dtoReservation{}
+
+	builder := selectReservation().
+		Where("r.end_time > ? AND r.start_time < ?", startTime, endTime).
+		Where("r." + active)
+
+	if err := db.Select(ctx, &reservations, builder); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	details := make([]*reservation.Detail, len(reservations))
+	for i, o := range reservations {
+		details[i] = convertReservation(o)
+	}
+	return details, nil
+}
+
+func selectReservation() sq.SelectBuilder {
+	return sq.Select(
 		"r.id",
 		"ri.id AS room_id",
 		"ri.name AS room_name",
@@ -67,118 +112,374 @@ func (db *db) listAll(startDate, endDate time.Time) ([]*dtoReservation, error) {
 		"r.start_time",
 		"r.end_time",
 		"r.memo",
+		"r.series_id",
+		"r.occurrence",
+		"r.is_exception",
+		"r.status",
+		"r.checked_in_at",
 	).
 		From("reservation AS r").
-		Join("reservation_item AS ri ON r.item_id = ri.id").
-		Where("r.start_time >= ? AND r.end_time < ?", startDate, endDate)
+		Join("reservation_item AS ri ON r.item_id = ri.id")
+}
 
-	err := db.Select(&reservations, builder)
-	return reservations, err
+func (db *db) Find(ctx context.Context, reservationID int64) (*reservation.Detail, error) {
+	dto := dtoReservation{}
+
+	builder := selectReservation().Where("r.id = ?", reservationID)
+
+	if err := db.Get(ctx, &dto, builder); err == sql.ErrNoRows {
+		return nil, exception.NotFound
+	} else if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return convertReservation(&dto), nil
 }
 
-func (db *db) Available(roomID int64, startTime, endTime time.Time) (bool, error) {
+func (db *db) Available(ctx context.Context, roomID int64, startTime, endTime time.Time) (bool, error) {
+	return db.available(ctx, db.DB, roomID, startTime, endTime, 0, "")
+}
+
+// available 은 queryer(db 혹은 transaction) 로 excludeID 를 제외하고 겹치는 예약이 있는지 확인
+// transaction 안에서는 lock 에 "FOR UPDATE" 를 주어 snapshot 이 아닌 최신 commit 된 값을 읽음
+func (db *db) available(ctx context.Context, queryer sqlx.QueryerContext, roomID int64, startTime, endTime time.Time, excludeID int64, lock string) (bool, error) {
 	builder := sq.Select("count(*)").
 		From("reservation").
 		Where("item_id = ?", roomID).
-		Where("end_time >= ? AND start_time < ?", startTime, endTime)
+		Where("end_time > ? AND start_time < ?", startTime, endTime).
+		Where(active)
+	if excludeID != 0 {
+		builder = builder.Where("id <> ?", excludeID)
+	}
+	if lock != "" {
+		builder = builder.Suffix(lock)
+	}
 
 	count := 0
-	if err := db.Get(&count, builder); err != nil {
+	if err := db.getWith(ctx, queryer, &count, builder); err != nil {
 		return false, errors.WithStack(err)
 	}
 
-	if count == 0 {
-		return true, nil
-	} else {
-		return false, nil
-	}
+	return count == 0, nil
 }
 
-type execer interface {
-	Exec(string, ...interface{}) (sql.Result, error)
+// lockRooms 는 보관 여부와 관계없이 회의실들을 id 순서로 lock 하여 두 회의실을 lock 하는 변경끼리 교착되지 않게 함
+func (db *db) lockRooms(ctx context.Context, tx *sqlx.Tx, roomIDs ...int64) error {
+	ids := []int64{}
+	builder := sq.Select("id").
+		From("reservation_item").
+		Where(sq.Eq{"id": roomIDs}).
+		OrderBy("id").
+		Suffix("FOR UPDATE")
+	return errors.WithStack(db.query(ctx, &ids, builder, tx.SelectContext))
 }
 
-func (db *db) MakeRepeatly(roomID int64, userName string, startTime, endTime time.Time, repeatCnt int, memo string) ([]int64, error) {
-	var (
-		err error
-		tx  *sql.Tx
-	)
+// lockSlots 는 builder 로 조회한 예약들의 회의실을 id 순서로 먼저 lock 한 뒤 예약을 lock 하여 다시 조회
+// 그 사이 다른 회의실로 옮겨진 예약이 있으면 errRoomChanged
+func (db *db) lockSlots(ctx context.Context, tx *sqlx.Tx, builder sq.SelectBuilder) ([]*dtoSlot, error) {
+	slots := []*dtoSlot{}
+	if err := db.query(ctx, &slots, builder, tx.SelectContext); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if len(slots) == 0 {
+		return slots, nil
+	}
 
-	ids := []int64{}
-	if tx, err = db.DB.Begin(); err != nil {
-		return nil, errors.Wrap(err, "Fail to begin transaction")
+	locked := map[int64]bool{}
+	roomIDs := []int64{}
+	for _, slot := range slots {
+		if !locked[slot.RoomID] {
+			locked[slot.RoomID] = true
+			roomIDs = append(roomIDs, slot.RoomID)
+		}
 	}
-	if repeatCnt == 0 {
-		return nil, exception.InvalidRequest
+	if err := db.lockRooms(ctx, tx, roomIDs...); err != nil {
+		return nil, err
 	}
-	for i := 0; i < repeatCnt; i++ {
-		if res, err := db.make(tx, roomID, userName, startTime, endTime,
-			fmt.Sprintf("(반복 %d/%d회)\n%s", i+1, repeatCnt, memo)); err != nil {
-			tx.Rollback()
-			return nil, err
-		} else {
-			id, _ := res.LastInsertId()
-			ids = append(ids, id)
-			startTime = startTime.AddDate(0, 0, 7)
-			endTime = endTime.AddDate(0, 0, 7)
+
+	slots = []*dtoSlot{}
+	if err := db.query(ctx, &slots, builder.Suffix("FOR UPDATE"), tx.SelectContext); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	for _, slot := range slots {
+		if !locked[slot.RoomID] {
+			return nil, errRoomChanged
 		}
+	}
+	return slots, nil
+}
 
+// lockRoom 은 회의실 row 에 배타 lock 을 걸어 같은 회의실의 예약 생성, 보관을 transaction 단위로 직렬화
+// 회의실이 없거나 보관되었으면 exception.RoomNotFound
+func (db *db) lockRoom(ctx context.Context, tx *sqlx.Tx, roomID int64) error {
+	builder := sq.Select("id").
+		From("reservation_item").
+		Where("id = ? AND item_type = 'MEETING' AND archived_at IS NULL", roomID).
+		Suffix("FOR UPDATE")
+
+	var id int64
+	if err := db.getWith(ctx, tx, &id, builder); err == sql.ErrNoRows {
+		return exception.RoomNotFound
+	} else if err != nil {
+		return errors.WithStack(err)
 	}
+	return nil
+}
 
-	if err = tx.Commit(); err != nil {
-		return nil, errors.Wrap(err, "Fail to commit transaction")
+func (db *db) MakeSeries(ctx context.Context, roomID int64, userName string, rule string, slots []reservation.Slot, memo string, status reservation.Status) (int64, error) {
+	if len(slots) == 0 {
+		return 0, exception.InvalidRequest
 	}
-	return ids, nil
+
+	var seriesID int64
+	err := db.transaction(ctx, func(tx *sqlx.Tx) error {
+		if err := db.lockRoom(ctx, tx, roomID); err != nil {
+			return err
+		}
+
+		var err error
+		if seriesID, err = db.createSeries(ctx, tx, roomID, userName, rule, memo); err != nil {
+			return err
+		}
+
+		for _, slot := range slots {
+			if _, err := db.make(ctx, tx, roomID, userName, slot.Start, slot.End, memo, status, seriesID, slot.Occurrence); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return seriesID, nil
+}
+
+func (db *db) Make(ctx context.Context, roomID int64, userName string, startTime, endTime time.Time, memo string, status reservation.Status) (int64, error) {
+	var id int64
+	err := db.transaction(ctx, func(tx *sqlx.Tx) error {
+		if err := db.lockRoom(ctx, tx, roomID); err != nil {
+			return err
+		}
+
+		var err error
+		id, err = db.make(ctx, tx, roomID, userName, startTime, endTime, memo, status, 0, 0)
+		return err
+	})
+	return id, err
 }
 
-func (db *db) Make(roomID int64, userName string, startTime, endTime time.Time, memo string) (int64, error) {
-	if res, err := db.make(db.DB, roomID, userName, startTime, endTime, memo); err != nil {
+// make 는 회의실 lock 을 잡은 transaction 안에서 겹침을 확인한 뒤 insert
+// seriesID 가 0 이 아니면 반복 예약의 occurrence 번째 회차로 저장
+func (db *db) make(ctx context.Context, tx *sqlx.Tx, roomID int64, userName string, startTime, endTime time.Time, memo string, status reservation.Status, seriesID int64, occurrence int) (int64, error) {
+	if able, err := db.available(ctx, tx, roomID, startTime, endTime, 0, "FOR UPDATE"); err != nil {
 		return 0, errors.WithStack(err)
-	} else {
-		return res.LastInsertId()
+	} else if !able {
+		return 0, exception.Unavailable
 	}
-}
 
-func (db *db) make(execer execer, roomID int64, userName string, startTime, endTime time.Time, memo string) (sql.Result, error) {
-	var err error
+	columns := []string{"item_id", "user_name", "start_time", "end_time", "memo", "status"}
+	values := []interface{}{roomID, userName, startTime, endTime, memo, string(status)}
 
-	columns := []string{"item_id", "user_name", "start_time", "end_time", "memo"}
-	values := []interface{}{roomID, userName, startTime, endTime, memo}
+	if seriesID != 0 {
+		columns = append(columns, "series_id", "occurrence")
+		values = append(values, seriesID, occurrence)
+	}
 
 	builder := sq.Insert("reservation").
 		Columns(columns...).
 		Values(values...)
 
-	query, args, err := builder.ToSql()
-
-	log.Debugf("query = %s\targs = %v", query, args)
-
+	res, err := db.execWith(ctx, tx, builder)
 	if err != nil {
-		return nil, errors.WithStack(err)
+		return 0, errors.WithStack(err)
 	}
+	return res.LastInsertId()
+}
 
-	if able, err := db.Available(roomID, startTime, endTime); err != nil {
-		return nil, errors.WithStack(err)
-	} else {
-		if !able {
-			return nil, exception.Unavailable
+// errRoomChanged 는 회의실을 lock 하는 사이 다른 변경이 예약의 회의실을 바꾼 경우이며 transaction 을 다시 시도함
+var errRoomChanged = errors.New("예약의 회의실이 바뀌었습니다")
+
+// lockRetry 는 errRoomChanged 일 때 transaction 을 시도하는 최대 횟수
+const lockRetry = 3
+
+// retryTransaction 은 errRoomChanged 이면 lockRetry 번까지 transaction 을 다시 실행
+func (db *db) retryTransaction(ctx context.Context, fn func(tx *sqlx.Tx) error) error {
+	for i := 0; ; i++ {
+		err := db.transaction(ctx, fn)
+		if err != errRoomChanged || i+1 >= lockRetry {
+			return err
 		}
 	}
-	return execer.Exec(query, args...)
 }
 
-func (db *db) Cancel(reservationID int64) (bool, error) {
-	builder := sq.Delete("reservation").Where("id = ?", reservationID)
-	if _, err := db.Exec(builder); err != nil {
-		return false, errors.WithStack(err)
+// Modify 는 생성, 취소와 같이 회의실부터 lock 한 뒤 예약을 lock 하고 자신을 제외한 겹침을 확인하여 update
+// 지금 회의실과 바꿀 회의실은 id 순서로 lock 하며 취소, 거절된 예약이면 exception.NotFound
+func (db *db) Modify(ctx context.Context, reservationID int64, roomID int64, userName string, startTime, endTime time.Time, memo string, status reservation.Status) error {
+	return db.retryTransaction(ctx, func(tx *sqlx.Tx) error {
+		return db.modify(ctx, tx, reservationID, roomID, userName, startTime, endTime, memo, status)
+	})
+}
+
+func (db *db) modify(ctx context.Context, tx *sqlx.Tx, reservationID int64, roomID int64, userName string, startTime, endTime time.Time, memo string, status reservation.Status) error {
+	var current int64
+	builder := sq.Select("item_id").
+		From("reservation").
+		Where("id = ? AND "+active, reservationID)
+	if err := db.getWith(ctx, tx, &current, builder); err == sql.ErrNoRows {
+		return exception.NotFound
+	} else if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if err := db.lockRooms(ctx, tx, current, roomID); err != nil {
+		return err
+	}
+	// 바꿀 회의실이 보관되지 않았는지 확인. 이미 lock 을 잡았으므로 lock 순서는 바뀌지 않음
+	if err := db.lockRoom(ctx, tx, roomID); err != nil {
+		return err
+	}
+
+	var locked int64
+	if err := db.getWith(ctx, tx, &locked, builder.Suffix("FOR UPDATE")); err == sql.ErrNoRows {
+		return exception.NotFound
+	} else if err != nil {
+		return errors.WithStack(err)
+	} else if locked != current {
+		return errRoomChanged
 	}
 
-	return true, nil
+	if able, err := db.available(ctx, tx, roomID, startTime, endTime, reservationID, "FOR UPDATE"); err != nil {
+		return errors.WithStack(err)
+	} else if !able {
+		return exception.Unavailable
+	}
+
+	update := sq.Update("reservation").
+		SetMap(map[string]interface{}{
+			"item_id":    roomID,
+			"user_name":  userName,
+			"start_time": startTime,
+			"end_time":   endTime,
+			"memo":       memo,
+			"status":     string(status),
+			// 반복 예약의 회차를 따로 변경하면 예외 회차로 표시
+			"is_exception": sq.Expr("series_id IS NOT NULL"),
+		}).
+		Where("id = ?", reservationID)
+
+	_, err := db.execWith(ctx, tx, update)
+	return errors.WithStack(err)
+}
+
+// Cancel 은 예약을 지우지 않고 cancelled 로 바꾸며 이미 취소, 거절된 예약이면 exception.NotFound
+// 같은 transaction 에서 비워진 시간에 들어갈 수 있는 대기를 예약하고 예약한 id 를 반환
+func (db *db) Cancel(ctx context.Context, reservationID int64, now time.Time) ([]int64, error) {
+	var promoted []int64
+	err := db.transaction(ctx, func(tx *sqlx.Tx) error {
+		target := dtoSlot{}
+		builder := sq.Select("item_id", "start_time", "end_time").
+			From("reservation").
+			Where("id = ? AND "+active, reservationID)
+		if err := db.getWith(ctx, tx, &target, builder); err == sql.ErrNoRows {
+			return exception.NotFound
+		} else if err != nil {
+			return errors.WithStack(err)
+		}
+
+		// 예약 생성과 같은 순서로 회의실부터 lock. 보관된 회의실이면 대기를 예약하지 않음
+		open := true
+		if err := db.lockRoom(ctx, tx, target.RoomID); err == exception.RoomNotFound {
+			open = false
+		} else if err != nil {
+			return err
+		}
+
+		update := sq.Update("reservation").
+			Set("status", string(reservation.StatusCancelled)).
+			Where("id = ? AND item_id = ? AND "+active, reservationID, target.RoomID)
+		res, err := db.execWith(ctx, tx, update)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if affected, err := res.RowsAffected(); err != nil {
+			return errors.WithStack(err)
+		} else if affected == 0 {
+			return exception.NotFound
+		}
+
+		if !open {
+			return nil
+		}
+		promoted, err = db.promote(ctx, tx, target.RoomID, target.StartTime, target.EndTime, now)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return promoted, nil
+}
+
+// Review 는 승인 대기 중인 예약의 상태만 바꾸며 이미 처리되었으면 exception.NotPending
+// 거절하면 취소와 같이 회의실부터 lock 하고 같은 transaction 에서 비워진 시간의 대기를 예약하여 예약한 id 를 반환
+func (db *db) Review(ctx context.Context, reservationID int64, status reservation.Status, now time.Time) ([]int64, error) {
+	var promoted []int64
+	err := db.retryTransaction(ctx, func(tx *sqlx.Tx) error {
+		slots, err := db.lockSlots(ctx, tx, selectSlot().
+			Where("id = ? AND status = ?", reservationID, string(reservation.StatusPending)))
+		if err != nil {
+			return err
+		}
+		if len(slots) == 0 {
+			return exception.NotPending
+		}
+
+		update := sq.Update("reservation").
+			Set("status", string(status)).
+			Where("id = ?", reservationID)
+		if _, err := db.execWith(ctx, tx, update); err != nil {
+			return errors.WithStack(err)
+		}
+
+		promoted = []int64{}
+		if status != reservation.StatusRejected {
+			return nil
+		}
+		promoted, err = db.promoteSlots(ctx, tx, slots, now)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return promoted, nil
 }
 
 type dtoRoom struct {
-	ID   int64  `db:"id"`
-	Name string `db:"name"`
+	ID               int64  `db:"id"`
+	Name             string `db:"name"`
+	Capacity         int    `db:"capacity"`
+	Building         string `db:"building"`
+	Floor            string `db:"floor"`
+	Policy           string `db:"policy"`
+	Group            string `db:"policy_group"`
+	RequiresApproval bool   `db:"requires_approval"`
+}
+
+// dtoSlot 은 예약의 회의실과 시간
+// selectSlot 은 예약의 id, 회의실, 시간만 조회
+func selectSlot() sq.SelectBuilder {
+	return sq.Select("id", "item_id", "start_time", "end_time").From("reservation")
+}
+
+type dtoSlot struct {
+	ID        int64     `db:"id"`
+	RoomID    int64     `db:"item_id"`
+	StartTime time.Time `db:"start_time"`
+	EndTime   time.Time `db:"end_time"`
+}
+
+type dtoEquipment struct {
+	RoomID    int64  `db:"item_id"`
+	Equipment string `db:"equipment"`
 }
 
 type dtoReservation struct {
@@ -189,11 +490,24 @@ type dtoReservation struct {
 	StartTime time.Time      `db:"start_time"`
 	EndTime   time.Time      `db:"end_time"`
 	Memo      sql.NullString `db:"memo"`
+
+	SeriesID    sql.NullInt64 `db:"series_id"`
+	Occurrence  sql.NullInt64 `db:"occurrence"`
+	IsException bool          `db:"is_exception"`
+	Status      string        `db:"status"`
+	CheckedInAt *time.Time    `db:"checked_in_at"`
 }
 
 func convertRoom(r *dtoRoom) *reservation.Room {
 	return &reservation.Room{
-		r.ID, r.Name,
+		ID:               r.ID,
+		Name:             r.Name,
+		Capacity:         r.Capacity,
+		Building:         r.Building,
+		Floor:            r.Floor,
+		Policy:           reservation.Policy(r.Policy),
+		Group:            r.Group,
+		RequiresApproval: r.RequiresApproval,
 	}
 }
 
@@ -202,7 +516,7 @@ func convertReservation(r *dtoReservation) *reservation.Detail {
 		return nil
 	}
 
-	return &reservation.Detail{
+	detail := &reservation.Detail{
 		ID: r.ID,
 		Room: reservation.Room{
 			ID:   r.RoomID,
@@ -210,6 +524,16 @@ func convertReservation(r *dtoReservation) *reservation.Detail {
 		},
 		User:  r.UserName,
 		Start: r.StartTime, End: r.EndTime,
-		Memo: r.Memo.String,
+		Memo:   r.Memo.String,
+		Status: reservation.Status(r.Status),
+
+		Occurrence: int(r.Occurrence.Int64),
+		Exception:  r.IsException,
+
+		CheckedInAt: r.CheckedInAt,
+	}
+	if r.SeriesID.Valid {
+		detail.SeriesID = &r.SeriesID.Int64
 	}
+	return detail
 }
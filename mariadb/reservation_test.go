@@ -1,14 +1,18 @@
 package mariadb
 
 import (
+	"context"
 	"testing"
 
 	"fmt"
 
 	"time"
 
+	"github.com/pkg/errors"
 	"github.com/rutesun/reservation/config"
 	"github.com/rutesun/reservation/exception"
+	"github.com/rutesun/reservation/reservation"
+	"github.com/rutesun/reservation/reservation/reservationtest"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -24,10 +28,11 @@ func init() {
 	if err = setting.DB.Ping(); err != nil {
 		panic(err)
 	}
-	mariadb = New(setting.DB)
+	mariadb = New(setting.DB, setting.QueryTimeout)
 }
 
 var (
+	ctx      = context.Background()
 	roomID   = int64(1)
 	userName = "Ted"
 )
@@ -35,7 +40,7 @@ var (
 func TestDb_listAll(t *testing.T) {
 	st, _ := time.Parse(time.RFC3339, "2018-08-04T00:00:00+09:00")
 	et, _ := time.Parse(time.RFC3339, "2018-08-30T00:00:00+09:00")
-	list, err := mariadb.List(st, et)
+	list, err := mariadb.List(ctx, st, et)
 
 	assert.NoError(t, err)
 
@@ -68,14 +73,14 @@ func TestDb_Make(t *testing.T) {
 	st, _ := time.Parse(time.RFC3339, "2018-08-04T18:00:00+09:00")
 	et, _ := time.Parse(time.RFC3339, "2018-08-04T19:00:00+09:00")
 
-	id, err := mariadb.Make(roomID, userName, st, et, "")
+	id, err := mariadb.Make(ctx, roomID, userName, st, et, "", reservation.StatusApproved)
 	if err != nil {
 		assert.EqualError(t, err, exception.Unavailable.Error())
 	}
 
 	t.Log(id)
 
-	check, err := mariadb.Available(roomID, st, et)
+	check, err := mariadb.Available(ctx, roomID, st, et)
 	assert.NoError(t, err)
 	assert.False(t, check)
 }
@@ -84,24 +89,33 @@ func TestDb_CheckAvailable(t *testing.T) {
 	st, _ := time.Parse(time.RFC3339, "2018-08-04T00:00:00+09:00")
 	et, _ := time.Parse(time.RFC3339, "2018-08-04T23:00:00+09:00")
 
-	_, err := mariadb.Available(roomID, st, et)
+	_, err := mariadb.Available(ctx, roomID, st, et)
 	assert.NoError(t, err)
 }
 
-func TestDb_MakeRepeatly(t *testing.T) {
+// weekly 는 st, et 부터 매주 같은 시간으로 n 번 반복하는 예약 시간
+func weekly(st, et time.Time, n int) []reservation.Slot {
+	slots := make([]reservation.Slot, n)
+	for i := range slots {
+		slots[i] = reservation.Slot{Start: st.AddDate(0, 0, 7*i), End: et.AddDate(0, 0, 7*i), Occurrence: i + 1}
+	}
+	return slots
+}
+
+func TestDb_MakeSeries(t *testing.T) {
 	st, _ := time.Parse(time.RFC3339, "2018-08-05T16:00:00+09:00")
 	et, _ := time.Parse(time.RFC3339, "2018-08-05T19:00:00+09:00")
 
 	repeatCnt := 5
-	ids, err := mariadb.MakeRepeatly(roomID, userName, st, et, repeatCnt, "")
+	seriesID, err := mariadb.MakeSeries(ctx, roomID, userName, "FREQ=WEEKLY", weekly(st, et, repeatCnt), "", reservation.StatusApproved)
 	if err != nil {
 		assert.EqualError(t, err, exception.Unavailable.Error())
 	}
 
-	t.Log(ids)
+	t.Log(seriesID)
 
 	for i := 0; i < 5; i++ {
-		check, err := mariadb.Available(roomID, st, et)
+		check, err := mariadb.Available(ctx, roomID, st, et)
 		assert.NoError(t, err)
 		assert.False(t, check)
 		st = st.AddDate(0, 0, 7)
@@ -110,8 +124,24 @@ func TestDb_MakeRepeatly(t *testing.T) {
 }
 
 func TestDb_Cancel(t *testing.T) {
+	st, _ := time.Parse(time.RFC3339, "2018-08-07T10:00:00+09:00")
+	et, _ := time.Parse(time.RFC3339, "2018-08-07T12:00:00+09:00")
 
-	_, err := mariadb.Cancel(1)
+	id, err := mariadb.Make(ctx, roomID, userName, st, et, "", reservation.StatusApproved)
+	assert.NoError(t, err)
 
+	_, err = mariadb.Cancel(ctx, id, st)
 	assert.NoError(t, err)
+
+	_, err = mariadb.Cancel(ctx, id, st)
+	assert.Equal(t, exception.NotFound, errors.Cause(err))
+}
+
+func TestDb_MakeConcurrently(t *testing.T) {
+	st, _ := time.Parse(time.RFC3339, "2018-09-03T10:00:00+09:00")
+	et, _ := time.Parse(time.RFC3339, "2018-09-03T12:00:00+09:00")
+
+	for _, id := range reservationtest.MakeConcurrently(t, mariadb, roomID, st, et, 30) {
+		mariadb.Cancel(ctx, id, st)
+	}
 }
@@ -0,0 +1,129 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rutesun/reservation/account"
+	"github.com/rutesun/reservation/exception"
+)
+
+// token 은 로그인 token 의 사용자와 만료 시간
+type token struct {
+	userID    int64
+	expiresAt time.Time
+}
+
+func (db *db) CreateUser(ctx context.Context, name, passwordHash string, now time.Time) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, errors.WithStack(err)
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if _, ok := db.user(name); ok {
+		return 0, exception.UserExists
+	}
+	db.lastUserID++
+	db.users[db.lastUserID] = &account.User{
+		ID:           db.lastUserID,
+		Name:         name,
+		Role:         account.Member,
+		Groups:       []string{},
+		CreatedAt:    now,
+		PasswordHash: passwordHash,
+	}
+	return db.lastUserID, nil
+}
+
+func (db *db) FindUser(ctx context.Context, name string) (*account.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	u, ok := db.user(name)
+	if !ok {
+		return nil, exception.UserNotFound
+	}
+	return copyUser(u), nil
+}
+
+func (db *db) UpdateUser(ctx context.Context, user *account.User) error {
+	if err := ctx.Err(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	stored, ok := db.users[user.ID]
+	if !ok {
+		return exception.UserNotFound
+	}
+	stored.Role = user.Role
+	stored.Groups = append([]string{}, user.Groups...)
+	return nil
+}
+
+func copyUser(u *account.User) *account.User {
+	user := *u
+	user.Groups = append([]string{}, u.Groups...)
+	return &user
+}
+
+// user 는 이름으로 사용자를 찾음. lock 은 호출하는 쪽에서 잡아야 함
+func (db *db) user(name string) (*account.User, bool) {
+	for _, u := range db.users {
+		if u.Name == name {
+			return u, true
+		}
+	}
+	return nil, false
+}
+
+func (db *db) CreateToken(ctx context.Context, userID int64, tokenHash string, expiresAt time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if _, ok := db.users[userID]; !ok {
+		return errors.Errorf("unknown user: %d", userID)
+	}
+	db.tokens[tokenHash] = token{userID: userID, expiresAt: expiresAt}
+	return nil
+}
+
+func (db *db) FindUserByToken(ctx context.Context, tokenHash string, now time.Time) (*account.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	t, ok := db.tokens[tokenHash]
+	if !ok || !t.expiresAt.After(now) {
+		return nil, exception.UserNotFound
+	}
+	return copyUser(db.users[t.userID]), nil
+}
+
+func (db *db) DeleteToken(ctx context.Context, tokenHash string) error {
+	if err := ctx.Err(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	delete(db.tokens, tokenHash)
+	return nil
+}
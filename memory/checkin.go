@@ -0,0 +1,83 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rutesun/reservation/exception"
+	"github.com/rutesun/reservation/reservation"
+)
+
+func (db *db) CheckIn(ctx context.Context, reservationID int64, now time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	r, ok := db.reservations[reservationID]
+	if !ok || r.Status != reservation.StatusApproved || r.CheckedInAt != nil {
+		return exception.NotFound
+	}
+	r.CheckedInAt = &now
+	return nil
+}
+
+// ReleaseNoShows 는 startedBefore 전에 시작하여 now 에 아직 진행 중인, 체크인하지 않은 승인된 예약을 no_show 로 바꿈
+func (db *db) ReleaseNoShows(ctx context.Context, startedBefore, now time.Time) ([]int64, []int64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, errors.WithStack(err)
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	released := []*reservation.Detail{}
+	for _, r := range db.reservations {
+		if r.Status != reservation.StatusApproved || r.CheckedInAt != nil ||
+			r.Start.After(startedBefore) || !r.End.After(now) {
+			continue
+		}
+		r.Status = reservation.StatusNoShow
+		released = append(released, r)
+	}
+	sort.Slice(released, func(i, j int) bool { return released[i].ID < released[j].ID })
+
+	ids, promoted := make([]int64, len(released)), []int64{}
+	for i, r := range released {
+		ids[i] = r.ID
+		promoted = append(promoted, db.promoteSlot(r.Room.ID, r.Start, r.End, now)...)
+	}
+	return ids, promoted, nil
+}
+
+func (db *db) CountNoShows(ctx context.Context, from, to time.Time) ([]reservation.NoShowCount, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	byUser := make(map[string]int)
+	for _, r := range db.reservations {
+		if r.Status == reservation.StatusNoShow && !r.Start.Before(from) && r.Start.Before(to) {
+			byUser[r.User]++
+		}
+	}
+
+	counts := make([]reservation.NoShowCount, 0, len(byUser))
+	for user, count := range byUser {
+		counts = append(counts, reservation.NoShowCount{User: user, Count: count})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].User < counts[j].User
+	})
+	return counts, nil
+}
@@ -0,0 +1,341 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rutesun/reservation/account"
+	"github.com/rutesun/reservation/exception"
+	"github.com/rutesun/reservation/log"
+	"github.com/rutesun/reservation/reservation"
+	"github.com/rutesun/reservation/webhook"
+)
+
+// db 는 외부 저장소 없이 동작하는 reservationRepository 구현체
+// 모든 변경은 mutex 로 직렬화되므로 mariadb 의 transaction 과 같은 all-or-nothing 을 보장
+// 실제 I/O 가 없으므로 ctx 는 시작 시점에 취소 여부만 확인
+type db struct {
+	mu             sync.RWMutex
+	rooms          map[int64]*reservation.Room
+	archived       map[int64]time.Time
+	reservations   map[int64]*reservation.Detail
+	series         map[int64]*reservation.Series
+	waitlist       map[int64]*reservation.Waitlist
+	subscriptions  map[int64]*webhook.Subscription
+	deliveries     map[int64]*webhook.Delivery
+	users          map[int64]*account.User
+	tokens         map[string]token
+	lastID         int64
+	lastRoomID     int64
+	lastSeriesID   int64
+	lastUserID     int64
+	lastWaitlistID int64
+	lastWebhookID  int64
+	lastDeliveryID int64
+}
+
+// New 는 주어진 이름의 회의실을 1번부터 순서대로 등록한 저장소를 생성
+func New(roomNames ...string) *db {
+	d := &db{
+		rooms:         make(map[int64]*reservation.Room),
+		archived:      make(map[int64]time.Time),
+		reservations:  make(map[int64]*reservation.Detail),
+		series:        make(map[int64]*reservation.Series),
+		waitlist:      make(map[int64]*reservation.Waitlist),
+		subscriptions: make(map[int64]*webhook.Subscription),
+		deliveries:    make(map[int64]*webhook.Delivery),
+		users:         make(map[int64]*account.User),
+		tokens:        make(map[string]token),
+	}
+	for _, name := range roomNames {
+		d.lastRoomID++
+		d.rooms[d.lastRoomID] = &reservation.Room{ID: d.lastRoomID, Name: name}
+	}
+	return d
+}
+
+func (db *db) RoomList(ctx context.Context, filter reservation.RoomFilter) ([]*reservation.Room, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	rooms := make([]*reservation.Room, 0, len(db.rooms))
+	for _, r := range db.rooms {
+		if _, ok := db.archived[r.ID]; ok || !match(r, filter) {
+			continue
+		}
+		room := *r
+		room.Equipment = append([]string(nil), r.Equipment...)
+		rooms = append(rooms, &room)
+	}
+	sort.Slice(rooms, func(i, j int) bool { return rooms[i].ID < rooms[j].ID })
+	return rooms, nil
+}
+
+func (db *db) List(ctx context.Context, startDate, endDate time.Time) ([]*reservation.Detail, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	details := []*reservation.Detail{}
+	for _, r := range db.reservations {
+		if r.Status.Active() && !r.Start.Before(startDate) && r.End.Before(endDate) {
+			detail := *r
+			details = append(details, &detail)
+		}
+	}
+	sort.Slice(details, func(i, j int) bool { return details[i].ID < details[j].ID })
+	return details, nil
+}
+
+// ListOverlapping 은 [startTime, endTime) 와 조금이라도 겹치는 예약을 모두 조회
+func (db *db) ListOverlapping(ctx context.Context, startTime, endTime time.Time) ([]*reservation.Detail, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	details := []*reservation.Detail{}
+	for _, r := range db.reservations {
+		if r.Status.Active() && r.End.After(startTime) && r.Start.Before(endTime) {
+			detail := *r
+			details = append(details, &detail)
+		}
+	}
+	sort.Slice(details, func(i, j int) bool { return details[i].ID < details[j].ID })
+	return details, nil
+}
+
+func (db *db) Available(ctx context.Context, roomID int64, startTime, endTime time.Time) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, errors.WithStack(err)
+	}
+
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	return db.available(roomID, startTime, endTime, 0), nil
+}
+
+// room 은 보관되지 않은 회의실을 찾음. 호출하는 쪽에서 lock 을 잡고 있어야 함
+func (db *db) room(roomID int64) (*reservation.Room, bool) {
+	if _, ok := db.archived[roomID]; ok {
+		return nil, false
+	}
+	room, ok := db.rooms[roomID]
+	return room, ok
+}
+
+// brief 는 다른 저장소와 같이 예약에 포함할 회의실 id, 이름만 남김
+func brief(room *reservation.Room) reservation.Room {
+	return reservation.Room{ID: room.ID, Name: room.Name}
+}
+
+// available 은 다른 저장소와 동일하게 [start, end) 범위로 겹침을 판단하며 취소, 거절된 예약은 제외
+// 호출하는 쪽에서 lock 을 잡고 있어야 함
+func (db *db) available(roomID int64, startTime, endTime time.Time, excludeID int64) bool {
+	for _, r := range db.reservations {
+		if r.Room.ID != roomID || r.ID == excludeID || !r.Status.Active() {
+			continue
+		}
+		if r.End.After(startTime) && r.Start.Before(endTime) {
+			return false
+		}
+	}
+	return true
+}
+
+func (db *db) MakeSeries(ctx context.Context, roomID int64, userName string, rule string, slots []reservation.Slot, memo string, status reservation.Status) (int64, error) {
+	if len(slots) == 0 {
+		return 0, exception.InvalidRequest
+	}
+	if err := ctx.Err(); err != nil {
+		return 0, errors.WithStack(err)
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	room, ok := db.room(roomID)
+	if !ok {
+		return 0, errors.WithStack(exception.RoomNotFound)
+	}
+
+	// 모든 회차가 가능할 때만 반영하기 위해 먼저 검사한 뒤 한꺼번에 추가
+	seriesID := db.lastSeriesID + 1
+	staged := make([]*reservation.Detail, 0, len(slots))
+	for _, slot := range slots {
+		if !db.available(roomID, slot.Start, slot.End, 0) || overlaps(staged, slot.Start, slot.End) {
+			return 0, exception.Unavailable
+		}
+		staged = append(staged, &reservation.Detail{
+			Room:  brief(room),
+			User:  userName,
+			Start: slot.Start, End: slot.End,
+			Memo:       memo,
+			Status:     status,
+			SeriesID:   &seriesID,
+			Occurrence: slot.Occurrence,
+		})
+	}
+
+	db.lastSeriesID = seriesID
+	db.series[seriesID] = &reservation.Series{
+		ID:   seriesID,
+		Room: brief(room),
+		User: userName,
+		Rule: rule,
+		Memo: memo,
+	}
+	for _, detail := range staged {
+		db.insert(detail)
+	}
+	return seriesID, nil
+}
+
+func (db *db) Make(ctx context.Context, roomID int64, userName string, startTime, endTime time.Time, memo string, status reservation.Status) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, errors.WithStack(err)
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	room, ok := db.room(roomID)
+	if !ok {
+		return 0, errors.WithStack(exception.RoomNotFound)
+	}
+
+	if !db.available(roomID, startTime, endTime, 0) {
+		return 0, exception.Unavailable
+	}
+
+	return db.insert(&reservation.Detail{
+		Room:  brief(room),
+		User:  userName,
+		Start: startTime, End: endTime,
+		Memo:   memo,
+		Status: status,
+	}), nil
+}
+
+// insert 는 id 를 발급하여 예약을 저장. 호출하는 쪽에서 lock 을 잡고 있어야 함
+func (db *db) insert(detail *reservation.Detail) int64 {
+	db.lastID++
+	detail.ID = db.lastID
+	db.reservations[detail.ID] = detail
+
+	log.Debugf("memory insert = %+v", detail)
+	return detail.ID
+}
+
+func (db *db) Find(ctx context.Context, reservationID int64) (*reservation.Detail, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	r, ok := db.reservations[reservationID]
+	if !ok {
+		return nil, exception.NotFound
+	}
+	detail := *r
+	return &detail, nil
+}
+
+func (db *db) Modify(ctx context.Context, reservationID int64, roomID int64, userName string, startTime, endTime time.Time, memo string, status reservation.Status) error {
+	if err := ctx.Err(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	r, ok := db.reservations[reservationID]
+	if !ok || !r.Status.Active() {
+		return exception.NotFound
+	}
+	room, ok := db.room(roomID)
+	if !ok {
+		return errors.WithStack(exception.RoomNotFound)
+	}
+
+	if !db.available(roomID, startTime, endTime, reservationID) {
+		return exception.Unavailable
+	}
+
+	r.Room = brief(room)
+	r.User = userName
+	r.Start, r.End = startTime, endTime
+	r.Memo = memo
+	r.Status = status
+	r.Exception = r.SeriesID != nil
+	return nil
+}
+
+// Cancel 은 예약을 cancelled 로 바꾸고 같은 lock 안에서 비워진 시간에 들어갈 수 있는 대기를 예약
+func (db *db) Cancel(ctx context.Context, reservationID int64, now time.Time) ([]int64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	r, ok := db.reservations[reservationID]
+	if !ok || !r.Status.Active() {
+		return nil, exception.NotFound
+	}
+	r.Status = reservation.StatusCancelled
+
+	// 보관된 회의실이면 대기를 예약하지 않음
+	room, ok := db.room(r.Room.ID)
+	if !ok {
+		return []int64{}, nil
+	}
+	return db.promote(room, r.Start, r.End, now), nil
+}
+
+// Review 는 승인 대기 중인 예약의 상태만 바꾸며 이미 처리되었으면 exception.NotPending
+// 거절하면 비워진 시간의 대기를 예약하고 예약한 id 를 반환
+func (db *db) Review(ctx context.Context, reservationID int64, status reservation.Status, now time.Time) ([]int64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	r, ok := db.reservations[reservationID]
+	if !ok || r.Status != reservation.StatusPending {
+		return nil, exception.NotPending
+	}
+	r.Status = status
+
+	if status != reservation.StatusRejected {
+		return []int64{}, nil
+	}
+	return db.promoteSlot(r.Room.ID, r.Start, r.End, now), nil
+}
+
+func overlaps(details []*reservation.Detail, startTime, endTime time.Time) bool {
+	for _, r := range details {
+		if r.End.After(startTime) && r.Start.Before(endTime) {
+			return true
+		}
+	}
+	return false
+}
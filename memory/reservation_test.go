@@ -0,0 +1,470 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rutesun/reservation/exception"
+	"github.com/rutesun/reservation/reservation"
+	"github.com/rutesun/reservation/reservation/reservationtest"
+	"github.com/rutesun/reservation/webhook"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	ctx      = context.Background()
+	roomID   = int64(1)
+	userName = "Ted"
+)
+
+func TestDb_RoomList(t *testing.T) {
+	memory := New("회의실A", "회의실B")
+
+	rooms, err := memory.RoomList(ctx, reservation.RoomFilter{})
+	assert.NoError(t, err)
+	assert.Len(t, rooms, 2)
+	assert.Equal(t, roomID, rooms[0].ID)
+	assert.Equal(t, "회의실A", rooms[0].Name)
+}
+
+func TestDb_Make(t *testing.T) {
+	memory := New("회의실A")
+
+	st, _ := time.Parse(time.RFC3339, "2018-08-04T18:00:00+09:00")
+	et, _ := time.Parse(time.RFC3339, "2018-08-04T19:00:00+09:00")
+
+	id, err := memory.Make(ctx, roomID, userName, st, et, "", reservation.StatusApproved)
+	assert.NoError(t, err)
+	assert.True(t, id > 0)
+
+	check, err := memory.Available(ctx, roomID, st, et)
+	assert.NoError(t, err)
+	assert.False(t, check)
+
+	_, err = memory.Make(ctx, roomID, userName, st.Add(30*time.Minute), et, "", reservation.StatusApproved)
+	assert.EqualError(t, err, exception.Unavailable.Error())
+
+	_, err = memory.Make(ctx, 2, userName, st, et, "", reservation.StatusApproved)
+	assert.EqualError(t, err, exception.RoomNotFound.Error())
+
+	// 끝나는 시간에 바로 이어서 시작하는 예약은 가능
+	_, err = memory.Make(ctx, roomID, userName, et, et.Add(time.Hour), "", reservation.StatusApproved)
+	assert.NoError(t, err)
+}
+
+// weekly 는 st, et 부터 매주 같은 시간으로 n 번 반복하는 예약 시간
+func weekly(st, et time.Time, n int) []reservation.Slot {
+	slots := make([]reservation.Slot, n)
+	for i := range slots {
+		slots[i] = reservation.Slot{Start: st.AddDate(0, 0, 7*i), End: et.AddDate(0, 0, 7*i), Occurrence: i + 1}
+	}
+	return slots
+}
+
+func TestDb_MakeSeries(t *testing.T) {
+	memory := New("회의실A")
+
+	st, _ := time.Parse(time.RFC3339, "2018-08-05T16:00:00+09:00")
+	et, _ := time.Parse(time.RFC3339, "2018-08-05T19:00:00+09:00")
+
+	t.Run("일부 회차가 겹치면 전체 실패", func(t *testing.T) {
+		_, err := memory.Make(ctx, roomID, userName, st.AddDate(0, 0, 21), et.AddDate(0, 0, 21), "", reservation.StatusApproved)
+		assert.NoError(t, err)
+
+		seriesID, err := memory.MakeSeries(ctx, roomID, userName, "FREQ=WEEKLY", weekly(st, et, 5), "", reservation.StatusApproved)
+		assert.EqualError(t, err, exception.Unavailable.Error())
+		assert.Zero(t, seriesID)
+
+		list, err := memory.List(ctx, st, st.AddDate(0, 0, 35))
+		assert.NoError(t, err)
+		assert.Len(t, list, 1)
+	})
+
+	t.Run("정상 반복 예약", func(t *testing.T) {
+		seriesID, err := memory.MakeSeries(ctx, roomID, userName, "FREQ=WEEKLY;COUNT=3", weekly(st, et, 3), "주간회의", reservation.StatusApproved)
+		assert.NoError(t, err)
+
+		series, err := memory.FindSeries(ctx, seriesID)
+		assert.NoError(t, err)
+		assert.Equal(t, "FREQ=WEEKLY;COUNT=3", series.Rule)
+		assert.Len(t, series.Occurrences, 3)
+		for i, o := range series.Occurrences {
+			assert.Equal(t, i+1, o.Occurrence)
+			assert.Equal(t, seriesID, *o.SeriesID)
+			assert.Equal(t, "주간회의", o.Memo)
+		}
+
+		for i := 0; i < 3; i++ {
+			check, err := memory.Available(ctx, roomID, st.AddDate(0, 0, 7*i), et.AddDate(0, 0, 7*i))
+			assert.NoError(t, err)
+			assert.False(t, check)
+		}
+	})
+}
+
+func TestDb_CancelSeries(t *testing.T) {
+	memory := New("회의실A")
+
+	st, _ := time.Parse(time.RFC3339, "2018-08-05T16:00:00+09:00")
+	et, _ := time.Parse(time.RFC3339, "2018-08-05T19:00:00+09:00")
+
+	seriesID, err := memory.MakeSeries(ctx, roomID, userName, "FREQ=WEEKLY;COUNT=3", weekly(st, et, 3), "", reservation.StatusApproved)
+	assert.NoError(t, err)
+	series, err := memory.FindSeries(ctx, seriesID)
+	assert.NoError(t, err)
+
+	_, err = memory.JoinWaitlist(ctx, &reservation.Waitlist{
+		Room: reservation.Room{ID: roomID}, User: "Amy", Start: st.AddDate(0, 0, 14), End: et.AddDate(0, 0, 14),
+		Status: reservation.StatusApproved, CreatedAt: st,
+	})
+	assert.NoError(t, err)
+
+	// 이미 시작한 첫 회차는 남기고 취소된 3번째 회차의 시간은 대기를 예약
+	canceled, promoted, err := memory.CancelSeries(ctx, seriesID, 1, st.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{series.Occurrences[1].ID, series.Occurrences[2].ID}, canceled)
+	if assert.Len(t, promoted, 1) {
+		amy, err := memory.Find(ctx, promoted[0])
+		assert.NoError(t, err)
+		assert.Equal(t, "Amy", amy.User)
+	}
+
+	series, err = memory.FindSeries(ctx, seriesID)
+	assert.NoError(t, err, "반복 예약은 남음")
+	if assert.Len(t, series.Occurrences, 1) {
+		assert.Equal(t, 1, series.Occurrences[0].Occurrence)
+	}
+}
+
+func TestDb_Cancel(t *testing.T) {
+	memory := New("회의실A")
+
+	st, _ := time.Parse(time.RFC3339, "2018-08-07T10:00:00+09:00")
+	et, _ := time.Parse(time.RFC3339, "2018-08-07T12:00:00+09:00")
+
+	id, err := memory.Make(ctx, roomID, userName, st, et, "", reservation.StatusApproved)
+	assert.NoError(t, err)
+
+	promoted, err := memory.Cancel(ctx, id, st)
+	assert.NoError(t, err)
+	assert.Empty(t, promoted)
+
+	check, err := memory.Available(ctx, roomID, st, et)
+	assert.NoError(t, err)
+	assert.True(t, check)
+
+	_, err = memory.Cancel(ctx, id, st)
+	assert.Equal(t, exception.NotFound, errors.Cause(err))
+
+	detail, err := memory.Find(ctx, id)
+	assert.NoError(t, err)
+	assert.Equal(t, reservation.StatusCancelled, detail.Status)
+
+	t.Run("지난 시간의 대기는 예약하지 않음", func(t *testing.T) {
+		id, err := memory.Make(ctx, roomID, userName, st.AddDate(0, 0, 1), et.AddDate(0, 0, 1), "", reservation.StatusApproved)
+		assert.NoError(t, err)
+		_, err = memory.JoinWaitlist(ctx, &reservation.Waitlist{
+			Room: reservation.Room{ID: roomID}, User: "Amy", Start: st.AddDate(0, 0, 1), End: et.AddDate(0, 0, 1),
+			Status: reservation.StatusApproved, CreatedAt: st,
+		})
+		assert.NoError(t, err)
+
+		promoted, err := memory.Cancel(ctx, id, et.AddDate(0, 0, 1))
+		assert.NoError(t, err)
+		assert.Empty(t, promoted)
+	})
+
+	_, err = memory.Make(ctx, roomID, userName, st, et, "", reservation.StatusApproved)
+	assert.NoError(t, err, "취소된 예약과 같은 시간에 다시 예약")
+}
+
+func TestDb_Review(t *testing.T) {
+	memory := New("회의실A")
+
+	st, _ := time.Parse(time.RFC3339, "2018-08-07T10:00:00+09:00")
+	et, _ := time.Parse(time.RFC3339, "2018-08-07T12:00:00+09:00")
+
+	id, err := memory.Make(ctx, roomID, userName, st, et, "", reservation.StatusPending)
+	assert.NoError(t, err)
+
+	check, err := memory.Available(ctx, roomID, st, et)
+	assert.NoError(t, err)
+	assert.False(t, check, "승인 대기 중인 예약도 시간을 차지")
+
+	_, err = memory.JoinWaitlist(ctx, &reservation.Waitlist{
+		Room: reservation.Room{ID: roomID}, User: "Amy", Start: st, End: et,
+		Status: reservation.StatusApproved, CreatedAt: st.AddDate(0, 0, -1),
+	})
+	assert.NoError(t, err)
+
+	promoted, err := memory.Review(ctx, id, reservation.StatusRejected, st)
+	assert.NoError(t, err)
+	assert.Len(t, promoted, 1, "거절된 시간은 대기를 예약")
+	_, err = memory.Review(ctx, id, reservation.StatusApproved, st)
+	assert.Equal(t, exception.NotPending, err)
+
+	detail, err := memory.Find(ctx, id)
+	assert.NoError(t, err)
+	assert.Equal(t, reservation.StatusRejected, detail.Status)
+
+	list, err := memory.List(ctx, st.AddDate(0, 0, -1), st.AddDate(0, 0, 1))
+	assert.NoError(t, err)
+	if assert.Len(t, list, 1, "거절된 예약은 목록에서 제외") {
+		assert.Equal(t, "Amy", list[0].User)
+	}
+
+	err = memory.Modify(ctx, id, roomID, userName, st, et, "", reservation.StatusPending)
+	assert.Equal(t, exception.NotFound, errors.Cause(err))
+}
+
+func TestDb_Waitlist(t *testing.T) {
+	memory := New("회의실A")
+
+	st, _ := time.Parse(time.RFC3339, "2018-08-07T10:00:00+09:00")
+	et, _ := time.Parse(time.RFC3339, "2018-08-07T12:00:00+09:00")
+
+	id, err := memory.Make(ctx, roomID, userName, st, et, "", reservation.StatusApproved)
+	assert.NoError(t, err)
+
+	join := func(user string, start, end time.Time, status reservation.Status) int64 {
+		id, err := memory.JoinWaitlist(ctx, &reservation.Waitlist{
+			Room: reservation.Room{ID: roomID}, User: user, Start: start, End: end,
+			Memo: user, Status: status, CreatedAt: st.AddDate(0, 0, -1),
+		})
+		assert.NoError(t, err)
+		return id
+	}
+	join("Amy", st, st.Add(time.Hour), reservation.StatusPending)
+	bob := join("Bob", st, et, reservation.StatusApproved)
+	join("Carl", st.Add(time.Hour), et, reservation.StatusApproved)
+	dan := join("Dan", et.Add(time.Hour), et.Add(2*time.Hour), reservation.StatusApproved)
+
+	w, err := memory.FindWaitlist(ctx, bob)
+	assert.NoError(t, err)
+	assert.Equal(t, "회의실A", w.Room.Name)
+	assert.True(t, st.Equal(w.Start))
+
+	// 먼저 들어온 Amy 가 예약된 뒤 Bob 은 들어갈 수 없고 Carl 은 남은 시간에 예약됨
+	promoted, err := memory.Cancel(ctx, id, st)
+	assert.NoError(t, err)
+	if assert.Len(t, promoted, 2) {
+		amy, err := memory.Find(ctx, promoted[0])
+		assert.NoError(t, err)
+		assert.Equal(t, "Amy", amy.User)
+		assert.Equal(t, "Amy", amy.Memo)
+		assert.Equal(t, reservation.StatusPending, amy.Status)
+
+		carl, err := memory.Find(ctx, promoted[1])
+		assert.NoError(t, err)
+		assert.Equal(t, "Carl", carl.User)
+		assert.True(t, et.Equal(carl.End))
+	}
+
+	list, err := memory.ListWaitlist(ctx, "Amy")
+	assert.NoError(t, err)
+	assert.Empty(t, list)
+	list, err = memory.ListWaitlist(ctx, "Dan")
+	assert.NoError(t, err)
+	if assert.Len(t, list, 1) {
+		assert.Equal(t, dan, list[0].ID)
+	}
+
+	assert.NoError(t, memory.LeaveWaitlist(ctx, bob))
+	assert.Equal(t, exception.WaitlistNotFound, memory.LeaveWaitlist(ctx, bob))
+	_, err = memory.FindWaitlist(ctx, bob)
+	assert.Equal(t, exception.WaitlistNotFound, errors.Cause(err))
+}
+
+func TestDb_NoShow(t *testing.T) {
+	memory := New("회의실A")
+
+	at := func(hour int) time.Time {
+		return time.Date(2018, 8, 7, hour, 0, 0, 0, time.FixedZone("KST", 9*60*60))
+	}
+	started, err := memory.Make(ctx, roomID, userName, at(10), at(11), "", reservation.StatusApproved)
+	assert.NoError(t, err)
+	ended, err := memory.Make(ctx, roomID, userName, at(9), at(10), "", reservation.StatusApproved)
+	assert.NoError(t, err)
+	checkedIn, err := memory.Make(ctx, roomID, userName, at(11), at(12), "", reservation.StatusApproved)
+	assert.NoError(t, err)
+
+	assert.NoError(t, memory.CheckIn(ctx, checkedIn, at(10).Add(50*time.Minute)))
+	detail, err := memory.Find(ctx, checkedIn)
+	assert.NoError(t, err)
+	if assert.NotNil(t, detail.CheckedInAt) {
+		assert.True(t, at(10).Add(50*time.Minute).Equal(*detail.CheckedInAt))
+	}
+
+	// 먼저 들어왔지만 이미 시작한 Bob 의 대기는 건너뛰고 남은 시간에 시작하는 Amy 의 대기만 예약
+	for _, w := range []reservation.Waitlist{
+		{Room: reservation.Room{ID: roomID}, User: "Bob", Start: at(10), End: at(11), Status: reservation.StatusApproved, CreatedAt: at(9)},
+		{Room: reservation.Room{ID: roomID}, User: "Amy", Start: at(10).Add(30 * time.Minute), End: at(11), Status: reservation.StatusApproved, CreatedAt: at(9)},
+	} {
+		w := w
+		_, err = memory.JoinWaitlist(ctx, &w)
+		assert.NoError(t, err)
+	}
+
+	// 이미 끝난 예약과 체크인한 예약은 그대로 둠
+	released, promoted, err := memory.ReleaseNoShows(ctx, at(10).Add(5*time.Minute), at(10).Add(20*time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{started}, released)
+	if assert.Len(t, promoted, 1) {
+		detail, err := memory.Find(ctx, promoted[0])
+		assert.NoError(t, err)
+		assert.Equal(t, "Amy", detail.User)
+	}
+	released, promoted, err = memory.ReleaseNoShows(ctx, at(11).Add(5*time.Minute), at(11).Add(20*time.Minute))
+	assert.NoError(t, err)
+	assert.Empty(t, released)
+	assert.Empty(t, promoted)
+
+	detail, err = memory.Find(ctx, started)
+	assert.NoError(t, err)
+	assert.Equal(t, reservation.StatusNoShow, detail.Status)
+	detail, err = memory.Find(ctx, ended)
+	assert.NoError(t, err)
+	assert.Equal(t, reservation.StatusApproved, detail.Status)
+
+	check, err := memory.Available(ctx, roomID, at(10), at(10).Add(30*time.Minute))
+	assert.NoError(t, err)
+	assert.True(t, check, "no_show 인 예약의 시간은 다시 예약 가능")
+	assert.Equal(t, exception.NotFound, memory.CheckIn(ctx, started, at(10).Add(30*time.Minute)))
+
+	counts, err := memory.CountNoShows(ctx, at(0), at(24))
+	assert.NoError(t, err)
+	assert.Equal(t, []reservation.NoShowCount{{User: userName, Count: 1}}, counts)
+}
+
+func TestDb_MakeConcurrently(t *testing.T) {
+	memory := New("회의실A")
+
+	st, _ := time.Parse(time.RFC3339, "2018-08-08T10:00:00+09:00")
+	et, _ := time.Parse(time.RFC3339, "2018-08-08T12:00:00+09:00")
+
+	reservationtest.MakeConcurrently(t, memory, roomID, st, et, 100)
+}
+
+func TestDb_Canceled(t *testing.T) {
+	memory := New("회의실A")
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+
+	st, _ := time.Parse(time.RFC3339, "2018-08-07T10:00:00+09:00")
+	_, err := memory.Make(canceled, roomID, userName, st, st.Add(time.Hour), "", reservation.StatusApproved)
+	assert.Equal(t, context.Canceled, errors.Cause(err))
+
+	list, err := memory.List(ctx, st, st.AddDate(0, 0, 1))
+	assert.NoError(t, err)
+	assert.Empty(t, list)
+}
+
+func TestDb_Modify(t *testing.T) {
+	memory := New("회의실A", "회의실B")
+
+	st, _ := time.Parse(time.RFC3339, "2018-08-07T10:00:00+09:00")
+	et, _ := time.Parse(time.RFC3339, "2018-08-07T12:00:00+09:00")
+
+	id, err := memory.Make(ctx, roomID, userName, st, et, "", reservation.StatusApproved)
+	assert.NoError(t, err)
+	other, err := memory.Make(ctx, 2, userName, st, et, "", reservation.StatusApproved)
+	assert.NoError(t, err)
+
+	// 자기 자신과의 겹침은 무시
+	err = memory.Modify(ctx, id, roomID, "Ryan", st.Add(time.Hour), et.Add(time.Hour), "memo", reservation.StatusApproved)
+	assert.NoError(t, err)
+
+	detail, err := memory.Find(ctx, id)
+	assert.NoError(t, err)
+	assert.Equal(t, "Ryan", detail.User)
+	assert.True(t, st.Add(time.Hour).Equal(detail.Start))
+
+	err = memory.Modify(ctx, id, 2, userName, st, et, "", reservation.StatusApproved)
+	assert.EqualError(t, err, exception.Unavailable.Error())
+
+	err = memory.Modify(ctx, other+1, roomID, userName, st, et, "", reservation.StatusApproved)
+	assert.EqualError(t, err, exception.NotFound.Error())
+}
+
+func TestDb_Webhook(t *testing.T) {
+	memory := New("회의실A")
+
+	now, _ := time.Parse(time.RFC3339, "2018-08-07T10:00:00+09:00")
+	missing := int64(999)
+	_, err := memory.CreateSubscription(ctx, &webhook.Subscription{URL: "http://localhost/hook", Secret: "s", RoomID: &missing, CreatedAt: now})
+	assert.Equal(t, exception.RoomNotFound, errors.Cause(err))
+
+	id, err := memory.CreateSubscription(ctx, &webhook.Subscription{
+		URL: "http://localhost/hook", Secret: "s", RoomID: &roomID, CreatedBy: "admin", CreatedAt: now,
+		Events: []reservation.EventType{reservation.EventCancelled, reservation.EventCreated},
+	})
+	assert.NoError(t, err)
+	all, err := memory.CreateSubscription(ctx, &webhook.Subscription{URL: "http://localhost/all", Secret: "s", CreatedAt: now})
+	assert.NoError(t, err)
+
+	list, err := memory.ListSubscriptions(ctx)
+	assert.NoError(t, err)
+	if assert.Len(t, list, 2) {
+		assert.Equal(t, []reservation.EventType{reservation.EventCancelled, reservation.EventCreated}, list[0].Events)
+		assert.Equal(t, roomID, *list[0].RoomID)
+		assert.Empty(t, list[1].Events)
+		assert.Nil(t, list[1].RoomID)
+	}
+
+	deliver := func(subscriptionID int64, next time.Time) int64 {
+		id, err := memory.CreateDelivery(ctx, &webhook.Delivery{
+			SubscriptionID: subscriptionID, EventType: reservation.EventCreated, Payload: []byte(`{"type":"reservation.created"}`),
+			Status: webhook.DeliveryPending, NextAttemptAt: &next, CreatedAt: now,
+		})
+		assert.NoError(t, err)
+		return id
+	}
+	later := deliver(id, now.Add(time.Minute))
+	first := deliver(id, now)
+	deliver(all, now)
+
+	due, err := memory.DueDeliveries(ctx, now, 10)
+	assert.NoError(t, err)
+	if assert.Len(t, due, 2) {
+		assert.Equal(t, first, due[0].ID)
+		assert.JSONEq(t, `{"type":"reservation.created"}`, string(due[0].Payload))
+	}
+
+	// 실패하여 다시 보낼 시간을 미루면 아직 보낼 때가 아님
+	next := now.Add(time.Hour)
+	due[0].Attempts, due[0].ResponseStatus, due[0].LastError, due[0].NextAttemptAt = 1, 500, "500 로 응답했습니다", &next
+	assert.NoError(t, memory.UpdateDelivery(ctx, due[0]))
+	due, err = memory.DueDeliveries(ctx, now.Add(time.Minute), 10)
+	assert.NoError(t, err)
+	assert.Len(t, due, 2)
+
+	d, err := memory.FindDelivery(ctx, first)
+	assert.NoError(t, err)
+	assert.Equal(t, 500, d.ResponseStatus)
+	assert.True(t, next.Equal(*d.NextAttemptAt))
+
+	d.Status, d.Attempts, d.ResponseStatus, d.LastError, d.NextAttemptAt, d.DeliveredAt = webhook.DeliverySucceeded, 2, 200, "", nil, &next
+	assert.NoError(t, memory.UpdateDelivery(ctx, d))
+	succeeded, err := memory.ListDeliveries(ctx, id, webhook.DeliverySucceeded)
+	assert.NoError(t, err)
+	if assert.Len(t, succeeded, 1) {
+		assert.Equal(t, first, succeeded[0].ID)
+		assert.Nil(t, succeeded[0].NextAttemptAt)
+	}
+	deliveries, err := memory.ListDeliveries(ctx, id, "")
+	assert.NoError(t, err)
+	if assert.Len(t, deliveries, 2) {
+		assert.Equal(t, first, deliveries[0].ID, "최근 전달부터")
+		assert.Equal(t, later, deliveries[1].ID)
+	}
+
+	assert.NoError(t, memory.DeleteSubscription(ctx, id))
+	assert.Equal(t, exception.WebhookNotFound, memory.DeleteSubscription(ctx, id))
+	_, err = memory.FindDelivery(ctx, first)
+	assert.Equal(t, exception.DeliveryNotFound, err)
+	_, err = memory.FindSubscription(ctx, id)
+	assert.Equal(t, exception.WebhookNotFound, err)
+}
@@ -0,0 +1,115 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rutesun/reservation/exception"
+	"github.com/rutesun/reservation/reservation"
+)
+
+func (db *db) CreateRoom(ctx context.Context, room *reservation.Room) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, errors.WithStack(err)
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	db.lastRoomID++
+	stored := *room
+	stored.ID = db.lastRoomID
+	stored.Equipment = append([]string(nil), room.Equipment...)
+	db.rooms[stored.ID] = &stored
+	return stored.ID, nil
+}
+
+func (db *db) UpdateRoom(ctx context.Context, room *reservation.Room) error {
+	if err := ctx.Err(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	stored, ok := db.room(room.ID)
+	if !ok {
+		return exception.RoomNotFound
+	}
+	stored.Name = room.Name
+	stored.Capacity = room.Capacity
+	stored.Building = room.Building
+	stored.Floor = room.Floor
+	stored.Policy = room.Policy
+	stored.Group = room.Group
+	stored.RequiresApproval = room.RequiresApproval
+	stored.Equipment = append([]string(nil), room.Equipment...)
+
+	// 다른 저장소는 조회할 때 join 하므로 저장된 예약의 회의실 이름도 함께 변경
+	for _, r := range db.reservations {
+		if r.Room.ID == room.ID {
+			r.Room.Name = room.Name
+		}
+	}
+	return nil
+}
+
+func (db *db) ArchiveRoom(ctx context.Context, roomID int64, now time.Time, cascade bool) ([]int64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if _, ok := db.room(roomID); !ok {
+		return nil, exception.RoomNotFound
+	}
+
+	upcoming := []int64{}
+	for _, r := range db.reservations {
+		if r.Room.ID == roomID && !r.Start.Before(now) && r.Status.Active() {
+			upcoming = append(upcoming, r.ID)
+		}
+	}
+	sort.Slice(upcoming, func(i, j int) bool { return upcoming[i] < upcoming[j] })
+
+	if len(upcoming) > 0 && !cascade {
+		return nil, exception.RoomInUse
+	}
+	for _, id := range upcoming {
+		db.reservations[id].Status = reservation.StatusCancelled
+	}
+
+	// 보관된 회의실의 대기는 예약될 수 없으므로 지움
+	for id, w := range db.waitlist {
+		if w.Room.ID == roomID {
+			delete(db.waitlist, id)
+		}
+	}
+
+	db.archived[roomID] = now
+	return upcoming, nil
+}
+
+// match 는 회의실이 조회 조건을 모두 만족하는지 확인
+func match(room *reservation.Room, filter reservation.RoomFilter) bool {
+	if room.Capacity < filter.MinCapacity {
+		return false
+	}
+	for _, want := range filter.Equipment {
+		found := false
+		for _, e := range room.Equipment {
+			if e == want {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
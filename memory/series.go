@@ -0,0 +1,74 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rutesun/reservation/exception"
+	"github.com/rutesun/reservation/reservation"
+)
+
+func (db *db) FindSeries(ctx context.Context, seriesID int64) (*reservation.Series, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	s, ok := db.series[seriesID]
+	if !ok {
+		return nil, exception.SeriesNotFound
+	}
+
+	series := *s
+	series.Room.Name = db.rooms[s.Room.ID].Name
+	series.Occurrences = []*reservation.Detail{}
+	for _, r := range db.reservations {
+		if r.SeriesID != nil && *r.SeriesID == seriesID && r.Status.Active() {
+			detail := *r
+			series.Occurrences = append(series.Occurrences, &detail)
+		}
+	}
+	sort.Slice(series.Occurrences, func(i, j int) bool {
+		return series.Occurrences[i].Occurrence < series.Occurrences[j].Occurrence
+	})
+	return &series, nil
+}
+
+// CancelSeries 는 from 번째 회차부터 아직 시작하지 않은 회차를 취소(StatusCancelled) 하고 회차 순서로 id 를 반환
+// 반복 예약과 지난 회차는 그대로 남으며 회차마다 비워진 시간의 대기를 예약
+func (db *db) CancelSeries(ctx context.Context, seriesID int64, from int, now time.Time) ([]int64, []int64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, errors.WithStack(err)
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if _, ok := db.series[seriesID]; !ok {
+		return nil, nil, exception.SeriesNotFound
+	}
+
+	occurrences := []*reservation.Detail{}
+	for _, r := range db.reservations {
+		if r.SeriesID != nil && *r.SeriesID == seriesID && r.Occurrence >= from && !r.Start.Before(now) && r.Status.Active() {
+			occurrences = append(occurrences, r)
+		}
+	}
+	sort.Slice(occurrences, func(i, j int) bool { return occurrences[i].Occurrence < occurrences[j].Occurrence })
+
+	canceled := make([]int64, len(occurrences))
+	for i, r := range occurrences {
+		r.Status = reservation.StatusCancelled
+		canceled[i] = r.ID
+	}
+
+	promoted := []int64{}
+	for _, r := range occurrences {
+		promoted = append(promoted, db.promoteSlot(r.Room.ID, r.Start, r.End, now)...)
+	}
+	return canceled, promoted, nil
+}
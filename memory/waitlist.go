@@ -0,0 +1,128 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rutesun/reservation/exception"
+	"github.com/rutesun/reservation/reservation"
+)
+
+func (db *db) JoinWaitlist(ctx context.Context, w *reservation.Waitlist) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, errors.WithStack(err)
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	room, ok := db.room(w.Room.ID)
+	if !ok {
+		return 0, errors.WithStack(exception.RoomNotFound)
+	}
+
+	db.lastWaitlistID++
+	entry := *w
+	entry.ID = db.lastWaitlistID
+	entry.Room = brief(room)
+	db.waitlist[entry.ID] = &entry
+	return entry.ID, nil
+}
+
+func (db *db) FindWaitlist(ctx context.Context, waitlistID int64) (*reservation.Waitlist, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	w, ok := db.waitlist[waitlistID]
+	if !ok {
+		return nil, exception.WaitlistNotFound
+	}
+	entry := *w
+	return &entry, nil
+}
+
+func (db *db) ListWaitlist(ctx context.Context, userName string) ([]*reservation.Waitlist, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	list := []*reservation.Waitlist{}
+	for _, w := range db.sortedWaitlist() {
+		if w.User == userName {
+			entry := *w
+			list = append(list, &entry)
+		}
+	}
+	return list, nil
+}
+
+func (db *db) LeaveWaitlist(ctx context.Context, waitlistID int64) error {
+	if err := ctx.Err(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if _, ok := db.waitlist[waitlistID]; !ok {
+		return exception.WaitlistNotFound
+	}
+	delete(db.waitlist, waitlistID)
+	return nil
+}
+
+// sortedWaitlist 는 대기를 들어온(id) 순서로 정렬. 호출하는 쪽에서 lock 을 잡고 있어야 함
+func (db *db) sortedWaitlist() []*reservation.Waitlist {
+	list := make([]*reservation.Waitlist, 0, len(db.waitlist))
+	for _, w := range db.waitlist {
+		list = append(list, w)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
+
+// promoteSlot 은 보관되지 않은 회의실이면 promote. 호출하는 쪽에서 lock 을 잡고 있어야 함
+func (db *db) promoteSlot(roomID int64, startTime, endTime, now time.Time) []int64 {
+	room, ok := db.room(roomID)
+	if !ok {
+		return []int64{}
+	}
+	return db.promote(room, startTime, endTime, now)
+}
+
+// promote 는 [startTime, endTime) 안에 시작하는 대기를 먼저 들어온 순서대로 확인하여
+// 예약할 수 있으면 예약하고 대기에서 지우며 now 전에 시작하는 대기는 건너뜀. 호출하는 쪽에서 lock 을 잡고 있어야 함
+func (db *db) promote(room *reservation.Room, startTime, endTime, now time.Time) []int64 {
+	if startTime.Before(now) {
+		startTime = now
+	}
+
+	promoted := []int64{}
+	for _, w := range db.sortedWaitlist() {
+		if w.Room.ID != room.ID || w.Start.Before(startTime) || !w.Start.Before(endTime) {
+			continue
+		}
+		if !db.available(room.ID, w.Start, w.End, 0) {
+			continue
+		}
+		id := db.insert(&reservation.Detail{
+			Room:  brief(room),
+			User:  w.User,
+			Start: w.Start, End: w.End,
+			Memo:   w.Memo,
+			Status: w.Status,
+		})
+		delete(db.waitlist, w.ID)
+		promoted = append(promoted, id)
+	}
+	return promoted
+}
@@ -0,0 +1,204 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rutesun/reservation/exception"
+	"github.com/rutesun/reservation/reservation"
+	"github.com/rutesun/reservation/webhook"
+)
+
+func (db *db) CreateSubscription(ctx context.Context, s *webhook.Subscription) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, errors.WithStack(err)
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if s.RoomID != nil {
+		if _, ok := db.room(*s.RoomID); !ok {
+			return 0, errors.WithStack(exception.RoomNotFound)
+		}
+	}
+
+	db.lastWebhookID++
+	subscription := copySubscription(s)
+	subscription.ID = db.lastWebhookID
+	db.subscriptions[subscription.ID] = subscription
+	return subscription.ID, nil
+}
+
+func (db *db) FindSubscription(ctx context.Context, subscriptionID int64) (*webhook.Subscription, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	s, ok := db.subscriptions[subscriptionID]
+	if !ok {
+		return nil, exception.WebhookNotFound
+	}
+	return copySubscription(s), nil
+}
+
+func (db *db) ListSubscriptions(ctx context.Context) ([]*webhook.Subscription, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	list := make([]*webhook.Subscription, 0, len(db.subscriptions))
+	for _, s := range db.subscriptions {
+		list = append(list, copySubscription(s))
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list, nil
+}
+
+func (db *db) DeleteSubscription(ctx context.Context, subscriptionID int64) error {
+	if err := ctx.Err(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if _, ok := db.subscriptions[subscriptionID]; !ok {
+		return exception.WebhookNotFound
+	}
+	delete(db.subscriptions, subscriptionID)
+	for id, d := range db.deliveries {
+		if d.SubscriptionID == subscriptionID {
+			delete(db.deliveries, id)
+		}
+	}
+	return nil
+}
+
+func (db *db) CreateDelivery(ctx context.Context, d *webhook.Delivery) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, errors.WithStack(err)
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if _, ok := db.subscriptions[d.SubscriptionID]; !ok {
+		return 0, exception.WebhookNotFound
+	}
+	db.lastDeliveryID++
+	delivery := copyDelivery(d)
+	delivery.ID = db.lastDeliveryID
+	db.deliveries[delivery.ID] = delivery
+	return delivery.ID, nil
+}
+
+func (db *db) FindDelivery(ctx context.Context, deliveryID int64) (*webhook.Delivery, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	d, ok := db.deliveries[deliveryID]
+	if !ok {
+		return nil, exception.DeliveryNotFound
+	}
+	return copyDelivery(d), nil
+}
+
+func (db *db) ListDeliveries(ctx context.Context, subscriptionID int64, status webhook.DeliveryStatus) ([]*webhook.Delivery, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	list := []*webhook.Delivery{}
+	for _, d := range db.deliveries {
+		if d.SubscriptionID == subscriptionID && (status == "" || d.Status == status) {
+			list = append(list, copyDelivery(d))
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID > list[j].ID })
+	return list, nil
+}
+
+func (db *db) DueDeliveries(ctx context.Context, now time.Time, limit int) ([]*webhook.Delivery, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	list := []*webhook.Delivery{}
+	for _, d := range db.deliveries {
+		if d.Status == webhook.DeliveryPending && d.NextAttemptAt != nil && !d.NextAttemptAt.After(now) {
+			list = append(list, copyDelivery(d))
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if !list[i].NextAttemptAt.Equal(*list[j].NextAttemptAt) {
+			return list[i].NextAttemptAt.Before(*list[j].NextAttemptAt)
+		}
+		return list[i].ID < list[j].ID
+	})
+	if len(list) > limit {
+		list = list[:limit]
+	}
+	return list, nil
+}
+
+func (db *db) UpdateDelivery(ctx context.Context, d *webhook.Delivery) error {
+	if err := ctx.Err(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	stored, ok := db.deliveries[d.ID]
+	if !ok {
+		// 보내는 사이 webhook 을 지운 경우
+		return nil
+	}
+	updated := copyDelivery(d)
+	updated.SubscriptionID, updated.EventType, updated.Payload, updated.CreatedAt = stored.SubscriptionID, stored.EventType, stored.Payload, stored.CreatedAt
+	db.deliveries[d.ID] = updated
+	return nil
+}
+
+func copySubscription(s *webhook.Subscription) *webhook.Subscription {
+	subscription := *s
+	subscription.Events = append([]reservation.EventType{}, s.Events...)
+	if s.RoomID != nil {
+		roomID := *s.RoomID
+		subscription.RoomID = &roomID
+	}
+	return &subscription
+}
+
+func copyDelivery(d *webhook.Delivery) *webhook.Delivery {
+	delivery := *d
+	delivery.Payload = append([]byte(nil), d.Payload...)
+	if d.NextAttemptAt != nil {
+		next := *d.NextAttemptAt
+		delivery.NextAttemptAt = &next
+	}
+	if d.DeliveredAt != nil {
+		delivered := *d.DeliveredAt
+		delivery.DeliveredAt = &delivered
+	}
+	return &delivery
+}
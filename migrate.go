@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/rutesun/reservation/config"
+	"github.com/rutesun/reservation/migration"
+)
+
+// runMigrate 는 migrate 하위 명령을 실행
+//
+//	up            적용되지 않은 migration 을 모두 적용 (기본값)
+//	down [n]      최근 migration n 개를 되돌림 (기본값 1)
+//	to <version>  주어진 version 으로 적용하거나 되돌림
+//	version       현재 적용된 version 출력
+func runMigrate(setting *config.Setting, args []string) error {
+	if setting.Driver == config.Memory {
+		return errors.New("memory 저장소는 migration 이 필요하지 않습니다")
+	}
+
+	m, err := migration.New(setting.DB, setting.Driver)
+	if err != nil {
+		return err
+	}
+
+	command := "up"
+	if len(args) > 0 {
+		command = args[0]
+	}
+
+	switch command {
+	case "up":
+		err = m.Up()
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil {
+				return errors.Wrap(err, "잘못된 횟수입니다")
+			}
+		}
+		err = m.Down(steps)
+	case "to":
+		if len(args) < 2 {
+			return errors.New("version 이 필요합니다")
+		}
+		var version int
+		if version, err = strconv.Atoi(args[1]); err != nil {
+			return errors.Wrap(err, "잘못된 version 입니다")
+		}
+		err = m.To(version)
+	case "version":
+	default:
+		return errors.Errorf("알 수 없는 명령입니다: %s", command)
+	}
+	if err != nil {
+		return err
+	}
+
+	version, err := m.Version()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("schema version: %d (latest: %d)\n", version, m.Latest())
+	return nil
+}
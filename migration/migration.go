@@ -0,0 +1,262 @@
+package migration
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"github.com/rutesun/reservation/log"
+	sq "gopkg.in/Masterminds/squirrel.v1"
+)
+
+// sql/<dialect>/<version>_<name>.(up|down).sql 형식의 파일을 binary 에 포함
+//
+//go:embed sql
+var files embed.FS
+
+var filePattern = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+const versionTable = "schema_version"
+
+// Migration 은 같은 version 의 up, down sql 한 쌍
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Load 는 dialect(mariadb, postgres, sqlite) 에 해당하는 migration 을 version 순으로 반환
+func Load(dialect string) ([]*Migration, error) {
+	dir := path.Join("sql", dialect)
+	entries, err := fs.ReadDir(files, dir)
+	if err != nil {
+		return nil, errors.Errorf("unsupported dialect: %s", dialect)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		matches := filePattern.FindStringSubmatch(entry.Name())
+		if matches == nil {
+			continue
+		}
+
+		version, _ := strconv.Atoi(matches[1])
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: matches[2]}
+			byVersion[version] = m
+		} else if m.Name != matches[2] {
+			return nil, errors.Errorf("duplicated migration version: %d", version)
+		}
+
+		body, err := fs.ReadFile(files, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if matches[3] == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	migrations := make([]*Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, errors.Errorf("migration %04d_%s must have both up and down", m.Version, m.Name)
+		}
+		migrations = append(migrations, m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Migrator 는 schema_version 테이블에 적용된 version 을 기록하며 migration 을 적용하거나 되돌림
+type Migrator struct {
+	DB         *sqlx.DB
+	builder    sq.StatementBuilderType
+	migrations []*Migration
+}
+
+func New(db *sqlx.DB, dialect string) (*Migrator, error) {
+	migrations, err := Load(dialect)
+	if err != nil {
+		return nil, err
+	}
+
+	builder := sq.StatementBuilder
+	if dialect == "postgres" {
+		builder = builder.PlaceholderFormat(sq.Dollar)
+	}
+	return &Migrator{DB: db, builder: builder, migrations: migrations}, nil
+}
+
+// Latest 는 포함된 migration 중 가장 높은 version
+func (m *Migrator) Latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Version 은 현재 DB 에 적용된 version, 한번도 적용되지 않았으면 0
+func (m *Migrator) Version() (int, error) {
+	if err := m.ensureVersionTable(); err != nil {
+		return 0, err
+	}
+
+	query, args, err := m.builder.Select("COALESCE(MAX(version), 0)").From(versionTable).ToSql()
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+
+	version := 0
+	if err := m.DB.Get(&version, query, args...); err != nil {
+		return 0, errors.WithStack(err)
+	}
+	return version, nil
+}
+
+// Up 은 적용되지 않은 migration 을 모두 적용
+func (m *Migrator) Up() error {
+	return m.To(m.Latest())
+}
+
+// Down 은 최근에 적용된 migration 부터 steps 개를 되돌림
+func (m *Migrator) Down(steps int) error {
+	current, err := m.Version()
+	if err != nil {
+		return err
+	}
+
+	target := 0
+	applied := 0
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		if m.migrations[i].Version > current {
+			continue
+		}
+		if applied == steps {
+			target = m.migrations[i].Version
+			break
+		}
+		applied++
+	}
+	return m.To(target)
+}
+
+// To 는 주어진 version 이 되도록 migration 을 적용하거나 되돌림
+func (m *Migrator) To(target int) error {
+	current, err := m.Version()
+	if err != nil {
+		return err
+	}
+
+	if target > current {
+		for _, migration := range m.migrations {
+			if migration.Version <= current || migration.Version > target {
+				continue
+			}
+			if err := m.apply(migration, true); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		migration := m.migrations[i]
+		if migration.Version > current || migration.Version <= target {
+			continue
+		}
+		if err := m.apply(migration, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// apply 는 migration 하나를 transaction 안에서 실행하고 schema_version 을 갱신
+// mariadb 는 DDL 이 transaction 에 포함되지 않으므로 실패 시 수동으로 확인해야 함
+func (m *Migrator) apply(migration *Migration, up bool) error {
+	body, direction := migration.Down, "down"
+	if up {
+		body, direction = migration.Up, "up"
+	}
+	log.Infof("migration %04d_%s %s", migration.Version, migration.Name, direction)
+
+	tx, err := m.DB.Beginx()
+	if err != nil {
+		return errors.Wrap(err, "Fail to begin transaction")
+	}
+
+	for _, statement := range split(body) {
+		if _, err := tx.Exec(statement); err != nil {
+			tx.Rollback()
+			return errors.Wrapf(err, "migration %04d_%s %s", migration.Version, migration.Name, direction)
+		}
+	}
+
+	var (
+		query string
+		args  []interface{}
+	)
+	if up {
+		query, args, err = m.builder.Insert(versionTable).
+			Columns("version", "name").
+			Values(migration.Version, migration.Name).ToSql()
+	} else {
+		query, args, err = m.builder.Delete(versionTable).Where("version = ?", migration.Version).ToSql()
+	}
+	if err != nil {
+		tx.Rollback()
+		return errors.WithStack(err)
+	}
+	if _, err := tx.Exec(query, args...); err != nil {
+		tx.Rollback()
+		return errors.WithStack(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.Wrap(err, "Fail to commit transaction")
+	}
+	return nil
+}
+
+func (m *Migrator) ensureVersionTable() error {
+	_, err := m.DB.Exec(fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	version    INTEGER      NOT NULL PRIMARY KEY,
+	name       VARCHAR(255) NOT NULL,
+	applied_at TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP
+)`, versionTable))
+	return errors.WithStack(err)
+}
+
+// split 은 ';' 로 끝나는 줄을 기준으로 statement 를 나눔
+// mysql driver 는 한번에 여러 statement 를 실행할 수 없음
+func split(body string) []string {
+	statements := []string{}
+	current := []string{}
+	for _, line := range strings.Split(body, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		current = append(current, line)
+		if strings.HasSuffix(trimmed, ";") {
+			statements = append(statements, strings.TrimSuffix(strings.TrimSpace(strings.Join(current, "\n")), ";"))
+			current = current[:0]
+		}
+	}
+	if len(current) > 0 {
+		statements = append(statements, strings.TrimSpace(strings.Join(current, "\n")))
+	}
+	return statements
+}
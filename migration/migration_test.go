@@ -0,0 +1,79 @@
+package migration
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoad(t *testing.T) {
+	var versions []int
+	for _, dialect := range []string{"mariadb", "postgres", "sqlite"} {
+		migrations, err := Load(dialect)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, migrations)
+
+		// 모든 dialect 는 같은 version 목록을 가져야 함
+		current := make([]int, len(migrations))
+		for i, m := range migrations {
+			current[i] = m.Version
+			assert.NotEmpty(t, m.Up)
+			assert.NotEmpty(t, m.Down)
+		}
+		if versions != nil {
+			assert.Equal(t, versions, current, dialect)
+		}
+		versions = current
+	}
+
+	_, err := Load("oracle")
+	assert.Error(t, err)
+}
+
+func TestSplit(t *testing.T) {
+	statements := split(`-- comment
+CREATE TABLE a (
+	id INT
+);
+
+DROP TABLE b;
+`)
+	assert.Equal(t, []string{"CREATE TABLE a (\n\tid INT\n)", "DROP TABLE b"}, statements)
+}
+
+func TestMigrator(t *testing.T) {
+	conn, err := sqlx.Open("sqlite3", "file:"+filepath.Join(t.TempDir(), "test.db")+"?_foreign_keys=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	m, err := New(conn, "sqlite")
+	assert.NoError(t, err)
+
+	version, err := m.Version()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, version)
+
+	assert.NoError(t, m.Up())
+	version, err = m.Version()
+	assert.NoError(t, err)
+	assert.Equal(t, m.Latest(), version)
+
+	_, err = conn.Exec("INSERT INTO reservation_item (name) VALUES ('회의실A')")
+	assert.NoError(t, err)
+
+	// 이미 적용된 migration 은 다시 적용하지 않음
+	assert.NoError(t, m.Up())
+
+	assert.NoError(t, m.Down(m.Latest()))
+	version, err = m.Version()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, version)
+
+	_, err = conn.Exec("SELECT count(*) FROM reservation_item")
+	assert.Error(t, err)
+}
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS reservation;
+DROP TABLE IF EXISTS reservation_item;
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS reservation_item (
+	id        BIGINT       NOT NULL AUTO_INCREMENT,
+	item_type VARCHAR(20)  NOT NULL DEFAULT 'MEETING',
+	name      VARCHAR(100) NOT NULL,
+	PRIMARY KEY (id)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+CREATE TABLE IF NOT EXISTS reservation (
+	id         BIGINT       NOT NULL AUTO_INCREMENT,
+	item_id    BIGINT       NOT NULL,
+	user_name  VARCHAR(100) NOT NULL,
+	start_time DATETIME     NOT NULL,
+	end_time   DATETIME     NOT NULL,
+	memo       TEXT,
+	PRIMARY KEY (id),
+	UNIQUE KEY reservation_item_start_uk (item_id, start_time),
+	KEY reservation_time_idx (item_id, start_time, end_time),
+	CONSTRAINT reservation_item_fk FOREIGN KEY (item_id) REFERENCES reservation_item (id)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
@@ -0,0 +1 @@
+ALTER TABLE reservation_item DROP COLUMN archived_at;
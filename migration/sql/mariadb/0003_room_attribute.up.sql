@@ -0,0 +1,11 @@
+ALTER TABLE reservation_item ADD COLUMN capacity INT NOT NULL DEFAULT 0;
+ALTER TABLE reservation_item ADD COLUMN building VARCHAR(100) NOT NULL DEFAULT '';
+ALTER TABLE reservation_item ADD COLUMN floor VARCHAR(20) NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS reservation_item_equipment (
+	item_id   BIGINT      NOT NULL,
+	equipment VARCHAR(50) NOT NULL,
+	PRIMARY KEY (item_id, equipment),
+	KEY reservation_item_equipment_idx (equipment),
+	CONSTRAINT reservation_item_equipment_fk FOREIGN KEY (item_id) REFERENCES reservation_item (id)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
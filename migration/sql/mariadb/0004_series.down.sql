@@ -0,0 +1,6 @@
+ALTER TABLE reservation DROP FOREIGN KEY reservation_series_fk;
+ALTER TABLE reservation DROP COLUMN is_exception;
+ALTER TABLE reservation DROP COLUMN occurrence;
+ALTER TABLE reservation DROP COLUMN series_id;
+
+DROP TABLE reservation_series;
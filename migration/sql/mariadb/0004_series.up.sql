@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS reservation_series (
+	id        BIGINT       NOT NULL AUTO_INCREMENT,
+	item_id   BIGINT       NOT NULL,
+	user_name VARCHAR(100) NOT NULL,
+	rule      VARCHAR(255) NOT NULL,
+	memo      TEXT,
+	PRIMARY KEY (id),
+	CONSTRAINT reservation_series_item_fk FOREIGN KEY (item_id) REFERENCES reservation_item (id)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+ALTER TABLE reservation ADD COLUMN series_id BIGINT NULL;
+ALTER TABLE reservation ADD COLUMN occurrence INT NULL;
+ALTER TABLE reservation ADD COLUMN is_exception TINYINT(1) NOT NULL DEFAULT 0;
+ALTER TABLE reservation ADD CONSTRAINT reservation_series_fk FOREIGN KEY (series_id) REFERENCES reservation_series (id);
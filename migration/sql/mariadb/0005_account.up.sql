@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS account (
+	id            BIGINT       NOT NULL AUTO_INCREMENT,
+	name          VARCHAR(100) NOT NULL,
+	password_hash VARCHAR(100) NOT NULL,
+	created_at    DATETIME     NOT NULL,
+	PRIMARY KEY (id),
+	UNIQUE KEY account_name_uk (name)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+-- token 은 원문 대신 sha256 hash 만 저장
+CREATE TABLE IF NOT EXISTS account_token (
+	token_hash CHAR(64) NOT NULL,
+	account_id BIGINT   NOT NULL,
+	expires_at DATETIME NOT NULL,
+	PRIMARY KEY (token_hash),
+	CONSTRAINT account_token_account_fk FOREIGN KEY (account_id) REFERENCES account (id)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
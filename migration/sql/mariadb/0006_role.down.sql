@@ -0,0 +1,6 @@
+ALTER TABLE reservation_item DROP COLUMN policy_group;
+ALTER TABLE reservation_item DROP COLUMN policy;
+
+DROP TABLE account_group;
+
+ALTER TABLE account DROP COLUMN role;
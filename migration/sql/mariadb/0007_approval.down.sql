@@ -0,0 +1,5 @@
+DELETE FROM reservation WHERE status NOT IN ('pending', 'approved');
+ALTER TABLE reservation ADD UNIQUE KEY reservation_item_start_uk (item_id, start_time);
+ALTER TABLE reservation DROP COLUMN status;
+
+ALTER TABLE reservation_item DROP COLUMN requires_approval;
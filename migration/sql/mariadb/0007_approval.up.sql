@@ -0,0 +1,6 @@
+ALTER TABLE reservation_item ADD COLUMN requires_approval TINYINT(1) NOT NULL DEFAULT 0;
+
+-- status 는 pending(승인 대기), approved, rejected, cancelled 이며 pending, approved 만 시간을 차지
+-- 거절, 취소된 예약이 남아 있어도 같은 시간에 다시 예약할 수 있도록 시작 시간 unique 키를 제거
+ALTER TABLE reservation ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'approved';
+ALTER TABLE reservation DROP INDEX reservation_item_start_uk;
@@ -0,0 +1,16 @@
+-- 예약할 수 없던 시간에 대한 대기. 예약이 취소되어 시간이 비면 id 순서로 예약됨
+-- status 는 대기에서 예약될 때의 상태(승인이 필요한 회의실이면 pending)
+CREATE TABLE IF NOT EXISTS waitlist (
+	id         BIGINT       NOT NULL AUTO_INCREMENT,
+	item_id    BIGINT       NOT NULL,
+	user_name  VARCHAR(100) NOT NULL,
+	start_time DATETIME     NOT NULL,
+	end_time   DATETIME     NOT NULL,
+	memo       TEXT,
+	status     VARCHAR(20)  NOT NULL DEFAULT 'approved',
+	created_at DATETIME     NOT NULL,
+	PRIMARY KEY (id),
+	KEY waitlist_time_idx (item_id, start_time, end_time),
+	KEY waitlist_user_idx (user_name),
+	CONSTRAINT waitlist_item_fk FOREIGN KEY (item_id) REFERENCES reservation_item (id)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
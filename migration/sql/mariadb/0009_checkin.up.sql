@@ -0,0 +1,3 @@
+-- 시작 후 정해진 시간 안에 체크인하지 않은 예약은 no_show 로 바꿔 회의실 시간을 돌려줌
+ALTER TABLE reservation ADD COLUMN checked_in_at DATETIME NULL;
+ALTER TABLE reservation ADD KEY reservation_status_idx (status, start_time);
@@ -0,0 +1,18 @@
+-- 예약 시간은 [start, end) 범위의 tstzrange 로 저장하고
+-- 회의실별 EXCLUDE 제약 조건으로 겹치는 예약을 DB 에서 거부
+CREATE EXTENSION IF NOT EXISTS btree_gist;
+
+CREATE TABLE IF NOT EXISTS reservation_item (
+	id        BIGSERIAL PRIMARY KEY,
+	item_type VARCHAR(20)  NOT NULL DEFAULT 'MEETING',
+	name      VARCHAR(100) NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS reservation (
+	id        BIGSERIAL PRIMARY KEY,
+	item_id   BIGINT       NOT NULL REFERENCES reservation_item (id),
+	user_name VARCHAR(100) NOT NULL,
+	period    TSTZRANGE    NOT NULL,
+	memo      TEXT,
+	CONSTRAINT reservation_no_overlap EXCLUDE USING gist (item_id WITH =, period WITH &&)
+);
@@ -0,0 +1 @@
+ALTER TABLE reservation_item ADD COLUMN archived_at TIMESTAMPTZ NULL;
@@ -0,0 +1,5 @@
+DROP TABLE reservation_item_equipment;
+
+ALTER TABLE reservation_item DROP COLUMN floor;
+ALTER TABLE reservation_item DROP COLUMN building;
+ALTER TABLE reservation_item DROP COLUMN capacity;
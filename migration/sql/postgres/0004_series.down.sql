@@ -0,0 +1,5 @@
+ALTER TABLE reservation DROP COLUMN is_exception;
+ALTER TABLE reservation DROP COLUMN occurrence;
+ALTER TABLE reservation DROP COLUMN series_id;
+
+DROP TABLE reservation_series;
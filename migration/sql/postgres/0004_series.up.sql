@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS reservation_series (
+	id        BIGSERIAL PRIMARY KEY,
+	item_id   BIGINT       NOT NULL REFERENCES reservation_item (id),
+	user_name VARCHAR(100) NOT NULL,
+	rule      VARCHAR(255) NOT NULL,
+	memo      TEXT
+);
+
+ALTER TABLE reservation ADD COLUMN series_id BIGINT NULL REFERENCES reservation_series (id);
+ALTER TABLE reservation ADD COLUMN occurrence INTEGER NULL;
+ALTER TABLE reservation ADD COLUMN is_exception BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX IF NOT EXISTS reservation_series_idx ON reservation (series_id, occurrence);
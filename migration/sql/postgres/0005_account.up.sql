@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS account (
+	id            BIGSERIAL PRIMARY KEY,
+	name          VARCHAR(100) NOT NULL UNIQUE,
+	password_hash VARCHAR(100) NOT NULL,
+	created_at    TIMESTAMPTZ  NOT NULL
+);
+
+-- token 은 원문 대신 sha256 hash 만 저장
+CREATE TABLE IF NOT EXISTS account_token (
+	token_hash CHAR(64)    PRIMARY KEY,
+	account_id BIGINT      NOT NULL REFERENCES account (id),
+	expires_at TIMESTAMPTZ NOT NULL
+);
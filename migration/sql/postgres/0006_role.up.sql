@@ -0,0 +1,11 @@
+ALTER TABLE account ADD COLUMN role VARCHAR(20) NOT NULL DEFAULT 'member';
+
+CREATE TABLE IF NOT EXISTS account_group (
+	account_id BIGINT       NOT NULL REFERENCES account (id),
+	group_name VARCHAR(100) NOT NULL,
+	PRIMARY KEY (account_id, group_name)
+);
+
+-- policy 는 open(누구나), group(policy_group 사용자만), readonly(관리자만) 예약 가능
+ALTER TABLE reservation_item ADD COLUMN policy VARCHAR(20) NOT NULL DEFAULT 'open';
+ALTER TABLE reservation_item ADD COLUMN policy_group VARCHAR(100) NOT NULL DEFAULT '';
@@ -0,0 +1,6 @@
+DELETE FROM reservation WHERE status NOT IN ('pending', 'approved');
+ALTER TABLE reservation DROP CONSTRAINT reservation_no_overlap;
+ALTER TABLE reservation ADD CONSTRAINT reservation_no_overlap EXCLUDE USING gist (item_id WITH =, period WITH &&);
+ALTER TABLE reservation DROP COLUMN status;
+
+ALTER TABLE reservation_item DROP COLUMN requires_approval;
@@ -0,0 +1,8 @@
+ALTER TABLE reservation_item ADD COLUMN requires_approval BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- status 는 pending(승인 대기), approved, rejected, cancelled 이며 pending, approved 만 시간을 차지
+-- 거절, 취소된 예약은 겹침 제약 조건에서 제외
+ALTER TABLE reservation ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'approved';
+ALTER TABLE reservation DROP CONSTRAINT reservation_no_overlap;
+ALTER TABLE reservation ADD CONSTRAINT reservation_no_overlap
+	EXCLUDE USING gist (item_id WITH =, period WITH &&) WHERE (status IN ('pending', 'approved'));
@@ -0,0 +1,15 @@
+-- 예약할 수 없던 시간에 대한 대기. 예약이 취소되어 시간이 비면 id 순서로 예약됨
+-- status 는 대기에서 예약될 때의 상태(승인이 필요한 회의실이면 pending)
+CREATE TABLE IF NOT EXISTS waitlist (
+	id         BIGSERIAL PRIMARY KEY,
+	item_id    BIGINT       NOT NULL REFERENCES reservation_item (id),
+	user_name  VARCHAR(100) NOT NULL,
+	start_time TIMESTAMPTZ  NOT NULL,
+	end_time   TIMESTAMPTZ  NOT NULL,
+	memo       TEXT,
+	status     VARCHAR(20)  NOT NULL DEFAULT 'approved',
+	created_at TIMESTAMPTZ  NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS waitlist_time_idx ON waitlist (item_id, start_time, end_time);
+CREATE INDEX IF NOT EXISTS waitlist_user_idx ON waitlist (user_name);
@@ -0,0 +1,3 @@
+UPDATE reservation SET status = 'cancelled' WHERE status = 'no_show';
+DROP INDEX reservation_status_idx;
+ALTER TABLE reservation DROP COLUMN checked_in_at;
@@ -0,0 +1,35 @@
+-- 관리자가 등록한 webhook. item_id 가 NULL 이면 모든 회의실의 Event 를 보냄
+-- secret 은 전달할 때마다 서명해야 하므로 원문으로 저장
+CREATE TABLE IF NOT EXISTS webhook_subscription (
+	id         BIGSERIAL PRIMARY KEY,
+	url        VARCHAR(2000) NOT NULL,
+	secret     VARCHAR(200)  NOT NULL,
+	item_id    BIGINT        REFERENCES reservation_item (id),
+	created_by VARCHAR(100)  NOT NULL,
+	created_at TIMESTAMPTZ   NOT NULL
+);
+
+-- 보낼 Event 종류. 없으면 모든 Event 를 보냄
+CREATE TABLE IF NOT EXISTS webhook_subscription_event (
+	subscription_id BIGINT      NOT NULL REFERENCES webhook_subscription (id),
+	event_type      VARCHAR(50) NOT NULL,
+	PRIMARY KEY (subscription_id, event_type)
+);
+
+-- 전달 기록. pending 인 전달은 next_attempt_at 이 지나면 보내며 실패하면 attempts 를 늘려 다시 보냄
+CREATE TABLE IF NOT EXISTS webhook_delivery (
+	id              BIGSERIAL PRIMARY KEY,
+	subscription_id BIGINT      NOT NULL REFERENCES webhook_subscription (id),
+	event_type      VARCHAR(50) NOT NULL,
+	payload         TEXT        NOT NULL,
+	status          VARCHAR(20) NOT NULL DEFAULT 'pending',
+	attempts        INT         NOT NULL DEFAULT 0,
+	response_status INT,
+	last_error      TEXT,
+	next_attempt_at TIMESTAMPTZ,
+	created_at      TIMESTAMPTZ NOT NULL,
+	delivered_at    TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS webhook_delivery_due_idx ON webhook_delivery (status, next_attempt_at);
+CREATE INDEX IF NOT EXISTS webhook_delivery_subscription_idx ON webhook_delivery (subscription_id, id);
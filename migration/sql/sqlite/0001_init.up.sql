@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS reservation_item (
+	id        INTEGER PRIMARY KEY AUTOINCREMENT,
+	item_type VARCHAR(20)  NOT NULL DEFAULT 'MEETING',
+	name      VARCHAR(100) NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS reservation (
+	id         INTEGER PRIMARY KEY AUTOINCREMENT,
+	item_id    INTEGER      NOT NULL REFERENCES reservation_item (id),
+	user_name  VARCHAR(100) NOT NULL,
+	start_time DATETIME     NOT NULL,
+	end_time   DATETIME     NOT NULL,
+	memo       TEXT,
+	UNIQUE (item_id, start_time)
+);
+
+CREATE INDEX IF NOT EXISTS reservation_time_idx ON reservation (item_id, start_time, end_time);
@@ -0,0 +1,7 @@
+DROP INDEX reservation_series_idx;
+
+ALTER TABLE reservation DROP COLUMN is_exception;
+ALTER TABLE reservation DROP COLUMN occurrence;
+ALTER TABLE reservation DROP COLUMN series_id;
+
+DROP TABLE reservation_series;
@@ -0,0 +1,14 @@
+-- sqlite 는 foreign key 가 걸린 column 을 DROP COLUMN 할 수 없으므로 series_id 는 참조 없이 추가
+CREATE TABLE IF NOT EXISTS reservation_series (
+	id        INTEGER PRIMARY KEY AUTOINCREMENT,
+	item_id   INTEGER      NOT NULL REFERENCES reservation_item (id),
+	user_name VARCHAR(100) NOT NULL,
+	rule      VARCHAR(255) NOT NULL,
+	memo      TEXT
+);
+
+ALTER TABLE reservation ADD COLUMN series_id INTEGER NULL;
+ALTER TABLE reservation ADD COLUMN occurrence INTEGER NULL;
+ALTER TABLE reservation ADD COLUMN is_exception BOOLEAN NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS reservation_series_idx ON reservation (series_id, occurrence);
@@ -0,0 +1,2 @@
+DROP TABLE account_token;
+DROP TABLE account;
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS account (
+	id            INTEGER PRIMARY KEY AUTOINCREMENT,
+	name          VARCHAR(100) NOT NULL UNIQUE,
+	password_hash VARCHAR(100) NOT NULL,
+	created_at    DATETIME     NOT NULL
+);
+
+-- token 은 원문 대신 sha256 hash 만 저장
+CREATE TABLE IF NOT EXISTS account_token (
+	token_hash CHAR(64) PRIMARY KEY,
+	account_id INTEGER  NOT NULL REFERENCES account (id),
+	expires_at DATETIME NOT NULL
+);
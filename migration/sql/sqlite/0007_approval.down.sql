@@ -0,0 +1,24 @@
+CREATE TABLE reservation_old (
+	id           INTEGER PRIMARY KEY AUTOINCREMENT,
+	item_id      INTEGER      NOT NULL REFERENCES reservation_item (id),
+	user_name    VARCHAR(100) NOT NULL,
+	start_time   DATETIME     NOT NULL,
+	end_time     DATETIME     NOT NULL,
+	memo         TEXT,
+	series_id    INTEGER      NULL,
+	occurrence   INTEGER      NULL,
+	is_exception BOOLEAN      NOT NULL DEFAULT 0,
+	UNIQUE (item_id, start_time)
+);
+
+INSERT INTO reservation_old (id, item_id, user_name, start_time, end_time, memo, series_id, occurrence, is_exception)
+	SELECT id, item_id, user_name, start_time, end_time, memo, series_id, occurrence, is_exception FROM reservation
+	WHERE status IN ('pending', 'approved');
+
+DROP TABLE reservation;
+ALTER TABLE reservation_old RENAME TO reservation;
+
+CREATE INDEX IF NOT EXISTS reservation_time_idx ON reservation (item_id, start_time, end_time);
+CREATE INDEX IF NOT EXISTS reservation_series_idx ON reservation (series_id, occurrence);
+
+ALTER TABLE reservation_item DROP COLUMN requires_approval;
@@ -0,0 +1,25 @@
+ALTER TABLE reservation_item ADD COLUMN requires_approval BOOLEAN NOT NULL DEFAULT 0;
+
+-- status 는 pending(승인 대기), approved, rejected, cancelled 이며 pending, approved 만 시간을 차지
+-- 거절, 취소된 예약이 남아 있어도 같은 시간에 다시 예약할 수 있도록 시작 시간 unique 제약 없이 table 을 다시 만듦
+CREATE TABLE reservation_new (
+	id           INTEGER PRIMARY KEY AUTOINCREMENT,
+	item_id      INTEGER      NOT NULL REFERENCES reservation_item (id),
+	user_name    VARCHAR(100) NOT NULL,
+	start_time   DATETIME     NOT NULL,
+	end_time     DATETIME     NOT NULL,
+	memo         TEXT,
+	series_id    INTEGER      NULL,
+	occurrence   INTEGER      NULL,
+	is_exception BOOLEAN      NOT NULL DEFAULT 0,
+	status       VARCHAR(20)  NOT NULL DEFAULT 'approved'
+);
+
+INSERT INTO reservation_new (id, item_id, user_name, start_time, end_time, memo, series_id, occurrence, is_exception)
+	SELECT id, item_id, user_name, start_time, end_time, memo, series_id, occurrence, is_exception FROM reservation;
+
+DROP TABLE reservation;
+ALTER TABLE reservation_new RENAME TO reservation;
+
+CREATE INDEX IF NOT EXISTS reservation_time_idx ON reservation (item_id, start_time, end_time);
+CREATE INDEX IF NOT EXISTS reservation_series_idx ON reservation (series_id, occurrence);
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rutesun/reservation/config"
+	"github.com/rutesun/reservation/notify"
+)
+
+// newNotifier 는 설정한 채널로 알림을 보내는 Dispatcher 를 만듦. 설정한 채널이 없으면 nil
+func newNotifier(c config.Notify) (*notify.Dispatcher, error) {
+	var channels []notify.Channel
+	if c.SMTP.Addr != "" {
+		channels = append(channels, notify.NewSMTP(c.SMTP.Addr, c.SMTP.From, c.SMTP.Username, c.SMTP.Password, c.SMTP.Domain))
+	}
+	if c.Webhook.URL != "" {
+		channels = append(channels, notify.NewWebhook(c.Webhook.URL))
+	}
+	if len(channels) == 0 {
+		return nil, nil
+	}
+
+	loc, err := time.LoadLocation(c.Timezone)
+	if err != nil {
+		return nil, errors.Wrapf(err, "잘못된 timezone 입니다: %s", c.Timezone)
+	}
+	opts := notify.Options{Locale: c.Locale, Location: loc, Attempts: c.Attempts, Backoff: c.Backoff}
+	if c.TemplateDir != "" {
+		if opts.Templates, err = notify.LoadTemplates(c.TemplateDir); err != nil {
+			return nil, err
+		}
+	}
+	return notify.New(channels, opts)
+}
@@ -0,0 +1,164 @@
+package notify
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rutesun/reservation/log"
+	"github.com/rutesun/reservation/reservation"
+)
+
+// Message 는 Event 를 사용자(To) 에게 보낼 제목과 본문으로 만든 알림
+type Message struct {
+	Event   reservation.Event
+	To      string
+	Subject string
+	Body    string
+}
+
+// Channel 은 Message 를 보내는 수단 (email, webhook ...)
+// Send 가 error 를 반환하면 Dispatcher 가 다시 보냄
+type Channel interface {
+	Name() string
+	Send(ctx context.Context, msg *Message) error
+}
+
+// Options 는 Dispatcher 설정이며 0 인 항목은 기본값을 사용
+type Options struct {
+	// Locale 은 알림 template 의 언어 (기본 ko)
+	Locale string
+	// Location 은 알림에 표시할 시간대 (기본 서버의 지역 시간)
+	Location  *time.Location
+	Templates *Templates
+	// QueueSize 만큼 쌓인 알림이 있으면 새 알림은 버림
+	QueueSize int
+	Workers   int
+	// Attempts 는 채널마다 보내는 최대 횟수, Backoff 는 첫 재시도까지 기다리는 시간이며 재시도마다 두배
+	Attempts int
+	Backoff  time.Duration
+	// Timeout 은 한번 보내는 데 허용하는 시간
+	Timeout time.Duration
+}
+
+const (
+	defaultQueueSize = 100
+	defaultWorkers   = 2
+	defaultAttempts  = 3
+	defaultBackoff   = time.Second
+	defaultTimeout   = 10 * time.Second
+)
+
+// Dispatcher 는 reservation.Notifier 로 받은 Event 를 queue 에 넣고 worker 가 따로 보내므로
+// 메일 서버가 느려도 예약 요청은 기다리지 않음
+type Dispatcher struct {
+	channels []Channel
+	opts     Options
+
+	mu     sync.RWMutex
+	closed bool
+	queue  chan reservation.Event
+	wg     sync.WaitGroup
+}
+
+// New 는 channels 로 알림을 보내는 worker 를 시작
+func New(channels []Channel, opts Options) (*Dispatcher, error) {
+	if opts.Locale == "" {
+		opts.Locale = DefaultLocale
+	}
+	if opts.Location == nil {
+		opts.Location = time.Local
+	}
+	if opts.Templates == nil {
+		var err error
+		if opts.Templates, err = DefaultTemplates(); err != nil {
+			return nil, err
+		}
+	}
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = defaultQueueSize
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = defaultWorkers
+	}
+	if opts.Attempts <= 0 {
+		opts.Attempts = defaultAttempts
+	}
+	if opts.Backoff <= 0 {
+		opts.Backoff = defaultBackoff
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultTimeout
+	}
+
+	d := &Dispatcher{
+		channels: channels,
+		opts:     opts,
+		queue:    make(chan reservation.Event, opts.QueueSize),
+	}
+	for i := 0; i < opts.Workers; i++ {
+		d.wg.Add(1)
+		go d.work()
+	}
+	return d, nil
+}
+
+// Notify 는 Event 를 queue 에 넣기만 하며 queue 가 가득 찼거나 Close 한 뒤이면 버림
+func (d *Dispatcher) Notify(ctx context.Context, event reservation.Event) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.closed {
+		return
+	}
+
+	select {
+	case d.queue <- event:
+	default:
+		log.Warnf("알림 queue 가 가득 차서 %s 알림을 버립니다 (예약 #%d)", event.Type, event.Reservation.ID)
+	}
+}
+
+// Close 는 더 이상 Event 를 받지 않고 queue 에 남은 알림을 모두 보낼 때까지 기다림
+func (d *Dispatcher) Close() {
+	d.mu.Lock()
+	if !d.closed {
+		d.closed = true
+		close(d.queue)
+	}
+	d.mu.Unlock()
+	d.wg.Wait()
+}
+
+func (d *Dispatcher) work() {
+	defer d.wg.Done()
+	for event := range d.queue {
+		msg, err := d.opts.Templates.Render(d.opts.Locale, d.opts.Location, event)
+		if err != nil {
+			log.Errorf("%s 알림을 만들지 못했습니다: %+v", event.Type, err)
+			continue
+		}
+		for _, ch := range d.channels {
+			d.send(ch, msg)
+		}
+	}
+}
+
+// send 는 채널마다 따로 재시도하므로 한 채널의 실패가 다른 채널에 다시 보내지 않음
+func (d *Dispatcher) send(ch Channel, msg *Message) {
+	backoff := d.opts.Backoff
+	for attempt := 1; ; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), d.opts.Timeout)
+		err := ch.Send(ctx, msg)
+		cancel()
+		if err == nil {
+			return
+		}
+		if attempt >= d.opts.Attempts {
+			log.Errorf("%s 로 %s 알림을 %d 번 보내지 못했습니다 (예약 #%d): %v", ch.Name(), msg.Event.Type, attempt, msg.Event.Reservation.ID, err)
+			return
+		}
+		log.Warnf("%s 로 %s 알림을 보내지 못해 %v 뒤 다시 보냅니다: %v", ch.Name(), msg.Event.Type, backoff, err)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
@@ -0,0 +1,251 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"mime"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rutesun/reservation/reservation"
+	"github.com/stretchr/testify/assert"
+)
+
+func event(eventType reservation.EventType) reservation.Event {
+	start := time.Date(2026, 3, 2, 1, 0, 0, 0, time.UTC)
+	return reservation.Event{
+		Type: eventType,
+		Reservation: &reservation.Detail{
+			ID:     1,
+			Room:   reservation.Room{ID: 1, Name: "회의실1"},
+			User:   "Ted",
+			Start:  start,
+			End:    start.Add(time.Hour),
+			Memo:   "주간 회의",
+			Status: reservation.StatusApproved,
+		},
+		At: start,
+	}
+}
+
+// flaky 는 fails 번 실패한 뒤 성공하는 Channel
+type flaky struct {
+	mu       sync.Mutex
+	fails    int
+	attempts int
+	sent     []*Message
+}
+
+func (f *flaky) Name() string { return "flaky" }
+
+func (f *flaky) Send(ctx context.Context, msg *Message) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.attempts++
+	if f.attempts <= f.fails {
+		return errors.New("일시적인 오류")
+	}
+	f.sent = append(f.sent, msg)
+	return nil
+}
+
+func TestDispatcher_Retry(t *testing.T) {
+	assert := assert.New(t)
+
+	ok, broken := &flaky{fails: 2}, &flaky{fails: 10}
+	d, err := New([]Channel{ok, broken}, Options{Attempts: 3, Backoff: time.Millisecond})
+	assert.NoError(err)
+
+	d.Notify(context.Background(), event(reservation.EventCreated))
+	d.Close()
+
+	assert.Equal(3, ok.attempts)
+	if assert.Len(ok.sent, 1) {
+		assert.Equal("Ted", ok.sent[0].To)
+	}
+	// 최대 횟수만큼 실패하면 버림
+	assert.Equal(3, broken.attempts)
+	assert.Empty(broken.sent)
+
+	// Close 한 뒤의 알림은 무시
+	d.Notify(context.Background(), event(reservation.EventCreated))
+	assert.Equal(3, ok.attempts)
+}
+
+func TestTemplates_Render(t *testing.T) {
+	assert := assert.New(t)
+
+	templates, err := DefaultTemplates()
+	assert.NoError(err)
+	seoul, _ := time.LoadLocation("Asia/Seoul")
+
+	for _, eventType := range reservation.EventTypes {
+		for _, locale := range []string{"ko", "en"} {
+			msg, err := templates.Render(locale, seoul, event(eventType))
+			if assert.NoError(err, "%s %s", locale, eventType) {
+				assert.NotEmpty(msg.Subject, "%s %s", locale, eventType)
+				assert.Contains(msg.Body, "2026-03-02 10:00", "%s %s", locale, eventType)
+			}
+		}
+	}
+
+	msg, err := templates.Render("ko", seoul, event(reservation.EventCreated))
+	assert.NoError(err)
+	assert.Equal("[회의실 예약] 회의실1 예약이 완료되었습니다", msg.Subject)
+	assert.Contains(msg.Body, "메모: 주간 회의")
+
+	msg, err = templates.Render("en", time.UTC, event(reservation.EventCancelled))
+	assert.NoError(err)
+	assert.Equal("[Room booking] Your booking for 회의실1 was cancelled", msg.Subject)
+	assert.Contains(msg.Body, "2026-03-02 01:00 - 2026-03-02 02:00")
+
+	// 없는 locale 은 기본 locale 로
+	msg, err = templates.Render("fr", seoul, event(reservation.EventRejected))
+	assert.NoError(err)
+	assert.Equal("[회의실 예약] 회의실1 예약이 거절되었습니다", msg.Subject)
+}
+
+// smtpServer 는 테스트를 위해 받은 메일을 기록만 하는 SMTP 서버
+type smtpServer struct {
+	listener net.Listener
+	mu       sync.Mutex
+	rcpt     []string
+	data     []string
+}
+
+func newSMTPServer(t *testing.T) *smtpServer {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &smtpServer{listener: l}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *smtpServer) serve(conn net.Conn) {
+	defer conn.Close()
+	r, w := bufio.NewReader(conn), bufio.NewWriter(conn)
+	reply := func(line string) {
+		w.WriteString(line + "\r\n")
+		w.Flush()
+	}
+
+	reply("220 localhost ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			reply("250 localhost")
+		case strings.HasPrefix(cmd, "RCPT TO:"):
+			s.mu.Lock()
+			s.rcpt = append(s.rcpt, strings.Trim(strings.TrimSpace(line)[len("RCPT TO:"):], "<>"))
+			s.mu.Unlock()
+			reply("250 OK")
+		case cmd == "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if line == ".\r\n" {
+					break
+				}
+				data.WriteString(line)
+			}
+			s.mu.Lock()
+			s.data = append(s.data, data.String())
+			s.mu.Unlock()
+			reply("250 OK")
+		case cmd == "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+func TestSMTP_Send(t *testing.T) {
+	assert := assert.New(t)
+
+	server := newSMTPServer(t)
+	defer server.listener.Close()
+
+	templates, _ := DefaultTemplates()
+	msg, _ := templates.Render("ko", time.UTC, event(reservation.EventApproved))
+
+	smtp := NewSMTP(server.listener.Addr().String(), "noreply@example.com", "", "", "example.com")
+	assert.NoError(smtp.Send(context.Background(), msg))
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	assert.Equal([]string{"Ted@example.com"}, server.rcpt)
+	if assert.Len(server.data, 1) {
+		header, body, _ := strings.Cut(server.data[0], "\r\n\r\n")
+		for _, line := range strings.Split(header, "\r\n") {
+			if subject, ok := strings.CutPrefix(line, "Subject: "); ok {
+				decoded, err := new(mime.WordDecoder).DecodeHeader(subject)
+				assert.NoError(err)
+				assert.Equal(msg.Subject, decoded)
+			}
+		}
+		decoded, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(body, "\r\n", ""))
+		assert.NoError(err)
+		assert.Equal(msg.Body, string(decoded))
+	}
+
+	// 주소를 알 수 없으면 보내지 않음
+	smtp.Domain = ""
+	assert.NoError(smtp.Send(context.Background(), msg))
+	assert.Len(server.data, 1)
+}
+
+func TestWebhook_Send(t *testing.T) {
+	assert := assert.New(t)
+
+	var received webhookPayload
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal("application/json", r.Header.Get("Content-Type"))
+		assert.NoError(json.NewDecoder(r.Body).Decode(&received))
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	templates, _ := DefaultTemplates()
+	msg, _ := templates.Render("en", time.UTC, event(reservation.EventPromoted))
+
+	webhook := NewWebhook(server.URL)
+	assert.NoError(webhook.Send(context.Background(), msg))
+	assert.Equal(reservation.EventPromoted, received.Type)
+	assert.Equal("Ted", received.To)
+	assert.Equal(msg.Subject, received.Subject)
+	assert.Equal(int64(1), received.Reservation.ID)
+
+	// 2xx 가 아니면 다시 보낼 수 있도록 error
+	status = http.StatusServiceUnavailable
+	assert.Error(webhook.Send(context.Background(), msg))
+}
@@ -0,0 +1,122 @@
+package notify
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rutesun/reservation/log"
+)
+
+// SMTP 는 알림을 email 로 보내는 Channel
+// 사용자 이름이 email 주소가 아니면 Domain 을 붙여 주소를 만들며, Domain 도 없으면 보내지 않음
+type SMTP struct {
+	Addr   string
+	From   string
+	Auth   smtp.Auth
+	Domain string
+}
+
+// NewSMTP 는 addr(host:port) 의 메일 서버로 보내는 SMTP. username 이 없으면 인증하지 않음
+func NewSMTP(addr, from, username, password, domain string) *SMTP {
+	s := &SMTP{Addr: addr, From: from, Domain: domain}
+	if username != "" {
+		host, _, _ := net.SplitHostPort(addr)
+		s.Auth = smtp.PlainAuth("", username, password, host)
+	}
+	return s
+}
+
+func (s *SMTP) Name() string {
+	return "smtp"
+}
+
+func (s *SMTP) Send(ctx context.Context, msg *Message) error {
+	to := s.recipient(msg.To)
+	if to == "" {
+		log.Debugf("%s 님의 email 주소를 알 수 없어 알림을 보내지 않습니다", msg.To)
+		return nil
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", s.Addr)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	host, _, _ := net.SplitHostPort(s.Addr)
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	if s.Auth != nil {
+		if err := c.Auth(s.Auth); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	if err := c.Mail(s.From); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := c.Rcpt(to); err != nil {
+		return errors.WithStack(err)
+	}
+	w, err := c.Data()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if _, err := w.Write(s.compose(to, msg)); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := w.Close(); err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(c.Quit())
+}
+
+func (s *SMTP) recipient(user string) string {
+	if strings.Contains(user, "@") {
+		return user
+	}
+	if s.Domain == "" {
+		return ""
+	}
+	return user + "@" + s.Domain
+}
+
+// compose 는 한글 제목, 본문을 그대로 보낼 수 있도록 제목은 Q encoding, 본문은 base64 로 만듦
+func (s *SMTP) compose(to string, msg *Message) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", s.From)
+	fmt.Fprintf(&b, "To: %s\r\n", to)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: base64\r\n\r\n")
+
+	body := base64.StdEncoding.EncodeToString([]byte(msg.Body))
+	for len(body) > 76 {
+		b.WriteString(body[:76] + "\r\n")
+		body = body[76:]
+	}
+	b.WriteString(body + "\r\n")
+	return []byte(b.String())
+}
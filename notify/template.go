@@ -0,0 +1,130 @@
+package notify
+
+import (
+	"bytes"
+	"embed"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rutesun/reservation/reservation"
+)
+
+// DefaultLocale 은 요청한 locale 의 template 이 없을 때 사용하는 locale
+const DefaultLocale = "ko"
+
+const timeLayout = "2006-01-02 15:04"
+
+//go:embed templates
+var embedded embed.FS
+
+// Templates 는 locale 과 EventType 별 알림 template
+// template 파일은 <locale>/<event type>.tmpl 이며 첫 줄은 제목, 나머지는 본문
+type Templates struct {
+	byLocale map[string]map[reservation.EventType]*template.Template
+}
+
+// templateData 는 template 에서 사용할 수 있는 값
+type templateData struct {
+	Type        reservation.EventType
+	ID          int64
+	User        string
+	Room        string
+	Start       string
+	End         string
+	Memo        string
+	Status      string
+	Occurrences int
+}
+
+// DefaultTemplates 는 기본 제공하는 ko, en template
+func DefaultTemplates() (*Templates, error) {
+	sub, err := fs.Sub(embedded, "templates")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	t := &Templates{byLocale: map[string]map[reservation.EventType]*template.Template{}}
+	if err := t.load(sub); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// LoadTemplates 는 dir 의 template 으로 기본 template 을 덮어씀
+// dir 에 없는 locale, EventType 은 기본 template 을 그대로 사용
+func LoadTemplates(dir string) (*Templates, error) {
+	t, err := DefaultTemplates()
+	if err != nil {
+		return nil, err
+	}
+	if err := t.load(os.DirFS(dir)); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (t *Templates) load(fsys fs.FS) error {
+	files, err := fs.Glob(fsys, "*/*.tmpl")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	for _, file := range files {
+		text, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		tmpl, err := template.New(file).Parse(string(text))
+		if err != nil {
+			return errors.Wrapf(err, "%s template 이 잘못되었습니다", file)
+		}
+		locale := path.Dir(file)
+		if t.byLocale[locale] == nil {
+			t.byLocale[locale] = map[reservation.EventType]*template.Template{}
+		}
+		t.byLocale[locale][reservation.EventType(strings.TrimSuffix(path.Base(file), ".tmpl"))] = tmpl
+	}
+	return nil
+}
+
+// Render 는 locale 의 template 으로 event 의 알림을 만듦. 시간은 loc 기준으로 표시
+// locale 에 해당 EventType 의 template 이 없으면 DefaultLocale 의 template 을 사용
+func (t *Templates) Render(locale string, loc *time.Location, event reservation.Event) (*Message, error) {
+	tmpl, ok := t.byLocale[locale][event.Type]
+	if !ok {
+		if tmpl, ok = t.byLocale[DefaultLocale][event.Type]; !ok {
+			return nil, errors.Errorf("%s 알림 template 이 없습니다", event.Type)
+		}
+	}
+
+	r := event.Reservation
+	data := templateData{
+		Type:        event.Type,
+		ID:          r.ID,
+		User:        r.User,
+		Room:        r.Room.Name,
+		Start:       r.Start.In(loc).Format(timeLayout),
+		End:         r.End.In(loc).Format(timeLayout),
+		Memo:        r.Memo,
+		Status:      string(r.Status),
+		Occurrences: event.Occurrences,
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	subject, body := buf.String(), ""
+	if i := strings.IndexByte(subject, '\n'); i >= 0 {
+		subject, body = subject[:i], subject[i+1:]
+	}
+	return &Message{
+		Event:   event,
+		To:      r.User,
+		Subject: strings.TrimSpace(subject),
+		Body:    strings.TrimLeft(body, "\n"),
+	}, nil
+}
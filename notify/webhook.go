@@ -0,0 +1,71 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rutesun/reservation/reservation"
+)
+
+// Webhook 은 알림을 JSON 으로 URL 에 POST 하는 Channel (Slack, 사내 메신저 연동 등)
+type Webhook struct {
+	URL    string
+	Client *http.Client
+}
+
+// webhookPayload 는 Webhook 으로 보내는 JSON
+type webhookPayload struct {
+	Type        reservation.EventType `json:"type"`
+	To          string                `json:"to"`
+	Subject     string                `json:"subject"`
+	Body        string                `json:"body"`
+	Reservation *reservation.Detail   `json:"reservation"`
+	Occurrences int                   `json:"occurrences,omitempty"`
+	At          time.Time             `json:"at"`
+}
+
+func NewWebhook(url string) *Webhook {
+	return &Webhook{URL: url, Client: http.DefaultClient}
+}
+
+func (w *Webhook) Name() string {
+	return "webhook"
+}
+
+func (w *Webhook) Send(ctx context.Context, msg *Message) error {
+	payload, err := json.Marshal(webhookPayload{
+		Type:        msg.Event.Type,
+		To:          msg.To,
+		Subject:     msg.Subject,
+		Body:        msg.Body,
+		Reservation: msg.Event.Reservation,
+		Occurrences: msg.Event.Occurrences,
+		At:          msg.Event.At,
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, w.URL, bytes.NewReader(payload))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	res, err := w.Client.Do(req.WithContext(ctx))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer res.Body.Close()
+	io.Copy(ioutil.Discard, res.Body)
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return errors.Errorf("webhook 이 %d 로 응답했습니다", res.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,483 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"github.com/rutesun/reservation/exception"
+	"github.com/rutesun/reservation/reservation"
+	sq "gopkg.in/Masterminds/squirrel.v1"
+)
+
+// exclusion_violation, https://www.postgresql.org/docs/current/errcodes-appendix.html
+const exclusionViolation = "23P01"
+
+// active 는 회의실 시간을 차지하는(승인 대기, 승인된) 예약만 남기는 조건. 겹침 제약 조건도 같은 조건을 사용
+const active = "status IN ('pending', 'approved')"
+
+type db struct {
+	DB      *sqlx.DB
+	timeout time.Duration
+}
+
+// New 는 query 하나당 queryTimeout 을 적용하는 저장소를 생성. 0 이면 timeout 없음
+func New(d *sqlx.DB, queryTimeout time.Duration) *db {
+	return &db{DB: d, timeout: queryTimeout}
+}
+
+// period 는 [start, end) 범위의 tstzrange 를 만드는 표현식
+func period(startTime, endTime time.Time) sq.Sqlizer {
+	return sq.Expr("tstzrange(?, ?, '[)')", startTime, endTime)
+}
+
+// translate 는 겹치는 예약으로 인한 제약 조건 위반을 exception.Unavailable 로 변환
+func translate(err error) error {
+	if pqErr, ok := errors.Cause(err).(*pq.Error); ok && pqErr.Code == exclusionViolation {
+		return exception.Unavailable
+	}
+	return errors.WithStack(err)
+}
+
+func (db *db) RoomList(ctx context.Context, filter reservation.RoomFilter) ([]*reservation.Room, error) {
+	rList := []*dtoRoom{}
+
+	builder := psql.Select(
+		"r.id",
+		"r.name",
+		"r.capacity",
+		"r.building",
+		"r.floor",
+		"r.policy",
+		"r.policy_group",
+		"r.requires_approval",
+	).
+		From("reservation_item AS r").
+		Where("r.item_type = 'MEETING' AND r.archived_at IS NULL").
+		OrderBy("r.id")
+	builder = filterRoom(builder, filter)
+
+	if err := db.Select(ctx, &rList, builder); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	rooms := make([]*reservation.Room, len(rList))
+	for i, r := range rList {
+		rooms[i] = convertRoom(r)
+	}
+
+	if err := db.loadEquipment(ctx, rooms); err != nil {
+		return nil, err
+	}
+	return rooms, nil
+}
+
+func (db *db) List(ctx context.Context, startDate, endDate time.Time) ([]*reservation.Detail, error) {
+	list, err := db.listAll(ctx, startDate, endDate)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	details := make([]*reservation.Detail, len(list))
+
+	for i, o := range list {
+		details[i] = convertReservation(o)
+	}
+	return details, nil
+}
+
+func (db *db) listAll(ctx context.Context, startDate, endDate time.Time) ([]*dtoReservation, error) {
+	reservations := []*dtoReservation{}
+
+	builder := selectReservation().
+		Where("lower(r.period) >= ? AND upper(r.period) < ?", startDate, endDate).
+		Where("r." + active)
+
+	err := db.Select(ctx, &reservations, builder)
+	return reservations, err
+}
+
+// ListOverlapping 은 [startTime, endTime) 와 조금이라도 겹치는 예약을 모두 조회
+func (db *db) ListOverlapping(ctx context.Context, startTime, endTime time.Time) ([]*reservation.Detail, error) {
+	reservations := []*dtoReservation{}
+
+	builder := selectReservation().
+		Where(sq.Expr("r.period && tstzrange(?, ?, '[)')", startTime, endTime)).
+		Where("r." + active)
+
+	if err := db.Select(ctx, &reservations, builder); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	details := make([]*reservation.Detail, len(reservations))
+	for i, o := range reservations {
+		details[i] = convertReservation(o)
+	}
+	return details, nil
+}
+
+func selectReservation() sq.SelectBuilder {
+	return psql.Select(
+		"r.id",
+		"ri.id AS room_id",
+		"ri.name AS room_name",
+		"r.user_name AS user_name",
+		"lower(r.period) AS start_time",
+		"upper(r.period) AS end_time",
+		"r.memo",
+		"r.series_id",
+		"r.occurrence",
+		"r.is_exception",
+		"r.status",
+		"r.checked_in_at",
+	).
+		From("reservation AS r").
+		Join("reservation_item AS ri ON r.item_id = ri.id")
+}
+
+func (db *db) Find(ctx context.Context, reservationID int64) (*reservation.Detail, error) {
+	dto := dtoReservation{}
+
+	builder := selectReservation().Where("r.id = ?", reservationID)
+
+	if err := db.Get(ctx, &dto, builder); err == sql.ErrNoRows {
+		return nil, exception.NotFound
+	} else if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return convertReservation(&dto), nil
+}
+
+func (db *db) Available(ctx context.Context, roomID int64, startTime, endTime time.Time) (bool, error) {
+	builder := psql.Select("count(*)").
+		From("reservation").
+		Where("item_id = ?", roomID).
+		Where(sq.Expr("period && tstzrange(?, ?, '[)')", startTime, endTime)).
+		Where(active)
+
+	count := 0
+	if err := db.Get(ctx, &count, builder); err != nil {
+		return false, errors.WithStack(err)
+	}
+
+	return count == 0, nil
+}
+
+// lockRoom 은 회의실 row 를 lock 하며 회의실이 없거나 보관되었으면 exception.RoomNotFound
+// 예약 생성은 "FOR SHARE" 로 서로 막지 않고, 회의실 변경, 보관은 "FOR UPDATE" 로 예약 생성과 직렬화
+// lockSlots 는 builder 로 조회한 예약들의 회의실을 id 순서로 먼저 lock(FOR SHARE) 한 뒤 예약을 lock 하여 다시 조회
+// 보관된 회의실은 lock 하지 않으며 대기를 예약할 때(promoteSlots) 건너뜀
+func (db *db) lockSlots(ctx context.Context, tx *sqlx.Tx, builder sq.SelectBuilder) ([]*dtoSlot, error) {
+	slots := []*dtoSlot{}
+	if err := db.query(ctx, &slots, builder, tx.SelectContext); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if len(slots) == 0 {
+		return slots, nil
+	}
+
+	roomIDs := []int64{}
+	for _, slot := range slots {
+		roomIDs = append(roomIDs, slot.RoomID)
+	}
+	rooms := []int64{}
+	lock := psql.Select("id").
+		From("reservation_item").
+		Where(sq.Eq{"id": roomIDs}).
+		Where("archived_at IS NULL").
+		OrderBy("id").
+		Suffix("FOR SHARE")
+	if err := db.query(ctx, &rooms, lock, tx.SelectContext); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	slots = []*dtoSlot{}
+	if err := db.query(ctx, &slots, builder.Suffix("FOR UPDATE"), tx.SelectContext); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return slots, nil
+}
+
+func (db *db) lockRoom(ctx context.Context, tx *sqlx.Tx, roomID int64, lock string) error {
+	builder := psql.Select("id").
+		From("reservation_item").
+		Where("id = ? AND item_type = 'MEETING' AND archived_at IS NULL", roomID).
+		Suffix(lock)
+
+	var id int64
+	if err := db.getWith(ctx, tx, &id, builder); err == sql.ErrNoRows {
+		return exception.RoomNotFound
+	} else if err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// 겹침은 EXCLUDE 제약 조건이 막으므로 회의실 보관만 막고 반복 예약 전체를 하나의 transaction 으로 처리
+func (db *db) MakeSeries(ctx context.Context, roomID int64, userName string, rule string, slots []reservation.Slot, memo string, status reservation.Status) (int64, error) {
+	if len(slots) == 0 {
+		return 0, exception.InvalidRequest
+	}
+
+	var seriesID int64
+	err := db.transaction(ctx, func(tx *sqlx.Tx) error {
+		if err := db.lockRoom(ctx, tx, roomID, "FOR SHARE"); err != nil {
+			return err
+		}
+
+		var err error
+		if seriesID, err = db.createSeries(ctx, tx, roomID, userName, rule, memo); err != nil {
+			return err
+		}
+
+		for _, slot := range slots {
+			if _, err := db.make(ctx, tx, roomID, userName, slot.Start, slot.End, memo, status, seriesID, slot.Occurrence); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return seriesID, nil
+}
+
+func (db *db) Make(ctx context.Context, roomID int64, userName string, startTime, endTime time.Time, memo string, status reservation.Status) (int64, error) {
+	var id int64
+	err := db.transaction(ctx, func(tx *sqlx.Tx) error {
+		if err := db.lockRoom(ctx, tx, roomID, "FOR SHARE"); err != nil {
+			return err
+		}
+
+		var err error
+		id, err = db.make(ctx, tx, roomID, userName, startTime, endTime, memo, status, 0, 0)
+		return err
+	})
+	return id, err
+}
+
+// make 는 겹침 검사를 하지 않고 바로 insert 하며 겹치는 경우 EXCLUDE 제약 조건 위반으로 실패
+// seriesID 가 0 이 아니면 반복 예약의 occurrence 번째 회차로 저장
+func (db *db) make(ctx context.Context, queryer sqlx.QueryerContext, roomID int64, userName string, startTime, endTime time.Time, memo string, status reservation.Status, seriesID int64, occurrence int) (int64, error) {
+	columns := []string{"item_id", "user_name", "period", "memo", "status"}
+	values := []interface{}{roomID, userName, period(startTime, endTime), memo, string(status)}
+	if seriesID != 0 {
+		columns = append(columns, "series_id", "occurrence")
+		values = append(values, seriesID, occurrence)
+	}
+
+	builder := psql.Insert("reservation").
+		Columns(columns...).
+		Values(values...).
+		Suffix("RETURNING id")
+
+	var id int64
+	if err := db.getWith(ctx, queryer, &id, builder); err != nil {
+		return 0, translate(err)
+	}
+	return id, nil
+}
+
+// Modify 는 update 한번으로 처리하며 자신의 기존 시간은 제약 조건 검사에서 자연히 제외됨
+// 취소, 거절된 예약이면 exception.NotFound
+func (db *db) Modify(ctx context.Context, reservationID int64, roomID int64, userName string, startTime, endTime time.Time, memo string, status reservation.Status) error {
+	return db.transaction(ctx, func(tx *sqlx.Tx) error {
+		if err := db.lockRoom(ctx, tx, roomID, "FOR SHARE"); err != nil {
+			return err
+		}
+
+		update := psql.Update("reservation").
+			SetMap(map[string]interface{}{
+				"item_id":   roomID,
+				"user_name": userName,
+				"period":    period(startTime, endTime),
+				"memo":      memo,
+				"status":    string(status),
+				// 반복 예약의 회차를 따로 변경하면 예외 회차로 표시
+				"is_exception": sq.Expr("series_id IS NOT NULL"),
+			}).
+			Where("id = ? AND "+active, reservationID)
+
+		res, err := db.execWith(ctx, tx, update)
+		if err != nil {
+			return translate(err)
+		}
+		if affected, err := res.RowsAffected(); err != nil {
+			return errors.WithStack(err)
+		} else if affected == 0 {
+			return exception.NotFound
+		}
+		return nil
+	})
+}
+
+// Cancel 은 예약을 지우지 않고 cancelled 로 바꾸며 이미 취소, 거절된 예약이면 exception.NotFound
+// 같은 transaction 에서 비워진 시간에 들어갈 수 있는 대기를 예약하고 예약한 id 를 반환
+func (db *db) Cancel(ctx context.Context, reservationID int64, now time.Time) ([]int64, error) {
+	var promoted []int64
+	err := db.transaction(ctx, func(tx *sqlx.Tx) error {
+		target := dtoSlot{}
+		builder := psql.Select("item_id", "lower(period) AS start_time", "upper(period) AS end_time").
+			From("reservation").
+			Where("id = ? AND "+active, reservationID)
+		if err := db.getWith(ctx, tx, &target, builder); err == sql.ErrNoRows {
+			return exception.NotFound
+		} else if err != nil {
+			return errors.WithStack(err)
+		}
+
+		// 회의실 보관과 직렬화. 보관된 회의실이면 대기를 예약하지 않음
+		open := true
+		if err := db.lockRoom(ctx, tx, target.RoomID, "FOR SHARE"); err == exception.RoomNotFound {
+			open = false
+		} else if err != nil {
+			return err
+		}
+
+		update := psql.Update("reservation").
+			Set("status", string(reservation.StatusCancelled)).
+			Where("id = ? AND item_id = ? AND "+active, reservationID, target.RoomID)
+		res, err := db.execWith(ctx, tx, update)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if affected, err := res.RowsAffected(); err != nil {
+			return errors.WithStack(err)
+		} else if affected == 0 {
+			return exception.NotFound
+		}
+
+		if !open {
+			return nil
+		}
+		promoted, err = db.promote(ctx, tx, target.RoomID, target.StartTime, target.EndTime, now)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return promoted, nil
+}
+
+// Review 는 승인 대기 중인 예약의 상태만 바꾸며 이미 처리되었으면 exception.NotPending
+// 거절하면 취소와 같이 회의실부터 lock 하고 같은 transaction 에서 비워진 시간의 대기를 예약하여 예약한 id 를 반환
+func (db *db) Review(ctx context.Context, reservationID int64, status reservation.Status, now time.Time) ([]int64, error) {
+	var promoted []int64
+	err := db.transaction(ctx, func(tx *sqlx.Tx) error {
+		slots, err := db.lockSlots(ctx, tx, selectSlot().
+			Where("id = ? AND status = ?", reservationID, string(reservation.StatusPending)))
+		if err != nil {
+			return err
+		}
+		if len(slots) == 0 {
+			return exception.NotPending
+		}
+
+		update := psql.Update("reservation").
+			Set("status", string(status)).
+			Where("id = ?", reservationID)
+		if _, err := db.execWith(ctx, tx, update); err != nil {
+			return errors.WithStack(err)
+		}
+
+		promoted = []int64{}
+		if status != reservation.StatusRejected {
+			return nil
+		}
+		promoted, err = db.promoteSlots(ctx, tx, slots, now)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return promoted, nil
+}
+
+type dtoRoom struct {
+	ID               int64  `db:"id"`
+	Name             string `db:"name"`
+	Capacity         int    `db:"capacity"`
+	Building         string `db:"building"`
+	Floor            string `db:"floor"`
+	Policy           string `db:"policy"`
+	Group            string `db:"policy_group"`
+	RequiresApproval bool   `db:"requires_approval"`
+}
+
+// dtoSlot 은 예약의 회의실과 시간
+// selectSlot 은 예약의 id, 회의실, 시간만 조회
+func selectSlot() sq.SelectBuilder {
+	return psql.Select("id", "item_id", "lower(period) AS start_time", "upper(period) AS end_time").From("reservation")
+}
+
+type dtoSlot struct {
+	ID        int64     `db:"id"`
+	RoomID    int64     `db:"item_id"`
+	StartTime time.Time `db:"start_time"`
+	EndTime   time.Time `db:"end_time"`
+}
+
+type dtoEquipment struct {
+	RoomID    int64  `db:"item_id"`
+	Equipment string `db:"equipment"`
+}
+
+type dtoReservation struct {
+	ID        int64          `db:"id"`
+	RoomID    int64          `db:"room_id"`
+	RoomName  string         `db:"room_name"`
+	UserName  string         `db:"user_name"`
+	StartTime time.Time      `db:"start_time"`
+	EndTime   time.Time      `db:"end_time"`
+	Memo      sql.NullString `db:"memo"`
+
+	SeriesID    sql.NullInt64 `db:"series_id"`
+	Occurrence  sql.NullInt64 `db:"occurrence"`
+	IsException bool          `db:"is_exception"`
+	Status      string        `db:"status"`
+	CheckedInAt *time.Time    `db:"checked_in_at"`
+}
+
+func convertRoom(r *dtoRoom) *reservation.Room {
+	return &reservation.Room{
+		ID:               r.ID,
+		Name:             r.Name,
+		Capacity:         r.Capacity,
+		Building:         r.Building,
+		Floor:            r.Floor,
+		Policy:           reservation.Policy(r.Policy),
+		Group:            r.Group,
+		RequiresApproval: r.RequiresApproval,
+	}
+}
+
+func convertReservation(r *dtoReservation) *reservation.Detail {
+	if r == nil {
+		return nil
+	}
+
+	detail := &reservation.Detail{
+		ID: r.ID,
+		Room: reservation.Room{
+			ID:   r.RoomID,
+			Name: r.RoomName,
+		},
+		User:  r.UserName,
+		Start: r.StartTime, End: r.EndTime,
+		Memo:   r.Memo.String,
+		Status: reservation.Status(r.Status),
+
+		Occurrence: int(r.Occurrence.Int64),
+		Exception:  r.IsException,
+
+		CheckedInAt: r.CheckedInAt,
+	}
+	if r.SeriesID.Valid {
+		detail.SeriesID = &r.SeriesID.Int64
+	}
+	return detail
+}
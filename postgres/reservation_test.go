@@ -0,0 +1,117 @@
+package postgres
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"github.com/rutesun/reservation/config"
+	"github.com/rutesun/reservation/exception"
+	"github.com/rutesun/reservation/migration"
+	"github.com/rutesun/reservation/reservation"
+	"github.com/rutesun/reservation/reservation/reservationtest"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	ctx      = context.Background()
+	roomID   = int64(1)
+	userName = "Ted"
+)
+
+// DATABASE_DRIVER=postgres 일 때만 실제 DB 를 사용하는 테스트를 실행
+func newTestDB(t *testing.T) *db {
+	if os.Getenv("DATABASE_DRIVER") != config.Postgres {
+		t.Skip("DATABASE_DRIVER=postgres 가 아니면 생략")
+	}
+
+	con, err := config.Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	setting, err := config.Make(con)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := migration.New(setting.DB, config.Postgres)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+	return New(setting.DB, setting.QueryTimeout)
+}
+
+func TestTranslate(t *testing.T) {
+	err := translate(errors.WithStack(&pq.Error{Code: exclusionViolation}))
+	assert.Equal(t, exception.Unavailable, err)
+
+	err = translate(&pq.Error{Code: "23505"})
+	assert.NotEqual(t, exception.Unavailable, errors.Cause(err))
+}
+
+func TestDb_Make(t *testing.T) {
+	postgres := newTestDB(t)
+
+	st, _ := time.Parse(time.RFC3339, "2018-08-04T18:00:00+09:00")
+	et, _ := time.Parse(time.RFC3339, "2018-08-04T19:00:00+09:00")
+
+	id, err := postgres.Make(ctx, roomID, userName, st, et, "", reservation.StatusApproved)
+	if err != nil {
+		assert.EqualError(t, err, exception.Unavailable.Error())
+	}
+	t.Log(id)
+
+	// 시작 시간만 다르고 겹치는 예약도 제약 조건으로 거부
+	_, err = postgres.Make(ctx, roomID, userName, st.Add(30*time.Minute), et.Add(30*time.Minute), "", reservation.StatusApproved)
+	assert.EqualError(t, err, exception.Unavailable.Error())
+
+	check, err := postgres.Available(ctx, roomID, st, et)
+	assert.NoError(t, err)
+	assert.False(t, check)
+}
+
+// weekly 는 st, et 부터 매주 같은 시간으로 n 번 반복하는 예약 시간
+func weekly(st, et time.Time, n int) []reservation.Slot {
+	slots := make([]reservation.Slot, n)
+	for i := range slots {
+		slots[i] = reservation.Slot{Start: st.AddDate(0, 0, 7*i), End: et.AddDate(0, 0, 7*i), Occurrence: i + 1}
+	}
+	return slots
+}
+
+func TestDb_MakeSeries(t *testing.T) {
+	postgres := newTestDB(t)
+
+	st, _ := time.Parse(time.RFC3339, "2018-08-05T16:00:00+09:00")
+	et, _ := time.Parse(time.RFC3339, "2018-08-05T19:00:00+09:00")
+
+	seriesID, err := postgres.MakeSeries(ctx, roomID, userName, "FREQ=WEEKLY", weekly(st, et, 5), "", reservation.StatusApproved)
+	if err != nil {
+		assert.EqualError(t, err, exception.Unavailable.Error())
+	}
+	t.Log(seriesID)
+
+	for i := 0; i < 5; i++ {
+		check, err := postgres.Available(ctx, roomID, st, et)
+		assert.NoError(t, err)
+		assert.False(t, check)
+		st = st.AddDate(0, 0, 7)
+		et = et.AddDate(0, 0, 7)
+	}
+}
+
+func TestDb_MakeConcurrently(t *testing.T) {
+	postgres := newTestDB(t)
+
+	st, _ := time.Parse(time.RFC3339, "2018-09-03T10:00:00+09:00")
+	et, _ := time.Parse(time.RFC3339, "2018-09-03T12:00:00+09:00")
+
+	for _, id := range reservationtest.MakeConcurrently(t, postgres, roomID, st, et, 30) {
+		postgres.Cancel(ctx, id, st)
+	}
+}
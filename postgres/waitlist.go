@@ -0,0 +1,169 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"github.com/rutesun/reservation/exception"
+	"github.com/rutesun/reservation/reservation"
+	sq "gopkg.in/Masterminds/squirrel.v1"
+)
+
+// JoinWaitlist 는 보관되지 않은 회의실에만 대기를 추가하며 아니면 exception.RoomNotFound
+func (db *db) JoinWaitlist(ctx context.Context, w *reservation.Waitlist) (int64, error) {
+	var id int64
+	err := db.transaction(ctx, func(tx *sqlx.Tx) error {
+		if err := db.lockRoom(ctx, tx, w.Room.ID, "FOR SHARE"); err != nil {
+			return err
+		}
+
+		builder := psql.Insert("waitlist").
+			Columns("item_id", "user_name", "start_time", "end_time", "memo", "status", "created_at").
+			Values(w.Room.ID, w.User, w.Start, w.End, w.Memo, string(w.Status), w.CreatedAt).
+			Suffix("RETURNING id")
+		return errors.WithStack(db.getWith(ctx, tx, &id, builder))
+	})
+	return id, err
+}
+
+func (db *db) FindWaitlist(ctx context.Context, waitlistID int64) (*reservation.Waitlist, error) {
+	dto := dtoWaitlist{}
+	if err := db.Get(ctx, &dto, selectWaitlist().Where("w.id = ?", waitlistID)); err == sql.ErrNoRows {
+		return nil, exception.WaitlistNotFound
+	} else if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return convertWaitlist(&dto), nil
+}
+
+func (db *db) ListWaitlist(ctx context.Context, userName string) ([]*reservation.Waitlist, error) {
+	list := []*dtoWaitlist{}
+	if err := db.Select(ctx, &list, selectWaitlist().Where("w.user_name = ?", userName).OrderBy("w.id")); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	waitlist := make([]*reservation.Waitlist, len(list))
+	for i, w := range list {
+		waitlist[i] = convertWaitlist(w)
+	}
+	return waitlist, nil
+}
+
+func (db *db) LeaveWaitlist(ctx context.Context, waitlistID int64) error {
+	res, err := db.Exec(ctx, psql.Delete("waitlist").Where("id = ?", waitlistID))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if affected, err := res.RowsAffected(); err != nil {
+		return errors.WithStack(err)
+	} else if affected == 0 {
+		return exception.WaitlistNotFound
+	}
+	return nil
+}
+
+// promote 는 [startTime, endTime) 안에 시작하는 대기를 먼저 들어온 순서대로 예약해보고 성공하면 대기에서 지움. 예약한 id 를 반환
+// now 전에 시작하는 대기는 건너뛰며, 겹침 제약 조건 위반은 transaction 전체를 실패시키므로 대기마다 savepoint 로 되돌림
+func (db *db) promote(ctx context.Context, tx *sqlx.Tx, roomID int64, startTime, endTime, now time.Time) ([]int64, error) {
+	if startTime.Before(now) {
+		startTime = now
+	}
+
+	list := []*dtoWaitlist{}
+	builder := selectWaitlist().
+		Where("w.item_id = ?", roomID).
+		Where("w.start_time >= ? AND w.start_time < ?", startTime, endTime).
+		OrderBy("w.id")
+	if err := db.query(ctx, &list, builder, tx.SelectContext); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	promoted := []int64{}
+	for _, w := range list {
+		if _, err := tx.ExecContext(ctx, "SAVEPOINT promote"); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		id, err := db.make(ctx, tx, roomID, w.UserName, w.StartTime, w.EndTime, w.Memo.String, reservation.Status(w.Status), 0, 0)
+		if err == exception.Unavailable {
+			if _, err := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT promote"); err != nil {
+				return nil, errors.WithStack(err)
+			}
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		if _, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT promote"); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if _, err := db.execWith(ctx, tx, psql.Delete("waitlist").Where("id = ?", w.ID)); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		promoted = append(promoted, id)
+	}
+	return promoted, nil
+}
+
+// promoteSlots 는 비워진 시간마다 promote 하며 보관된 회의실은 건너뜀
+// 호출하는 쪽에서 회의실 lock 을 먼저 잡고 있어야 함
+func (db *db) promoteSlots(ctx context.Context, tx *sqlx.Tx, slots []*dtoSlot, now time.Time) ([]int64, error) {
+	promoted := []int64{}
+	for _, slot := range slots {
+		if err := db.lockRoom(ctx, tx, slot.RoomID, "FOR SHARE"); err == exception.RoomNotFound {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		ids, err := db.promote(ctx, tx, slot.RoomID, slot.StartTime, slot.EndTime, now)
+		if err != nil {
+			return nil, err
+		}
+		promoted = append(promoted, ids...)
+	}
+	return promoted, nil
+}
+
+func selectWaitlist() sq.SelectBuilder {
+	return psql.Select(
+		"w.id",
+		"ri.id AS room_id",
+		"ri.name AS room_name",
+		"w.user_name",
+		"w.start_time",
+		"w.end_time",
+		"w.memo",
+		"w.status",
+		"w.created_at",
+	).
+		From("waitlist AS w").
+		Join("reservation_item AS ri ON w.item_id = ri.id")
+}
+
+type dtoWaitlist struct {
+	ID        int64          `db:"id"`
+	RoomID    int64          `db:"room_id"`
+	RoomName  string         `db:"room_name"`
+	UserName  string         `db:"user_name"`
+	StartTime time.Time      `db:"start_time"`
+	EndTime   time.Time      `db:"end_time"`
+	Memo      sql.NullString `db:"memo"`
+	Status    string         `db:"status"`
+	CreatedAt time.Time      `db:"created_at"`
+}
+
+func convertWaitlist(w *dtoWaitlist) *reservation.Waitlist {
+	return &reservation.Waitlist{
+		ID:        w.ID,
+		Room:      reservation.Room{ID: w.RoomID, Name: w.RoomName},
+		User:      w.UserName,
+		Start:     w.StartTime,
+		End:       w.EndTime,
+		Memo:      w.Memo.String,
+		Status:    reservation.Status(w.Status),
+		CreatedAt: w.CreatedAt,
+	}
+}
@@ -0,0 +1,91 @@
+package reservation
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rutesun/reservation/account"
+	"github.com/rutesun/reservation/exception"
+)
+
+// Status 는 예약의 승인 상태
+// StatusPending, StatusApproved 인 예약만 회의실 시간을 차지하며
+// StatusRejected, StatusCancelled, StatusNoShow 인 예약은 조회(Find) 만 가능하고 목록, 겹침 확인에서 제외
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusApproved  Status = "approved"
+	StatusRejected  Status = "rejected"
+	StatusCancelled Status = "cancelled"
+	// StatusNoShow 는 시작 후 체크인하지 않아 회의실 시간을 돌려준 예약
+	StatusNoShow Status = "no_show"
+)
+
+// Active 는 회의실 시간을 차지하는 상태인지 확인
+func (st Status) Active() bool {
+	return st == StatusPending || st == StatusApproved
+}
+
+// bookStatus 는 owner 가 roomID 에 예약할 수 있는지 확인하고 예약의 상태를 결정
+// 승인이 필요한 회의실은 승인할 수 있는 사용자(ActionApprove) 가 예약할 때만 바로 승인되며
+// 요청한 사용자가 없으면(ics 가져오기 등) 사용자별 확인만 건너뛰며 승인이 필요한 회의실이면 승인 대기
+func (s *Service) bookStatus(ctx context.Context, roomID int64, owner string) (Status, error) {
+	room, err := s.findRoom(ctx, roomID)
+	if err != nil {
+		return "", err
+	}
+	user, ok := account.FromContext(ctx)
+	if !ok {
+		if room.RequiresApproval {
+			return StatusPending, nil
+		}
+		return StatusApproved, nil
+	}
+
+	target := Target{Room: room, Owner: owner}
+	if err := s.authorize(ctx, ActionBook, target); err != nil {
+		return "", err
+	}
+	if room.RequiresApproval && s.authorizer.Authorize(ctx, user, ActionApprove, target) != nil {
+		return StatusPending, nil
+	}
+	return StatusApproved, nil
+}
+
+// Approve 는 승인 대기 중인 예약을 승인
+func (s *Service) Approve(ctx context.Context, reservationID int64) (*Detail, error) {
+	return s.review(ctx, reservationID, StatusApproved)
+}
+
+// Reject 는 승인 대기 중인 예약을 거절하며 거절된 예약의 시간은 대기를 먼저 예약하고 다른 사용자가 예약할 수 있음
+func (s *Service) Reject(ctx context.Context, reservationID int64) (*Detail, error) {
+	return s.review(ctx, reservationID, StatusRejected)
+}
+
+// review 는 승인 대기 중이 아닌 예약이면 exception.NotPending 을 반환
+func (s *Service) review(ctx context.Context, reservationID int64, status Status) (*Detail, error) {
+	detail, err := s.reservation.Find(ctx, reservationID)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err := s.authorize(ctx, ActionApprove, Target{Room: &detail.Room, Owner: detail.User}); err != nil {
+		return nil, err
+	}
+	if detail.Status != StatusPending {
+		return nil, errors.WithStack(exception.NotPending.WithDetail("현재 상태: " + string(detail.Status)))
+	}
+
+	promoted, err := s.reservation.Review(ctx, reservationID, status, time.Now())
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	eventType := EventApproved
+	if status == StatusRejected {
+		eventType = EventRejected
+	}
+	s.notify(ctx, eventType, []int64{reservationID})
+	s.notify(ctx, EventPromoted, promoted)
+	return s.Find(ctx, reservationID)
+}
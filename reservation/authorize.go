@@ -0,0 +1,95 @@
+package reservation
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/rutesun/reservation/account"
+	"github.com/rutesun/reservation/exception"
+)
+
+// Action 은 권한을 확인하는 작업
+type Action string
+
+const (
+	ActionBook       Action = "book"
+	ActionModify     Action = "modify"
+	ActionCancel     Action = "cancel"
+	ActionManageRoom Action = "manage_room"
+	ActionReport     Action = "report"
+	ActionApprove    Action = "approve"
+	ActionCheckIn    Action = "check_in"
+)
+
+// Target 은 작업 대상. Room 은 예약할 회의실, Owner 는 예약한(할) 사용자이며 작업에 따라 비어 있음
+type Target struct {
+	Room  *Room
+	Owner string
+}
+
+// Authorizer 는 user 가 target 에 action 을 할 수 있는지 확인
+// 할 수 없으면 이유를 덧붙인 exception.Forbidden 을 반환
+type Authorizer interface {
+	Authorize(ctx context.Context, user *account.User, action Action, target Target) error
+}
+
+// RoleAuthorizer 는 역할과 회의실 policy 로 권한을 확인하는 기본 Authorizer
+//   - Admin, FacilityManager 는 모든 회의실에 다른 사용자 이름으로도 예약할 수 있고 모든 예약을 변경, 취소, 체크인, 승인하며 회의실 관리, 보고서 조회 가능
+//   - Member 는 자신의 이름으로 open 회의실과 속한 group 의 회의실만 예약하고 자신의 예약만 변경, 취소, 체크인
+//   - Guest 는 조회만 가능
+type RoleAuthorizer struct{}
+
+func (RoleAuthorizer) Authorize(ctx context.Context, user *account.User, action Action, target Target) error {
+	switch user.Role {
+	case account.Admin, account.FacilityManager:
+		return nil
+	case account.Member:
+	default:
+		return forbidden("%s 역할은 조회만 할 수 있습니다", user.Role)
+	}
+
+	switch action {
+	case ActionManageRoom:
+		return forbidden("회의실은 관리자만 변경할 수 있습니다")
+	case ActionReport:
+		return forbidden("보고서는 관리자만 조회할 수 있습니다")
+	case ActionApprove:
+		return forbidden("예약은 관리자만 승인, 거절할 수 있습니다")
+	}
+	if target.Owner != user.Name {
+		return forbidden("다른 사용자의 예약은 관리자만 예약, 변경, 취소할 수 있습니다")
+	}
+	if action == ActionCancel || target.Room == nil {
+		return nil
+	}
+
+	switch target.Room.Policy {
+	case PolicyReadOnly:
+		return forbidden("%s 은 관리자만 예약할 수 있는 회의실입니다", target.Room.Name)
+	case PolicyGroup:
+		if !user.InGroup(target.Room.Group) {
+			return forbidden("%s 은 %s group 만 예약할 수 있는 회의실입니다", target.Room.Name, target.Room.Group)
+		}
+	}
+	return nil
+}
+
+func forbidden(format string, args ...interface{}) error {
+	return exception.Forbidden.WithDetail(fmt.Sprintf(format, args...))
+}
+
+// SetAuthorizer 는 기본 RoleAuthorizer 대신 사용할 Authorizer 를 지정
+func (s *Service) SetAuthorizer(authorizer Authorizer) {
+	s.authorizer = authorizer
+}
+
+// authorize 는 ctx 에 요청한 사용자가 있을 때만 권한을 확인
+// CLI 처럼 요청한 사용자 없이 내부에서 호출하면 확인하지 않음
+func (s *Service) authorize(ctx context.Context, action Action, target Target) error {
+	user, ok := account.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+	return errors.WithStack(s.authorizer.Authorize(ctx, user, action, target))
+}
@@ -0,0 +1,110 @@
+package reservation
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rutesun/reservation/exception"
+)
+
+// slotUnit 은 예약 시간의 단위. 예약은 정시 or 30분에 시작하고 끝남
+const slotUnit = 30 * time.Minute
+
+// maxSearchRange 는 빈 시간을 한번에 검색할 수 있는 최대 범위
+const maxSearchRange = 7 * 24 * time.Hour
+
+// Slot 은 예약 시간 [Start, End). 반복 예약의 회차이면 몇 번째 회차인지(Occurrence, 1부터) 를 포함
+type Slot struct {
+	Start      time.Time `json:"startTime"`
+	End        time.Time `json:"endTime"`
+	Occurrence int       `json:"occurrence,omitempty"`
+}
+
+// FreeSlots 는 회의실 하나에서 바로 예약할 수 있는 시간 목록
+type FreeSlots struct {
+	Room  *Room  `json:"room"`
+	Slots []Slot `json:"slots"`
+}
+
+// alignUp 은 t 이후 처음 오는 정시 or 30분으로 올림
+func alignUp(t time.Time) time.Time {
+	aligned := t.Add(-time.Duration(t.Minute()%30)*time.Minute -
+		time.Duration(t.Second())*time.Second -
+		time.Duration(t.Nanosecond()))
+	if aligned.Before(t) {
+		aligned = aligned.Add(slotUnit)
+	}
+	return aligned
+}
+
+// Search 는 [from, to) 안에서 duration 만큼 비어 있는 시간을 조건에 맞는 회의실별로 찾음
+// 시작 시간은 30분 단위로 옮겨가며 찾으므로 반환된 slot 은 서로 겹칠 수 있고 그대로 예약할 수 있음
+// 범위와 겹치는 예약을 한번에 조회하여 계산하며 빈 시간이 없는 회의실은 제외
+func (s *Service) Search(ctx context.Context, from, to time.Time, duration time.Duration, filter RoomFilter) ([]*FreeSlots, error) {
+	if !from.Before(to) {
+		return nil, errors.WithStack(exception.Mismatch("to", "끝 시간이 시작 시간보다 뒤여야 합니다"))
+	}
+	if to.Sub(from) > maxSearchRange {
+		return nil, errors.WithStack(exception.Mismatch("to", "한번에 %v 까지만 검색할 수 있습니다", maxSearchRange))
+	}
+	if duration <= 0 || duration%slotUnit != 0 {
+		return nil, errors.WithStack(exception.Mismatch("duration", "duration 은 %v 단위여야 합니다", slotUnit))
+	}
+
+	rooms, err := s.RoomList(ctx, filter)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	list, err := s.reservation.ListOverlapping(ctx, from, to)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	reserved := make(map[int64][]*Detail)
+	for _, detail := range list {
+		reserved[detail.Room.ID] = append(reserved[detail.Room.ID], detail)
+	}
+
+	result := []*FreeSlots{}
+	for _, room := range rooms {
+		slots := freeSlots(reserved[room.ID], alignUp(from), to, duration)
+		if len(slots) > 0 {
+			result = append(result, &FreeSlots{Room: room, Slots: slots})
+		}
+	}
+	return result, nil
+}
+
+// freeSlots 는 한 회의실의 예약 목록에서 start 부터 30분씩 옮겨가며 duration 만큼 비어 있는 시간을 찾음
+func freeSlots(reserved []*Detail, start, to time.Time, duration time.Duration) []Slot {
+	sort.Slice(reserved, func(i, j int) bool { return reserved[i].Start.Before(reserved[j].Start) })
+
+	slots := []Slot{}
+	i := 0
+	for st := start; !st.Add(duration).After(to); st = st.Add(slotUnit) {
+		et := st.Add(duration)
+
+		// 이미 끝난 예약은 이후 시작 시간과도 겹치지 않으므로 건너뜀
+		for i < len(reserved) && !reserved[i].End.After(st) {
+			i++
+		}
+
+		free := true
+		for _, r := range reserved[i:] {
+			if !r.Start.Before(et) {
+				break
+			}
+			if r.End.After(st) {
+				free = false
+				break
+			}
+		}
+		if free {
+			slots = append(slots, Slot{Start: st, End: et})
+		}
+	}
+	return slots
+}
@@ -0,0 +1,82 @@
+package reservation
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// 구독용 일정에 포함하는 기간. 반복 예약은 기간 안에 회차가 하나라도 있으면 전체 회차를 포함
+const (
+	calendarPast   = 30 * 24 * time.Hour
+	calendarFuture = 365 * 24 * time.Hour
+)
+
+// Calendar 는 회의실 or 사용자의 구독용 일정
+// Reservations 는 단건 예약, Series 는 반복 예약이며 Series 의 Occurrences 도 같은 조건의 회차만 포함
+type Calendar struct {
+	Name         string
+	Reservations []*Detail
+	Series       []*Series
+}
+
+// RoomCalendar 는 회의실 하나의 예약을 구독용 일정으로 만듦
+func (s *Service) RoomCalendar(ctx context.Context, roomID int64) (*Calendar, error) {
+	room, err := s.findRoom(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+	return s.calendar(ctx, room.Name, func(d *Detail) bool { return d.Room.ID == roomID })
+}
+
+// UserCalendar 는 사용자 한명의 예약을 구독용 일정으로 만듦
+func (s *Service) UserCalendar(ctx context.Context, userName string) (*Calendar, error) {
+	return s.calendar(ctx, userName, func(d *Detail) bool { return d.User == userName })
+}
+
+// calendar 는 기간 안의 예약 중 match 에 맞는 예약을 단건과 반복 예약으로 나눔
+// 반복 예약의 회차가 다른 회의실, 사용자로 변경되었으면 그 회차는 제외
+func (s *Service) calendar(ctx context.Context, name string, match func(*Detail) bool) (*Calendar, error) {
+	now := time.Now()
+	reserved, err := s.List(ctx, now.Add(-calendarPast), now.Add(calendarFuture))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	cal := &Calendar{Name: name, Reservations: []*Detail{}, Series: []*Series{}}
+	seen := make(map[int64]bool)
+	for _, list := range reserved {
+		for _, detail := range list {
+			if !match(detail) {
+				continue
+			}
+			if detail.SeriesID == nil {
+				cal.Reservations = append(cal.Reservations, detail)
+				continue
+			}
+			if seen[*detail.SeriesID] {
+				continue
+			}
+			seen[*detail.SeriesID] = true
+
+			series, err := s.FindSeries(ctx, *detail.SeriesID)
+			if err != nil {
+				return nil, err
+			}
+			occurrences := []*Detail{}
+			for _, o := range series.Occurrences {
+				if match(o) {
+					occurrences = append(occurrences, o)
+				}
+			}
+			series.Occurrences = occurrences
+			cal.Series = append(cal.Series, series)
+		}
+	}
+
+	sort.Slice(cal.Reservations, func(i, j int) bool { return cal.Reservations[i].ID < cal.Reservations[j].ID })
+	sort.Slice(cal.Series, func(i, j int) bool { return cal.Series[i].ID < cal.Series[j].ID })
+	return cal, nil
+}
@@ -0,0 +1,112 @@
+package reservation
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rutesun/reservation/exception"
+	"github.com/rutesun/reservation/log"
+)
+
+const (
+	// checkInEarly 는 시작 전에 체크인할 수 있는 시간
+	checkInEarly = 15 * time.Minute
+	// DefaultNoShowGrace 는 시작 후 체크인을 기다리는 기본 시간
+	DefaultNoShowGrace = 15 * time.Minute
+)
+
+// NoShowCount 는 사용자별 no_show 횟수
+type NoShowCount struct {
+	User  string `json:"user"`
+	Count int    `json:"count"`
+}
+
+// SetNoShowGrace 는 시작 후 체크인을 기다리는 시간을 지정. 0 이하이면 no_show 로 바꾸지 않음
+func (s *Service) SetNoShowGrace(grace time.Duration) {
+	s.noShowGrace = grace
+}
+
+// CheckIn 은 승인된 예약에 체크인하며 시작 checkInEarly 전부터 시작 후 noShowGrace 가 지나기 전까지 할 수 있음
+// 이미 체크인한 예약이면 그대로 반환
+func (s *Service) CheckIn(ctx context.Context, reservationID int64) (*Detail, error) {
+	detail, err := s.reservation.Find(ctx, reservationID)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if !detail.Status.Active() {
+		return nil, errors.WithStack(exception.NotFound)
+	}
+	if err := s.authorize(ctx, ActionCheckIn, Target{Owner: detail.User}); err != nil {
+		return nil, err
+	}
+	if detail.CheckedInAt != nil {
+		return detail, nil
+	}
+	if detail.Status != StatusApproved {
+		return nil, errors.WithStack(exception.InvalidCondition.WithDetail("승인되지 않은 예약은 체크인할 수 없습니다"))
+	}
+
+	now := time.Now()
+	deadline := detail.End
+	if s.noShowGrace > 0 && detail.Start.Add(s.noShowGrace).Before(deadline) {
+		deadline = detail.Start.Add(s.noShowGrace)
+	}
+	if now.Before(detail.Start.Add(-checkInEarly)) {
+		return nil, errors.WithStack(exception.InvalidCondition.WithDetail("체크인은 시작 " + checkInEarly.String() + " 전부터 할 수 있습니다"))
+	}
+	if !now.Before(deadline) {
+		return nil, errors.WithStack(exception.InvalidCondition.WithDetail("체크인할 수 있는 시간이 지났습니다"))
+	}
+
+	if err := s.reservation.CheckIn(ctx, reservationID, now); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return s.Find(ctx, reservationID)
+}
+
+// ReleaseNoShows 는 시작 후 noShowGrace 가 지나도록 체크인하지 않은 진행 중인 예약을 no_show 로 바꿔
+// 남은 회의실 시간을 돌려주고 알림. 남은 시간에 들어갈 수 있는 대기는 예약하고 알리며 이미 끝난 예약은 바꾸지 않음
+func (s *Service) ReleaseNoShows(ctx context.Context, now time.Time) ([]int64, error) {
+	if s.noShowGrace <= 0 {
+		return []int64{}, nil
+	}
+	released, promoted, err := s.reservation.ReleaseNoShows(ctx, now.Add(-s.noShowGrace), now)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	s.notify(ctx, EventNoShow, released)
+	s.notify(ctx, EventPromoted, promoted)
+	return released, nil
+}
+
+// WatchNoShows 는 ctx 가 취소될 때까지 interval 마다 ReleaseNoShows 를 실행하며 실패하면 log 만 남김
+func (s *Service) WatchNoShows(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if released, err := s.ReleaseNoShows(ctx, now); err != nil {
+				log.Errorf("no_show 확인 실패: %+v", err)
+			} else if len(released) > 0 {
+				log.Infof("체크인하지 않은 예약 %d 건을 no_show 로 바꿨습니다", len(released))
+			}
+		}
+	}
+}
+
+// NoShows 는 [from, to) 기간에 시작한 예약의 사용자별 no_show 횟수를 많은 순서로 조회
+func (s *Service) NoShows(ctx context.Context, from, to time.Time) ([]NoShowCount, error) {
+	if err := s.authorize(ctx, ActionReport, Target{}); err != nil {
+		return nil, err
+	}
+	if !from.Before(to) {
+		return nil, errors.WithStack(exception.Mismatch("to", "끝 날짜가 시작 날짜보다 뒤여야 합니다"))
+	}
+	counts, err := s.reservation.CountNoShows(ctx, from, to)
+	return counts, errors.WithStack(err)
+}
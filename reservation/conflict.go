@@ -0,0 +1,170 @@
+package reservation
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/rutesun/reservation/exception"
+)
+
+// partialRetry 는 Partial 예약 중 다른 예약이 먼저 들어와 실패했을 때 겹침을 다시 확인하여 재시도하는 횟수
+const partialRetry = 3
+
+// MakeResult 는 예약 결과. 단건 예약이면 ID, 반복 예약이면 SeriesID 와 예약된 상태(Status) 를 포함
+// DryRun 이면 실제로 예약하지 않고 Booked 는 예약될 시간, Conflicts 는 예약할 수 없는 시간
+type MakeResult struct {
+	ID        int64      `json:"id,omitempty"`
+	SeriesID  int64      `json:"seriesId,omitempty"`
+	Status    Status     `json:"status,omitempty"`
+	DryRun    bool       `json:"dryRun,omitempty"`
+	Booked    []Slot     `json:"booked"`
+	Conflicts []Conflict `json:"conflicts"`
+}
+
+// Conflict 는 예약할 수 없는 시간과 겹치는 기존 예약
+// With 가 없으면 같은 반복 예약의 앞 회차와 겹침
+type Conflict struct {
+	Slot
+	With *Detail `json:"with,omitempty"`
+}
+
+// ConflictError 는 겹치는 예약 때문에 예약할 수 없을 때의 오류
+// errors.Cause 는 exception.Unavailable 이므로 기존처럼 비교할 수 있고, AsConflict 로 겹치는 예약을 확인
+type ConflictError struct {
+	Conflicts []Conflict `json:"conflicts"`
+}
+
+func (e *ConflictError) Error() string {
+	return exception.Unavailable.Error()
+}
+
+func (e *ConflictError) Cause() error {
+	return exception.Unavailable
+}
+
+func (e *ConflictError) Unwrap() error {
+	return exception.Unavailable
+}
+
+// AsConflict 는 err 가 감싸고 있는 ConflictError 를 찾음
+func AsConflict(err error) (*ConflictError, bool) {
+	for err != nil {
+		if conflict, ok := err.(*ConflictError); ok {
+			return conflict, true
+		}
+		causer, ok := err.(interface{ Cause() error })
+		if !ok {
+			return nil, false
+		}
+		err = causer.Cause()
+	}
+	return nil, false
+}
+
+// withConflicts 는 exception.Unavailable 이면 겹치는 예약을 찾아 ConflictError 로 바꿈
+// 그 사이 겹치는 예약이 취소되어 찾지 못하면 err 를 그대로 반환
+func (s *Service) withConflicts(ctx context.Context, err error, roomID int64, slots []Slot, excludeID int64) error {
+	if errors.Cause(err) != exception.Unavailable {
+		return err
+	}
+
+	_, conflicts, lookupErr := s.conflicts(ctx, roomID, slots, excludeID)
+	if lookupErr != nil || len(conflicts) == 0 {
+		return err
+	}
+	return errors.WithStack(&ConflictError{Conflicts: conflicts})
+}
+
+// conflicts 는 slots 를 순서대로 예약한다고 할 때 예약할 수 있는 시간과 겹치는 시간을 나눔
+// 모든 slot 범위의 예약을 한번에 조회하여 계산하며 excludeID 예약(변경 중인 자기 자신)은 제외
+// 겹치는 예약의 memo 는 다른 사용자에게 보일 수 있으므로 포함하지 않음
+func (s *Service) conflicts(ctx context.Context, roomID int64, slots []Slot, excludeID int64) ([]Slot, []Conflict, error) {
+	if _, err := s.findRoom(ctx, roomID); err != nil {
+		return nil, nil, err
+	}
+
+	from, to := slots[0].Start, slots[0].End
+	for _, slot := range slots {
+		if slot.Start.Before(from) {
+			from = slot.Start
+		}
+		if slot.End.After(to) {
+			to = slot.End
+		}
+	}
+
+	list, err := s.reservation.ListOverlapping(ctx, from, to)
+	if err != nil {
+		return nil, nil, errors.WithStack(err)
+	}
+
+	available := []Slot{}
+	conflicts := []Conflict{}
+	for _, slot := range slots {
+		var conflict *Conflict
+		for _, detail := range list {
+			if detail.Room.ID != roomID || detail.ID == excludeID {
+				continue
+			}
+			if detail.End.After(slot.Start) && detail.Start.Before(slot.End) {
+				with := *detail
+				with.Memo = ""
+				conflict = &Conflict{Slot: slot, With: &with}
+				break
+			}
+		}
+		if conflict == nil && overlapsAny(available, slot) {
+			conflict = &Conflict{Slot: slot}
+		}
+
+		if conflict != nil {
+			conflicts = append(conflicts, *conflict)
+		} else {
+			available = append(available, slot)
+		}
+	}
+	return available, conflicts, nil
+}
+
+func overlapsAny(slots []Slot, slot Slot) bool {
+	for _, other := range slots {
+		if other.End.After(slot.Start) && other.Start.Before(slot.End) {
+			return true
+		}
+	}
+	return false
+}
+
+// preview 는 예약하지 않고 예약할 수 있는 시간과 겹치는 시간만 확인
+func (s *Service) preview(ctx context.Context, roomID int64, slots []Slot) (*MakeResult, error) {
+	available, conflicts, err := s.conflicts(ctx, roomID, slots, 0)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &MakeResult{DryRun: true, Booked: available, Conflicts: conflicts}, nil
+}
+
+// makePartial 은 겹치는 회차를 빼고 나머지 회차만 반복 예약으로 묶어서 예약
+// 겹침을 확인한 뒤 예약하기 전에 다른 예약이 들어오면 다시 확인하여 재시도
+// 예약할 수 있는 회차가 하나도 없으면 겹치는 회차 정보를 담은 ConflictError
+func (s *Service) makePartial(ctx context.Context, roomID int64, userName string, rule string, slots []Slot, memo string, status Status) (*MakeResult, error) {
+	for i := 0; ; i++ {
+		available, conflicts, err := s.conflicts(ctx, roomID, slots, 0)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		result := &MakeResult{Status: status, Booked: available, Conflicts: conflicts}
+		if len(available) == 0 {
+			return nil, errors.WithStack(&ConflictError{Conflicts: conflicts})
+		}
+
+		result.SeriesID, err = s.reservation.MakeSeries(ctx, roomID, userName, rule, available, memo, status)
+		if err == nil {
+			return result, nil
+		}
+		if errors.Cause(err) != exception.Unavailable || i+1 >= partialRetry {
+			return nil, errors.WithStack(err)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package reservation
+
+import (
+	"context"
+	"time"
+
+	"github.com/rutesun/reservation/log"
+)
+
+// EventType 은 예약에 생긴 변화의 종류
+type EventType string
+
+const (
+	EventCreated   EventType = "reservation.created"
+	EventModified  EventType = "reservation.modified"
+	EventCancelled EventType = "reservation.cancelled"
+	EventApproved  EventType = "reservation.approved"
+	EventRejected  EventType = "reservation.rejected"
+	// EventPromoted 는 대기에서 예약된 경우
+	EventPromoted EventType = "reservation.promoted"
+	// EventNoShow 는 체크인하지 않아 회의실 시간을 돌려준 경우
+	EventNoShow EventType = "reservation.no_show"
+)
+
+// EventTypes 는 모든 EventType
+var EventTypes = []EventType{EventCreated, EventModified, EventCancelled, EventApproved, EventRejected, EventPromoted, EventNoShow}
+
+// Event 는 사용자에게 알릴 예약의 변화
+// 반복 예약 전체를 예약, 취소한 경우 Reservation 은 첫 회차이며 Occurrences 는 함께 예약, 취소된 회차 수
+type Event struct {
+	Type        EventType `json:"type"`
+	Reservation *Detail   `json:"reservation"`
+	Occurrences int       `json:"occurrences,omitempty"`
+	At          time.Time `json:"at"`
+}
+
+// Notifier 는 Event 를 사용자에게 알림
+// Service 의 작업이 끝난 뒤 호출되므로 오래 걸리는 알림은 따로 보내야 하며 알림에 실패해도 작업은 취소되지 않음
+type Notifier interface {
+	Notify(ctx context.Context, event Event)
+}
+
+// logNotifier 는 Event 를 log 에만 남기는 기본 Notifier
+type logNotifier struct{}
+
+func (logNotifier) Notify(ctx context.Context, event Event) {
+	r := event.Reservation
+	log.Infof("%s: %s 님의 예약 #%d (%s, %v ~ %v)", event.Type, r.User, r.ID, r.Room.Name, r.Start, r.End)
+}
+
+// SetNotifier 는 기본 Notifier(log) 대신 사용할 Notifier 를 지정
+func (s *Service) SetNotifier(notifier Notifier) {
+	s.notifier = notifier
+}
+
+// AddNotifier 는 기존 Notifier 와 함께 알릴 Notifier 를 추가
+func (s *Service) AddNotifier(notifier Notifier) {
+	if list, ok := s.notifier.(notifiers); ok {
+		s.notifier = append(list, notifier)
+		return
+	}
+	s.notifier = notifiers{s.notifier, notifier}
+}
+
+// notifiers 는 추가된 순서대로 모두 알림
+type notifiers []Notifier
+
+func (list notifiers) Notify(ctx context.Context, event Event) {
+	for _, n := range list {
+		n.Notify(ctx, event)
+	}
+}
+
+// notify 는 예약 id 마다 예약을 조회하여 Event 를 알림. 조회하지 못한 예약은 log 만 남김
+func (s *Service) notify(ctx context.Context, eventType EventType, reservationIDs []int64) {
+	for _, id := range reservationIDs {
+		detail, err := s.reservation.Find(ctx, id)
+		if err != nil {
+			log.Warnf("%s 알림을 위해 예약 #%d 를 조회하지 못했습니다: %v", eventType, id, err)
+			continue
+		}
+		s.emit(ctx, Event{Type: eventType, Reservation: detail})
+	}
+}
+
+// notifySeries 는 반복 예약의 첫 회차로 occurrences 개 회차에 대한 Event 를 알림
+func (s *Service) notifySeries(ctx context.Context, eventType EventType, seriesID int64, occurrences int) {
+	series, err := s.reservation.FindSeries(ctx, seriesID)
+	if err != nil || len(series.Occurrences) == 0 {
+		log.Warnf("%s 알림을 위해 반복 예약 #%d 를 조회하지 못했습니다: %v", eventType, seriesID, err)
+		return
+	}
+	s.emit(ctx, Event{Type: eventType, Reservation: series.Occurrences[0], Occurrences: occurrences})
+}
+
+func (s *Service) emit(ctx context.Context, event Event) {
+	event.At = time.Now()
+	s.notifier.Notify(ctx, event)
+}
@@ -0,0 +1,271 @@
+package reservation
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rutesun/reservation/exception"
+)
+
+// 이용률은 평일 업무 시간 [openHour, closeHour) 기준으로 계산
+const (
+	openHour  = 9
+	closeHour = 18
+)
+
+// maxReportRange 는 이용률을 한번에 계산할 수 있는 최대 기간
+const maxReportRange = 366 * 24 * time.Hour
+
+// peakCount 는 이용률 보고서에 포함하는 가장 많이 예약된 시간대 수
+const peakCount = 3
+
+type GroupBy string
+
+const (
+	GroupByRoom GroupBy = "room"
+	GroupByDay  GroupBy = "day"
+	GroupByHour GroupBy = "hour"
+)
+
+// Usage 는 회의실, 날짜 or 시간대 하나의 이용률
+// BookedHours 는 업무 시간 중 예약된 시간, AvailableHours 는 업무 시간 전체 (회의실 수 만큼)
+// Meetings, AverageMinutes, RepeatRatio 는 기간 안에 시작한 예약 기준이며 RepeatRatio 는 반복 예약 회차의 비율
+// PeakHour 는 가장 많이 예약된 시간대(0~23), 예약이 없으면 없음
+type Usage struct {
+	Key            string  `json:"key"`
+	RoomID         int64   `json:"roomId,omitempty"`
+	BookedHours    float64 `json:"bookedHours"`
+	AvailableHours float64 `json:"availableHours"`
+	Utilization    float64 `json:"utilization"`
+	Meetings       int     `json:"meetings"`
+	AverageMinutes float64 `json:"averageMinutes"`
+	RepeatRatio    float64 `json:"repeatRatio"`
+	PeakHour       *int    `json:"peakHour,omitempty"`
+}
+
+// Utilization 은 [From, To) 기간의 이용률 보고서
+// Rows 는 GroupBy 별 이용률, PeakHours 는 전체에서 가장 많이 예약된 시간대 순서
+type Utilization struct {
+	From      time.Time `json:"from"`
+	To        time.Time `json:"to"`
+	GroupBy   GroupBy   `json:"groupBy"`
+	Total     Usage     `json:"total"`
+	PeakHours []int     `json:"peakHours"`
+	Rows      []Usage   `json:"rows"`
+}
+
+// usage 는 Usage 를 계산하기 위해 모으는 값
+type usage struct {
+	key       string
+	roomID    int64
+	booked    time.Duration
+	available time.Duration
+	meetings  int
+	length    time.Duration
+	repeats   int
+	hours     [24]time.Duration
+}
+
+func (u *usage) result() Usage {
+	r := Usage{
+		Key:            u.key,
+		RoomID:         u.roomID,
+		BookedHours:    round(u.booked.Hours(), 2),
+		AvailableHours: round(u.available.Hours(), 2),
+		Meetings:       u.meetings,
+	}
+	if u.available > 0 {
+		r.Utilization = round(float64(u.booked)/float64(u.available), 4)
+	}
+	if u.meetings > 0 {
+		r.AverageMinutes = round(u.length.Minutes()/float64(u.meetings), 2)
+		r.RepeatRatio = round(float64(u.repeats)/float64(u.meetings), 4)
+	}
+	if peaks := peakHours(u.hours, 1); len(peaks) > 0 {
+		r.PeakHour = &peaks[0]
+	}
+	return r
+}
+
+func round(v float64, digits int) float64 {
+	p := math.Pow(10, float64(digits))
+	return math.Round(v*p) / p
+}
+
+// peakHours 는 예약된 시간이 많은 시간대부터 n 개. 예약이 없는 시간대는 제외
+func peakHours(hours [24]time.Duration, n int) []int {
+	peaks := []int{}
+	for h, d := range hours {
+		if d > 0 {
+			peaks = append(peaks, h)
+		}
+	}
+	sort.SliceStable(peaks, func(i, j int) bool { return hours[peaks[i]] > hours[peaks[j]] })
+	if len(peaks) > n {
+		peaks = peaks[:n]
+	}
+	return peaks
+}
+
+// Utilization 은 [from, to) 기간 동안 보관되지 않은 회의실의 이용률을 groupBy 별로 계산
+// 날짜, 시간대, 업무 시간은 loc 기준이며 기간과 겹치는 예약을 한번에 조회하여 계산
+func (s *Service) Utilization(ctx context.Context, from, to time.Time, groupBy GroupBy, loc *time.Location) (*Utilization, error) {
+	if err := s.authorize(ctx, ActionReport, Target{}); err != nil {
+		return nil, err
+	}
+	switch groupBy {
+	case GroupByRoom, GroupByDay, GroupByHour:
+	default:
+		return nil, errors.WithStack(exception.Invalid("groupBy", "groupBy 는 room, day, hour 중 하나입니다"))
+	}
+	if !from.Before(to) {
+		return nil, errors.WithStack(exception.Mismatch("to", "끝 날짜가 시작 날짜보다 뒤여야 합니다"))
+	}
+	if to.Sub(from) > maxReportRange {
+		return nil, errors.WithStack(exception.Mismatch("to", "한번에 %d 일까지만 계산할 수 있습니다", maxReportRange/(24*time.Hour)))
+	}
+	from, to = from.In(loc), to.In(loc)
+
+	rooms, err := s.RoomList(ctx, RoomFilter{})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	list, err := s.reservation.ListOverlapping(ctx, from, to)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	total := &usage{key: "total"}
+	buckets := make(map[string]*usage)
+	// bucket 은 회의실 or t 의 날짜, 시간대에 해당하는 usage. 회의실은 이름이 같을 수 있으므로 id 로 구분
+	bucket := func(room *Room, t time.Time) *usage {
+		var key string
+		switch groupBy {
+		case GroupByDay:
+			key = t.Format("2006-01-02")
+		case GroupByHour:
+			key = t.Format("15:00")
+		default:
+			key = strconv.FormatInt(room.ID, 10)
+		}
+		b, ok := buckets[key]
+		if !ok {
+			b = &usage{key: key}
+			if groupBy == GroupByRoom {
+				b.key, b.roomID = room.Name, room.ID
+			}
+			buckets[key] = b
+		}
+		return b
+	}
+
+	active := make(map[int64]*Room)
+	for _, room := range rooms {
+		active[room.ID] = room
+		if groupBy == GroupByRoom {
+			bucket(room, from)
+		}
+	}
+
+	// 업무 시간을 한 시간씩 나누어 회의실 수 만큼 예약 가능한 시간으로 더함
+	for day := startOfDay(from); day.Before(to); day = day.AddDate(0, 0, 1) {
+		if day.Weekday() == time.Saturday || day.Weekday() == time.Sunday {
+			continue
+		}
+		for h := openHour; h < closeHour; h++ {
+			hs := time.Date(day.Year(), day.Month(), day.Day(), h, 0, 0, 0, loc)
+			d := overlap(hs, hs.Add(time.Hour), from, to)
+			if d <= 0 {
+				continue
+			}
+			total.available += d * time.Duration(len(rooms))
+			for _, room := range rooms {
+				bucket(room, hs).available += d
+			}
+		}
+	}
+
+	for _, r := range list {
+		room, ok := active[r.Room.ID]
+		if !ok {
+			continue
+		}
+		start, end := r.Start.In(loc), r.End.In(loc)
+
+		if !start.Before(from) {
+			for _, b := range []*usage{total, bucket(room, start)} {
+				b.meetings++
+				b.length += end.Sub(start)
+				if r.SeriesID != nil {
+					b.repeats++
+				}
+			}
+		}
+
+		// 예약을 한 시간 단위로 나누어 업무 시간과 겹치는 만큼 더함
+		for hs := startOfHour(maxTime(start, from)); hs.Before(end) && hs.Before(to); hs = hs.Add(time.Hour) {
+			if hs.Weekday() == time.Saturday || hs.Weekday() == time.Sunday ||
+				hs.Hour() < openHour || hs.Hour() >= closeHour {
+				continue
+			}
+			d := overlap(hs, hs.Add(time.Hour), maxTime(start, from), minTime(end, to))
+			if d <= 0 {
+				continue
+			}
+			for _, b := range []*usage{total, bucket(room, hs)} {
+				b.booked += d
+				b.hours[hs.Hour()] += d
+			}
+		}
+	}
+
+	report := &Utilization{
+		From:      from,
+		To:        to,
+		GroupBy:   groupBy,
+		Total:     total.result(),
+		PeakHours: peakHours(total.hours, peakCount),
+		Rows:      make([]Usage, 0, len(buckets)),
+	}
+	for _, b := range buckets {
+		report.Rows = append(report.Rows, b.result())
+	}
+	sort.Slice(report.Rows, func(i, j int) bool {
+		if report.Rows[i].Key != report.Rows[j].Key {
+			return report.Rows[i].Key < report.Rows[j].Key
+		}
+		return report.Rows[i].RoomID < report.Rows[j].RoomID
+	})
+	return report, nil
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func startOfHour(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, t.Location())
+}
+
+// overlap 은 [s1, e1) 와 [s2, e2) 가 겹치는 길이
+func overlap(s1, e1, s2, e2 time.Time) time.Duration {
+	return minTime(e1, e2).Sub(maxTime(s1, s2))
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
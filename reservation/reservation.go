@@ -1,6 +1,7 @@
 package reservation
 
 import (
+	"context"
 	"time"
 
 	"github.com/pkg/errors"
@@ -10,18 +11,32 @@ import (
 
 const DateFormat = "2016-01-02"
 
+// Detail 이 반복 예약의 한 회차이면 SeriesID 와 몇 번째 회차인지(Occurrence, 1부터) 를 포함
+// Exception 은 반복 예약에서 따로 변경된 회차, CheckedInAt 은 체크인한 시간
 type Detail struct {
-	ID    int64     `json:"id"`
-	Room  Room      `json:"room"`
-	User  string    `json:"user"`
-	Start time.Time `json:"startTime"`
-	End   time.Time `json:"endTime"`
-	Memo  string    `json:"memo"`
+	ID         int64     `json:"id"`
+	Room       Room      `json:"room"`
+	User       string    `json:"user"`
+	Start      time.Time `json:"startTime"`
+	End        time.Time `json:"endTime"`
+	Memo       string    `json:"memo"`
+	Status     Status    `json:"status"`
+	SeriesID   *int64    `json:"seriesId,omitempty"`
+	Occurrence int       `json:"occurrence,omitempty"`
+	Exception  bool      `json:"exception,omitempty"`
+
+	CheckedInAt *time.Time `json:"checkedInAt,omitempty"`
 }
 
+// ExtraInfo 의 Rule 이 있으면 Rule 로 반복하고, 없으면 Repeat 횟수만큼 매주 반복
+// 반복 예약은 Series 로 묶여 함께 조회, 취소할 수 있음
+// Partial 이면 겹치는 회차만 빼고 예약하며, DryRun 이면 예약하지 않고 결과만 미리 확인
 type ExtraInfo struct {
-	Memo   string
-	Repeat int
+	Memo    string
+	Repeat  int
+	Rule    *Rule
+	Partial bool
+	DryRun  bool
 }
 
 var emptyExtra = ExtraInfo{}
@@ -36,32 +51,62 @@ func NewReservedMap() *ReservedMap {
 }
 
 type reservationRepository interface {
-	RoomList() ([]*Room, error)
-	List(startDate, endDate time.Time) ([]*Detail, error)
-	Available(roomID int64, startTime, endTime time.Time) (bool, error)
-	Make(roomID int64, userName string, startTime, endTime time.Time, memo string) (int64, error)
-	MakeRepeatly(roomID int64, userName string, startTime, endTime time.Time, repeatCnt int, memo string) ([]int64, error)
-	Cancel(reservationID int64) (bool, error)
+	RoomList(ctx context.Context, filter RoomFilter) ([]*Room, error)
+	List(ctx context.Context, startDate, endDate time.Time) ([]*Detail, error)
+	ListOverlapping(ctx context.Context, startTime, endTime time.Time) ([]*Detail, error)
+	Available(ctx context.Context, roomID int64, startTime, endTime time.Time) (bool, error)
+	Make(ctx context.Context, roomID int64, userName string, startTime, endTime time.Time, memo string, status Status) (int64, error)
+	MakeSeries(ctx context.Context, roomID int64, userName string, rule string, slots []Slot, memo string, status Status) (int64, error)
+	Find(ctx context.Context, reservationID int64) (*Detail, error)
+	Modify(ctx context.Context, reservationID int64, roomID int64, userName string, startTime, endTime time.Time, memo string, status Status) error
+	Cancel(ctx context.Context, reservationID int64, now time.Time) ([]int64, error)
+	Review(ctx context.Context, reservationID int64, status Status, now time.Time) ([]int64, error)
+
+	FindSeries(ctx context.Context, seriesID int64) (*Series, error)
+	CancelSeries(ctx context.Context, seriesID int64, from int, now time.Time) ([]int64, []int64, error)
+
+	CreateRoom(ctx context.Context, room *Room) (int64, error)
+	UpdateRoom(ctx context.Context, room *Room) error
+	ArchiveRoom(ctx context.Context, roomID int64, now time.Time, cascade bool) ([]int64, error)
+
+	JoinWaitlist(ctx context.Context, w *Waitlist) (int64, error)
+	FindWaitlist(ctx context.Context, waitlistID int64) (*Waitlist, error)
+	ListWaitlist(ctx context.Context, userName string) ([]*Waitlist, error)
+	LeaveWaitlist(ctx context.Context, waitlistID int64) error
+
+	CheckIn(ctx context.Context, reservationID int64, now time.Time) error
+	ReleaseNoShows(ctx context.Context, startedBefore, now time.Time) ([]int64, []int64, error)
+	CountNoShows(ctx context.Context, from, to time.Time) ([]NoShowCount, error)
 }
 
+// Service 는 ctx 에 요청한 사용자(account.FromContext) 가 있으면 authorizer 로 권한을 확인
+// 대기에서 예약된 경우처럼 사용자에게 알릴 변화는 notifier 로 알림
 type Service struct {
 	reservation reservationRepository
+	authorizer  Authorizer
+	notifier    Notifier
+	// noShowGrace 는 시작 후 체크인을 기다리는 시간
+	noShowGrace time.Duration
 }
 
 func New(reservation reservationRepository) *Service {
-	return &Service{reservation}
+	return &Service{reservation: reservation, authorizer: RoleAuthorizer{}, notifier: logNotifier{}, noShowGrace: DefaultNoShowGrace}
 }
 
-func (s *Service) RoomList() ([]*Room, error) {
-	return s.reservation.RoomList()
+func (s *Service) RoomList(ctx context.Context, filter RoomFilter) ([]*Room, error) {
+	if filter.MinCapacity < 0 {
+		return nil, errors.WithStack(exception.Invalid("minCapacity", "수용 인원은 0 이상이어야 합니다"))
+	}
+	filter.Equipment = normalizeEquipment(filter.Equipment)
+	return s.reservation.RoomList(ctx, filter)
 }
 
-func (s *Service) List(startDate, endDate time.Time) (map[int64][]*Detail, error) {
+func (s *Service) List(ctx context.Context, startDate, endDate time.Time) (map[int64][]*Detail, error) {
 	if endDate.Before(startDate) {
-		return nil, errors.WithStack(exception.InvalidRequest)
+		return nil, errors.WithStack(exception.Mismatch("endDate", "끝 날짜가 시작 날짜보다 앞섭니다"))
 	}
 
-	list, err := s.reservation.List(startDate, endDate)
+	list, err := s.reservation.List(ctx, startDate, endDate)
 	if err != nil {
 		return nil, err
 	}
@@ -80,34 +125,174 @@ func (s *Service) List(startDate, endDate time.Time) (map[int64][]*Detail, error
 	return reservedMap, nil
 }
 
-func (s *Service) Available(roomID int64, startTimestamp time.Time, endTimestamp time.Time) (bool, error) {
+func (s *Service) Available(ctx context.Context, roomID int64, startTimestamp time.Time, endTimestamp time.Time) (bool, error) {
 	if endTimestamp.Before(startTimestamp) {
-		return false, errors.WithStack(exception.InvalidRequest)
+		return false, errors.WithStack(exception.Mismatch("end_time", "끝나는 시간이 시작 시간보다 앞섭니다"))
 	}
 
-	return s.reservation.Available(roomID, startTimestamp, endTimestamp)
+	return s.reservation.Available(ctx, roomID, startTimestamp, endTimestamp)
 }
 
-func (s *Service) Make(roomID int64, userName string, startTimestamp time.Time, endTimestamp time.Time, extra ExtraInfo) error {
+// validate 는 예약 시간이 올바른지 확인
+func validate(startTimestamp time.Time, endTimestamp time.Time) error {
 	if endTimestamp.Before(startTimestamp) {
-		return errors.WithStack(exception.InvalidRequest)
+		return errors.WithStack(exception.Mismatch("end_time", "끝나는 시간이 시작 시간보다 앞섭니다"))
 	}
 
 	// 정시 or 30분 단위로만 예약 가능
-	if startTimestamp.Minute()%30 != 0 ||
-		endTimestamp.Minute()%30 != 0 {
-		return errors.WithStack(exception.InvalidRequest)
+	if startTimestamp.Minute()%30 != 0 {
+		return errors.WithStack(exception.Mismatch("start_time", "정시 or 30분 단위로만 예약할 수 있습니다"))
+	}
+	if endTimestamp.Minute()%30 != 0 {
+		return errors.WithStack(exception.Mismatch("end_time", "정시 or 30분 단위로만 예약할 수 있습니다"))
+	}
+	return nil
+}
+
+// Make 는 예약 결과를 반환하며 반복 예약의 일부 회차만 예약한 경우 예약하지 못한 회차는 Conflicts 에 포함
+// 반복 예약은 회차마다 알리지 않고 첫 회차로 한번만 알림
+// 승인이 필요한 회의실이면 모든 회차가 승인 대기(StatusPending) 로 예약됨
+func (s *Service) Make(ctx context.Context, roomID int64, userName string, startTimestamp time.Time, endTimestamp time.Time, extra ExtraInfo) (*MakeResult, error) {
+	if err := validate(startTimestamp, endTimestamp); err != nil {
+		return nil, err
+	}
+	status, err := s.bookStatus(ctx, roomID, userName)
+	if err != nil {
+		return nil, err
+	}
+
+	rule := extra.Rule
+	if rule == nil && extra.Repeat > 1 {
+		var err error
+		if rule, err = WeeklyRule(extra.Repeat, startTimestamp.Location()); err != nil {
+			return nil, err
+		}
+	}
+
+	slots := []Slot{{Start: startTimestamp, End: endTimestamp}}
+	if rule != nil {
+		var err error
+		if slots, err = rule.Expand(startTimestamp, endTimestamp); err != nil {
+			return nil, err
+		}
+		for _, slot := range slots {
+			if err := validate(slot.Start, slot.End); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	switch {
+	case extra.DryRun:
+		return s.preview(ctx, roomID, slots)
+	case rule == nil:
+		id, err := s.reservation.Make(ctx, roomID, userName, startTimestamp, endTimestamp, extra.Memo, status)
+		if err != nil {
+			return nil, errors.WithStack(s.withConflicts(ctx, err, roomID, slots, 0))
+		}
+		s.notify(ctx, EventCreated, []int64{id})
+		return &MakeResult{ID: id, Status: status, Booked: slots, Conflicts: []Conflict{}}, nil
+	case extra.Partial:
+		result, err := s.makePartial(ctx, roomID, userName, rule.String(), slots, extra.Memo, status)
+		if err != nil {
+			return nil, err
+		}
+		s.notifySeries(ctx, EventCreated, result.SeriesID, len(result.Booked))
+		return result, nil
+	default:
+		seriesID, err := s.reservation.MakeSeries(ctx, roomID, userName, rule.String(), slots, extra.Memo, status)
+		if err != nil {
+			return nil, errors.WithStack(s.withConflicts(ctx, err, roomID, slots, 0))
+		}
+		s.notifySeries(ctx, EventCreated, seriesID, len(slots))
+		return &MakeResult{SeriesID: seriesID, Status: status, Booked: slots, Conflicts: []Conflict{}}, nil
+	}
+}
+
+func (s *Service) Find(ctx context.Context, reservationID int64) (*Detail, error) {
+	detail, err := s.reservation.Find(ctx, reservationID)
+	return detail, errors.WithStack(err)
+}
+
+// Modification 은 예약에서 바꿀 항목만 채움. nil 인 항목은 기존 값을 유지
+// 예약한 사용자는 바꿀 수 없음
+type Modification struct {
+	RoomID *int64
+	Start  *time.Time
+	End    *time.Time
+	Memo   *string
+}
+
+// Modify 는 예약의 회의실, 시간, 메모를 한번에 변경
+// 예약 id 를 유지하며 겹침 확인에서 자기 자신은 제외
+// 반복 예약의 회차이면 반복 예약에 남은 채로 예외(Exception) 회차가 됨
+// 바꾼 회의실(바꾸지 않으면 기존 회의실) 에 다시 예약할 수 있는지도 확인
+// 승인된 예약도 승인이 필요한 회의실, 시간을 바꾸면 다시 승인 대기가 됨
+func (s *Service) Modify(ctx context.Context, reservationID int64, m Modification) (*Detail, error) {
+	detail, err := s.reservation.Find(ctx, reservationID)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if !detail.Status.Active() {
+		return nil, errors.WithStack(exception.NotFound)
+	}
+	if err := s.authorize(ctx, ActionModify, Target{Owner: detail.User}); err != nil {
+		return nil, err
+	}
+	before := *detail
+
+	if m.RoomID != nil {
+		detail.Room = Room{ID: *m.RoomID}
+	}
+	status, err := s.bookStatus(ctx, detail.Room.ID, detail.User)
+	if err != nil {
+		return nil, err
 	}
-	var err error
-	if extra.Repeat > 1 {
-		_, err = s.reservation.MakeRepeatly(roomID, userName, startTimestamp, endTimestamp, extra.Repeat, extra.Memo)
-	} else {
-		_, err = s.reservation.Make(roomID, userName, startTimestamp, endTimestamp, extra.Memo)
+	if m.Start != nil {
+		detail.Start = *m.Start
+	}
+	if m.End != nil {
+		detail.End = *m.End
+	}
+	if m.Memo != nil {
+		detail.Memo = *m.Memo
 	}
-	return errors.WithStack(err)
 
+	if err := validate(detail.Start, detail.End); err != nil {
+		return nil, err
+	}
+	if before.Status == StatusApproved && detail.Room.ID == before.Room.ID &&
+		detail.Start.Equal(before.Start) && detail.End.Equal(before.End) {
+		status = StatusApproved
+	}
+
+	if err := s.reservation.Modify(ctx, reservationID, detail.Room.ID, detail.User, detail.Start, detail.End, detail.Memo, status); err != nil {
+		slots := []Slot{{Start: detail.Start, End: detail.End}}
+		return nil, errors.WithStack(s.withConflicts(ctx, err, detail.Room.ID, slots, reservationID))
+	}
+	s.notify(ctx, EventModified, []int64{reservationID})
+	return s.Find(ctx, reservationID)
 }
 
-func (s *Service) Cancel(reservationID int64) (bool, error) {
-	return s.reservation.Cancel(reservationID)
+// Cancel 은 예약을 취소(StatusCancelled) 하며 없거나 이미 취소, 거절된 예약이면 exception.NotFound 를 반환
+// 취소와 같은 transaction 에서 비워진 시간에 들어갈 수 있는 대기를 먼저 들어온 순서대로 예약하고 알림
+func (s *Service) Cancel(ctx context.Context, reservationID int64) (bool, error) {
+	detail, err := s.reservation.Find(ctx, reservationID)
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+	if !detail.Status.Active() {
+		return false, errors.WithStack(exception.NotFound)
+	}
+	if err := s.authorize(ctx, ActionCancel, Target{Owner: detail.User}); err != nil {
+		return false, err
+	}
+
+	promoted, err := s.reservation.Cancel(ctx, reservationID, time.Now())
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+	s.notify(ctx, EventCancelled, []int64{reservationID})
+	s.notify(ctx, EventPromoted, promoted)
+	return true, nil
 }
@@ -0,0 +1,52 @@
+// Package reservationtest 는 저장소(mariadb, postgres, sqlite, memory) 테스트가 함께 쓰는 검증을 모아 둠
+package reservationtest
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rutesun/reservation/exception"
+	"github.com/rutesun/reservation/reservation"
+	"github.com/stretchr/testify/assert"
+)
+
+// Maker 는 예약을 만드는 저장소
+type Maker interface {
+	Make(ctx context.Context, roomID int64, userName string, startTime, endTime time.Time, memo string, status reservation.Status) (int64, error)
+}
+
+// MakeConcurrently 는 roomID 의 [st, et) 에 겹치는 예약 n 개를 동시에 만들어 하나만 성공하는지 확인하고 만든 예약의 id 를 반환
+// 시작 시간을 30분씩 어긋나게 하여 unique 키가 아닌 겹침 확인으로만 막히도록 함
+func MakeConcurrently(t *testing.T, repo Maker, roomID int64, st, et time.Time, n int) []int64 {
+	var (
+		wg      sync.WaitGroup
+		success int32
+		ids     = make(chan int64, n)
+	)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			offset := time.Duration(i%3) * 30 * time.Minute
+			if id, err := repo.Make(context.Background(), roomID, "Ted", st.Add(offset), et.Add(offset), "", reservation.StatusApproved); err == nil {
+				atomic.AddInt32(&success, 1)
+				ids <- id
+			} else {
+				assert.EqualError(t, err, exception.Unavailable.Error())
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(ids)
+
+	assert.Equal(t, int32(1), success)
+
+	made := []int64{}
+	for id := range ids {
+		made = append(made, id)
+	}
+	return made
+}
@@ -1,6 +1,152 @@
 package reservation
 
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rutesun/reservation/exception"
+)
+
+// Policy 는 회의실을 누가 예약할 수 있는지
+// PolicyOpen 은 누구나, PolicyGroup 은 Room.Group 에 속한 사용자만, PolicyReadOnly 는 관리자만 예약 가능
+type Policy string
+
+const (
+	PolicyOpen     Policy = "open"
+	PolicyGroup    Policy = "group"
+	PolicyReadOnly Policy = "readonly"
+)
+
+// Room 의 속성은 예약 조회(Detail) 에는 포함되지 않으므로 비어 있으면 생략
+// RequiresApproval 이면 승인할 수 있는 사용자가 아닌 예약은 승인 대기(StatusPending) 로 시작
 type Room struct {
-	ID   int64  `json:"id"`
-	Name string `json:"name"`
+	ID               int64    `json:"id"`
+	Name             string   `json:"name"`
+	Capacity         int      `json:"capacity,omitempty"`
+	Building         string   `json:"building,omitempty"`
+	Floor            string   `json:"floor,omitempty"`
+	Equipment        []string `json:"equipment,omitempty"`
+	Policy           Policy   `json:"policy,omitempty"`
+	Group            string   `json:"group,omitempty"`
+	RequiresApproval bool     `json:"requiresApproval,omitempty"`
+}
+
+// RoomFilter 는 회의실 목록 조회 조건이며 0 값인 조건은 무시
+// Equipment 는 모든 장비를 갖춘 회의실만 조회
+type RoomFilter struct {
+	MinCapacity int
+	Equipment   []string
+}
+
+// normalizeEquipment 는 장비 코드(vc, whiteboard, screen 등)를 소문자로 맞추고 중복을 제거하여 정렬
+// "vc,screen" 처럼 ',' 로 이어진 값도 나누어 처리
+func normalizeEquipment(equipment []string) []string {
+	seen := make(map[string]bool)
+	normalized := []string{}
+	for _, e := range equipment {
+		for _, code := range strings.Split(e, ",") {
+			code = strings.ToLower(strings.TrimSpace(code))
+			if code == "" || seen[code] {
+				continue
+			}
+			seen[code] = true
+			normalized = append(normalized, code)
+		}
+	}
+	sort.Strings(normalized)
+	return normalized
+}
+
+// validateRoom 은 이름과 수용 인원, policy 를 확인하고 이름, 장비를 정리
+func validateRoom(room *Room) error {
+	room.Name = strings.TrimSpace(room.Name)
+	room.Building = strings.TrimSpace(room.Building)
+	room.Floor = strings.TrimSpace(room.Floor)
+	if room.Name == "" {
+		return errors.WithStack(exception.Invalid("name", "회의실 이름이 필요합니다"))
+	}
+	if room.Capacity < 0 {
+		return errors.WithStack(exception.Invalid("capacity", "수용 인원은 0 이상이어야 합니다"))
+	}
+	room.Equipment = normalizeEquipment(room.Equipment)
+
+	room.Group = strings.TrimSpace(room.Group)
+	switch room.Policy {
+	case "":
+		room.Policy = PolicyOpen
+	case PolicyOpen, PolicyReadOnly:
+	case PolicyGroup:
+		if room.Group == "" {
+			return errors.WithStack(exception.Invalid("group", "group 으로 제한하려면 group 이 필요합니다"))
+		}
+	default:
+		return errors.WithStack(exception.Invalid("policy", "policy 는 open, group, readonly 중 하나입니다"))
+	}
+	if room.Policy != PolicyGroup {
+		room.Group = ""
+	}
+	return nil
+}
+
+func (s *Service) findRoom(ctx context.Context, roomID int64) (*Room, error) {
+	rooms, err := s.reservation.RoomList(ctx, RoomFilter{})
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range rooms {
+		if r.ID == roomID {
+			return r, nil
+		}
+	}
+	return nil, errors.WithStack(exception.RoomNotFound)
+}
+
+func (s *Service) CreateRoom(ctx context.Context, room Room) (*Room, error) {
+	if err := s.authorize(ctx, ActionManageRoom, Target{Room: &room}); err != nil {
+		return nil, err
+	}
+	if err := validateRoom(&room); err != nil {
+		return nil, err
+	}
+
+	id, err := s.reservation.CreateRoom(ctx, &room)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return s.findRoom(ctx, id)
+}
+
+// UpdateRoom 은 이름과 속성을 모두 주어진 값으로 바꾸며 장비 목록도 통째로 교체
+func (s *Service) UpdateRoom(ctx context.Context, roomID int64, room Room) (*Room, error) {
+	if err := s.authorize(ctx, ActionManageRoom, Target{Room: &room}); err != nil {
+		return nil, err
+	}
+	if err := validateRoom(&room); err != nil {
+		return nil, err
+	}
+	room.ID = roomID
+
+	if err := s.reservation.UpdateRoom(ctx, &room); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return s.findRoom(ctx, roomID)
+}
+
+// ArchiveRoom 은 회의실을 목록에서 숨기고 더 이상 예약할 수 없게 함
+// 지난 예약은 그대로 남아 List 에서 회의실 이름을 확인할 수 있음
+// 앞으로 시작할 예약이 있으면 cascade 일 때만 해당 예약을 취소하고 보관하며, 아니면 exception.RoomInUse
+// 회의실의 대기는 모두 지우고, 취소된 예약은 알린 뒤 그 수를 반환
+func (s *Service) ArchiveRoom(ctx context.Context, roomID int64, cascade bool) (int64, error) {
+	if err := s.authorize(ctx, ActionManageRoom, Target{}); err != nil {
+		return 0, err
+	}
+	canceled, err := s.reservation.ArchiveRoom(ctx, roomID, time.Now(), cascade)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	s.notify(ctx, EventCancelled, canceled)
+	return int64(len(canceled)), nil
 }
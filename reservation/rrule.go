@@ -0,0 +1,370 @@
+package reservation
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rutesun/reservation/exception"
+)
+
+// maxOccurrences 는 반복 예약 하나로 만들 수 있는 최대 횟수
+const maxOccurrences = 366
+
+// maxPeriods 는 조건에 맞는 날이 거의 없는 규칙(ex: 2월부터 12개월마다 31일)으로 끝없이 찾지 않도록 확인할 최대 주기 수
+const maxPeriods = 5000
+
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+)
+
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// WeekdayNum 은 BYDAY 의 한 항목. N 은 MONTHLY 에서 몇 번째 요일인지(음수는 뒤에서부터), 0 이면 모든 해당 요일
+type WeekdayNum struct {
+	N   int
+	Day time.Weekday
+}
+
+// Rule 은 RFC 5545 RRULE 중 회의실 예약에 필요한 부분만 지원
+//   - FREQ: DAILY, WEEKLY, MONTHLY
+//   - INTERVAL, COUNT, UNTIL, BYDAY, BYMONTHDAY, WKST(MO 만 지원)
+//
+// 무한 반복은 허용하지 않으므로 COUNT 나 UNTIL 중 하나가 필요
+type Rule struct {
+	Freq       Frequency
+	Interval   int
+	Count      int
+	Until      time.Time
+	ByDay      []WeekdayNum
+	ByMonthDay []int
+
+	// loc 은 반복을 계산할 지역 시간. 일광 절약 시간이 바뀌어도 같은 시각을 유지
+	loc *time.Location
+}
+
+// ParseRule 은 "FREQ=WEEKLY;BYDAY=MO,WE;COUNT=10" 형식의 RRULE 을 해석
+// "RRULE:" 으로 시작해도 되며, loc 은 반복을 계산하고 UNTIL 에 시간대가 없을 때 사용
+func ParseRule(value string, loc *time.Location) (*Rule, error) {
+	if loc == nil {
+		loc = time.Local
+	}
+	rule := &Rule{Interval: 1, loc: loc}
+
+	value = strings.TrimPrefix(strings.TrimSpace(value), "RRULE:")
+	for _, part := range strings.Split(value, ";") {
+		if part == "" {
+			continue
+		}
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return nil, invalidRule("%s", part)
+		}
+		key, val := strings.ToUpper(kv[0]), strings.ToUpper(kv[1])
+
+		var err error
+		switch key {
+		case "FREQ":
+			switch Frequency(val) {
+			case Daily, Weekly, Monthly:
+				rule.Freq = Frequency(val)
+			default:
+				return nil, invalidRule("지원하지 않는 FREQ %s", val)
+			}
+		case "INTERVAL":
+			if rule.Interval, err = strconv.Atoi(val); err != nil || rule.Interval < 1 {
+				return nil, invalidRule("INTERVAL=%s", val)
+			}
+		case "COUNT":
+			if rule.Count, err = strconv.Atoi(val); err != nil || rule.Count < 1 {
+				return nil, invalidRule("COUNT=%s", val)
+			}
+		case "UNTIL":
+			if rule.Until, err = parseUntil(val, loc); err != nil {
+				return nil, invalidRule("UNTIL=%s", val)
+			}
+		case "BYDAY":
+			for _, day := range strings.Split(val, ",") {
+				wd, err := parseWeekdayNum(day)
+				if err != nil {
+					return nil, err
+				}
+				rule.ByDay = append(rule.ByDay, wd)
+			}
+		case "BYMONTHDAY":
+			for _, day := range strings.Split(val, ",") {
+				n, err := strconv.Atoi(day)
+				if err != nil || n == 0 || n < -31 || n > 31 {
+					return nil, invalidRule("BYMONTHDAY=%s", day)
+				}
+				rule.ByMonthDay = append(rule.ByMonthDay, n)
+			}
+		case "WKST":
+			if val != "MO" {
+				return nil, invalidRule("지원하지 않는 WKST %s", val)
+			}
+		default:
+			return nil, invalidRule("지원하지 않는 항목 %s", key)
+		}
+	}
+
+	if rule.Freq == "" {
+		return nil, invalidRule("FREQ 가 필요합니다")
+	}
+	if rule.Count == 0 && rule.Until.IsZero() {
+		return nil, invalidRule("COUNT 나 UNTIL 이 필요합니다")
+	}
+	if rule.Count > 0 && !rule.Until.IsZero() {
+		return nil, invalidRule("COUNT 와 UNTIL 은 함께 쓸 수 없습니다")
+	}
+	if rule.Count > maxOccurrences {
+		return nil, invalidRule("COUNT 는 %d 이하여야 합니다", maxOccurrences)
+	}
+	for _, wd := range rule.ByDay {
+		if wd.N != 0 && rule.Freq != Monthly {
+			return nil, invalidRule("몇 번째 요일은 MONTHLY 에서만 쓸 수 있습니다")
+		}
+	}
+	if len(rule.ByMonthDay) > 0 && rule.Freq != Monthly {
+		return nil, invalidRule("BYMONTHDAY 는 MONTHLY 에서만 쓸 수 있습니다")
+	}
+	return rule, nil
+}
+
+// String 은 RRULE 형식으로 반환하며 UNTIL 은 UTC 로 표기
+func (r *Rule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, fmt.Sprintf("INTERVAL=%d", r.Interval))
+	}
+	if r.Count > 0 {
+		parts = append(parts, fmt.Sprintf("COUNT=%d", r.Count))
+	}
+	if !r.Until.IsZero() {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for i, wd := range r.ByDay {
+			days[i] = strings.ToUpper(wd.Day.String()[:2])
+			if wd.N != 0 {
+				days[i] = strconv.Itoa(wd.N) + days[i]
+			}
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		days := make([]string, len(r.ByMonthDay))
+		for i, n := range r.ByMonthDay {
+			days[i] = strconv.Itoa(n)
+		}
+		parts = append(parts, "BYMONTHDAY="+strings.Join(days, ","))
+	}
+	return strings.Join(parts, ";")
+}
+
+// WeeklyRule 은 기존 repeat 횟수와 같이 매주 같은 시간에 count 번 반복하는 규칙
+func WeeklyRule(count int, loc *time.Location) (*Rule, error) {
+	return ParseRule(fmt.Sprintf("FREQ=WEEKLY;COUNT=%d", count), loc)
+}
+
+func invalidRule(format string, args ...interface{}) error {
+	return errors.WithStack(exception.Invalid("rrule", "잘못된 RRULE, "+format, args...))
+}
+
+// parseUntil 은 20181231T090000Z(UTC), 20181231T090000(지역 시간), 20181231(그 날 끝까지) 형식을 지원
+func parseUntil(value string, loc *time.Location) (time.Time, error) {
+	if strings.HasSuffix(value, "Z") {
+		return time.Parse("20060102T150405Z", value)
+	}
+	if strings.Contains(value, "T") {
+		return time.ParseInLocation("20060102T150405", value, loc)
+	}
+	date, err := time.ParseInLocation("20060102", value, loc)
+	if err != nil {
+		return date, err
+	}
+	return date.AddDate(0, 0, 1).Add(-time.Second), nil
+}
+
+// parseWeekdayNum 은 MO, 2TU, -1FR 형식을 해석
+func parseWeekdayNum(value string) (WeekdayNum, error) {
+	if len(value) < 2 {
+		return WeekdayNum{}, invalidRule("BYDAY=%s", value)
+	}
+	day, ok := weekdays[value[len(value)-2:]]
+	if !ok {
+		return WeekdayNum{}, invalidRule("BYDAY=%s", value)
+	}
+
+	n := 0
+	if prefix := value[:len(value)-2]; prefix != "" {
+		var err error
+		if n, err = strconv.Atoi(prefix); err != nil || n == 0 || n < -5 || n > 5 {
+			return WeekdayNum{}, invalidRule("BYDAY=%s", value)
+		}
+	}
+	return WeekdayNum{N: n, Day: day}, nil
+}
+
+// Expand 는 첫 예약 [start, end) 부터 규칙에 맞는 예약 시간을 순서대로 반환
+// 각 회차는 loc 기준으로 첫 예약과 같은 시각에 시작하고 같은 길이만큼 이어짐
+// 첫 예약 시간도 규칙에 맞아야 첫 회차로 포함됨
+func (r *Rule) Expand(start, end time.Time) ([]Slot, error) {
+	if end.Before(start) {
+		return nil, errors.WithStack(exception.Mismatch("end_time", "끝나는 시간이 시작 시간보다 앞섭니다"))
+	}
+
+	start = start.In(r.loc)
+	duration := end.Sub(start)
+	hour, min, sec := start.Clock()
+	at := func(date time.Time) time.Time {
+		return time.Date(date.Year(), date.Month(), date.Day(), hour, min, sec, 0, r.loc)
+	}
+
+	slots := []Slot{}
+	for period := 0; period < maxPeriods; period++ {
+		for _, date := range r.candidates(start, period*r.Interval) {
+			st := at(date)
+			if st.Before(start) {
+				continue
+			}
+			if !r.Until.IsZero() && st.After(r.Until) {
+				return r.done(slots)
+			}
+			slots = append(slots, Slot{Start: st, End: st.Add(duration), Occurrence: len(slots) + 1})
+			if len(slots) == r.Count {
+				return slots, nil
+			}
+			if len(slots) > maxOccurrences {
+				return nil, invalidRule("반복 횟수는 %d 이하여야 합니다", maxOccurrences)
+			}
+		}
+	}
+	return r.done(slots)
+}
+
+func (r *Rule) done(slots []Slot) ([]Slot, error) {
+	if len(slots) == 0 {
+		return nil, invalidRule("규칙에 맞는 예약 시간이 없습니다")
+	}
+	return slots, nil
+}
+
+// candidates 는 start 가 속한 주기에서 offset 만큼 떨어진 주기의 날짜들을 순서대로 반환
+func (r *Rule) candidates(start time.Time, offset int) []time.Time {
+	day := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, r.loc)
+
+	switch r.Freq {
+	case Daily:
+		date := day.AddDate(0, 0, offset)
+		if len(r.ByDay) > 0 && !r.hasWeekday(date.Weekday()) {
+			return nil
+		}
+		return []time.Time{date}
+
+	case Weekly:
+		// 월요일부터 시작하는 주 단위
+		monday := day.AddDate(0, 0, -((int(day.Weekday())+6)%7)+offset*7)
+		if len(r.ByDay) == 0 {
+			return []time.Time{monday.AddDate(0, 0, (int(start.Weekday())+6)%7)}
+		}
+		dates := []time.Time{}
+		for i := 0; i < 7; i++ {
+			if date := monday.AddDate(0, 0, i); r.hasWeekday(date.Weekday()) {
+				dates = append(dates, date)
+			}
+		}
+		return dates
+
+	default:
+		first := time.Date(start.Year(), start.Month()+time.Month(offset), 1, 0, 0, 0, 0, r.loc)
+		return r.monthDays(first, start.Day())
+	}
+}
+
+// monthDays 는 first 가 속한 달에서 BYDAY, BYMONTHDAY 에 맞는 날짜를 반환
+// 둘 다 있으면 두 조건을 모두 만족하는 날짜(ex: 13일의 금요일)이며
+// 둘 다 없으면 첫 예약과 같은 날짜로, 그 날짜가 없는 달(ex: 31일)은 건너뜀
+func (r *Rule) monthDays(first time.Time, defaultDay int) []time.Time {
+	last := first.AddDate(0, 1, -1).Day()
+
+	byMonthDay := map[int]bool{}
+	for _, n := range r.ByMonthDay {
+		if n < 0 {
+			n = last + n + 1
+		}
+		if n >= 1 && n <= last {
+			byMonthDay[n] = true
+		}
+	}
+
+	byDay := map[int]bool{}
+	for _, wd := range r.ByDay {
+		// 그 달의 첫번째 해당 요일부터 7일씩
+		matched := []int{}
+		for d := 1 + (int(wd.Day)-int(first.Weekday())+7)%7; d <= last; d += 7 {
+			matched = append(matched, d)
+		}
+
+		switch {
+		case wd.N == 0:
+			for _, d := range matched {
+				byDay[d] = true
+			}
+		case wd.N > 0 && wd.N <= len(matched):
+			byDay[matched[wd.N-1]] = true
+		case wd.N < 0 && -wd.N <= len(matched):
+			byDay[matched[len(matched)+wd.N]] = true
+		}
+	}
+
+	days := []int{}
+	for d := 1; d <= last; d++ {
+		switch {
+		case len(r.ByMonthDay) > 0 && len(r.ByDay) > 0:
+			if byMonthDay[d] && byDay[d] {
+				days = append(days, d)
+			}
+		case len(r.ByMonthDay) > 0:
+			if byMonthDay[d] {
+				days = append(days, d)
+			}
+		case len(r.ByDay) > 0:
+			if byDay[d] {
+				days = append(days, d)
+			}
+		case d == defaultDay:
+			days = append(days, d)
+		}
+	}
+
+	dates := make([]time.Time, len(days))
+	for i, d := range days {
+		dates[i] = first.AddDate(0, 0, d-1)
+	}
+	return dates
+}
+
+func (r *Rule) hasWeekday(day time.Weekday) bool {
+	for _, wd := range r.ByDay {
+		if wd.Day == day {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,101 @@
+package reservation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rutesun/reservation/exception"
+	"github.com/stretchr/testify/assert"
+)
+
+func expand(t *testing.T, rrule string, loc *time.Location, start string) []Slot {
+	rule, err := ParseRule(rrule, loc)
+	if !assert.NoError(t, err) {
+		return nil
+	}
+	st, err := time.ParseInLocation("2006-01-02T15:04", start, loc)
+	assert.NoError(t, err)
+
+	slots, err := rule.Expand(st, st.Add(time.Hour))
+	assert.NoError(t, err)
+	return slots
+}
+
+func dates(slots []Slot) []string {
+	list := make([]string, len(slots))
+	for i, slot := range slots {
+		list[i] = slot.Start.Format("2006-01-02 15:04 Mon")
+	}
+	return list
+}
+
+func TestParseRule(t *testing.T) {
+	for _, rrule := range []string{
+		"",
+		"FREQ=YEARLY;COUNT=2",
+		"FREQ=WEEKLY",
+		"FREQ=WEEKLY;COUNT=2;UNTIL=20181231",
+		"FREQ=WEEKLY;BYDAY=2MO;COUNT=2",
+		"FREQ=DAILY;BYMONTHDAY=1;COUNT=2",
+		"FREQ=DAILY;COUNT=1000",
+		"FREQ=DAILY;INTERVAL=0;COUNT=2",
+		"FREQ=MONTHLY;BYDAY=XX;COUNT=2",
+	} {
+		_, err := ParseRule(rrule, time.UTC)
+		assert.Equal(t, exception.InvalidRequest, errors.Cause(err), rrule)
+	}
+
+	rule, err := ParseRule("RRULE:FREQ=MONTHLY;BYDAY=-1FR;COUNT=3", time.UTC)
+	assert.NoError(t, err)
+	assert.Equal(t, Monthly, rule.Freq)
+	assert.Equal(t, []WeekdayNum{{N: -1, Day: time.Friday}}, rule.ByDay)
+}
+
+func TestRule_Expand(t *testing.T) {
+	seoul, _ := time.LoadLocation("Asia/Seoul")
+
+	t.Run("평일 매일", func(t *testing.T) {
+		slots := expand(t, "FREQ=DAILY;BYDAY=MO,TU,WE,TH,FR;COUNT=6", seoul, "2018-08-09T10:00")
+		assert.Equal(t, []string{
+			"2018-08-09 10:00 Thu", "2018-08-10 10:00 Fri",
+			"2018-08-13 10:00 Mon", "2018-08-14 10:00 Tue", "2018-08-15 10:00 Wed", "2018-08-16 10:00 Thu",
+		}, dates(slots))
+	})
+
+	t.Run("격주 월, 수", func(t *testing.T) {
+		slots := expand(t, "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE;UNTIL=20180831", seoul, "2018-08-08T10:00")
+		assert.Equal(t, []string{
+			"2018-08-08 10:00 Wed", "2018-08-20 10:00 Mon", "2018-08-22 10:00 Wed",
+		}, dates(slots))
+	})
+
+	t.Run("매달 둘째 화요일, 마지막 금요일", func(t *testing.T) {
+		slots := expand(t, "FREQ=MONTHLY;BYDAY=2TU,-1FR;COUNT=4", seoul, "2018-08-14T15:00")
+		assert.Equal(t, []string{
+			"2018-08-14 15:00 Tue", "2018-08-31 15:00 Fri",
+			"2018-09-11 15:00 Tue", "2018-09-28 15:00 Fri",
+		}, dates(slots))
+	})
+
+	t.Run("31일이 없는 달은 건너뜀", func(t *testing.T) {
+		slots := expand(t, "FREQ=MONTHLY;COUNT=3", seoul, "2018-08-31T09:00")
+		assert.Equal(t, []string{
+			"2018-08-31 09:00 Fri", "2018-10-31 09:00 Wed", "2018-12-31 09:00 Mon",
+		}, dates(slots))
+	})
+
+	t.Run("일광 절약 시간이 바뀌어도 같은 시각", func(t *testing.T) {
+		newYork, err := time.LoadLocation("America/New_York")
+		if err != nil {
+			t.Skip("America/New_York 시간대 정보가 없음")
+		}
+
+		slots := expand(t, "FREQ=WEEKLY;COUNT=3", newYork, "2018-10-28T10:00")
+		assert.Equal(t, []string{
+			"2018-10-28 10:00 Sun", "2018-11-04 10:00 Sun", "2018-11-11 10:00 Sun",
+		}, dates(slots))
+		assert.Equal(t, 169*time.Hour, slots[1].Start.Sub(slots[0].Start))
+		assert.Equal(t, time.Hour, slots[1].End.Sub(slots[1].Start))
+	})
+}
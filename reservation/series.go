@@ -0,0 +1,80 @@
+package reservation
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rutesun/reservation/exception"
+	"github.com/rutesun/reservation/log"
+)
+
+// Series 는 반복 규칙으로 만든 예약들을 묶음. Occurrences 는 회차 순서
+type Series struct {
+	ID          int64     `json:"id"`
+	Room        Room      `json:"room"`
+	User        string    `json:"user"`
+	Rule        string    `json:"rule"`
+	Memo        string    `json:"memo"`
+	Occurrences []*Detail `json:"occurrences"`
+}
+
+func (s *Service) FindSeries(ctx context.Context, seriesID int64) (*Series, error) {
+	series, err := s.reservation.FindSeries(ctx, seriesID)
+	return series, errors.WithStack(err)
+}
+
+// CancelSeries 는 반복 예약의 아직 시작하지 않은 회차를 모두 취소하고 취소된 회차 수를 반환
+func (s *Service) CancelSeries(ctx context.Context, seriesID int64) (int64, error) {
+	return s.cancelSeries(ctx, seriesID, 1)
+}
+
+// CancelFollowing 은 occurrence 회차와 그 이후 회차 중 아직 시작하지 않은 회차를 취소하고 취소된 회차 수를 반환
+// 이전 회차는 반복 예약에 그대로 남음
+func (s *Service) CancelFollowing(ctx context.Context, seriesID int64, occurrence int) (int64, error) {
+	if occurrence < 1 {
+		return 0, errors.WithStack(exception.Invalid("from", "회차는 1부터 시작합니다"))
+	}
+	return s.cancelSeries(ctx, seriesID, occurrence)
+}
+
+// cancelSeries 는 반복 예약을 만든 사용자로 취소 권한을 확인하며 이미 시작한 회차는 취소하지 않음
+// 취소된 회차의 시간에 들어갈 수 있는 대기는 예약하고 알림
+func (s *Service) cancelSeries(ctx context.Context, seriesID int64, from int) (int64, error) {
+	series, err := s.FindSeries(ctx, seriesID)
+	if err != nil {
+		return 0, err
+	}
+	if err := s.authorize(ctx, ActionCancel, Target{Owner: series.User}); err != nil {
+		return 0, err
+	}
+
+	canceled, promoted, err := s.reservation.CancelSeries(ctx, seriesID, from, time.Now())
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	if len(canceled) > 0 {
+		if detail, err := s.reservation.Find(ctx, canceled[0]); err == nil {
+			s.emit(ctx, Event{Type: EventCancelled, Reservation: detail, Occurrences: len(canceled)})
+		} else {
+			log.Warnf("%s 알림을 위해 예약 #%d 를 조회하지 못했습니다: %v", EventCancelled, canceled[0], err)
+		}
+	}
+	s.notify(ctx, EventPromoted, promoted)
+	return int64(len(canceled)), nil
+}
+
+// ModifyOccurrence 는 반복 예약의 한 회차만 변경하며 해당 회차는 예외(Exception) 회차가 됨
+func (s *Service) ModifyOccurrence(ctx context.Context, seriesID int64, occurrence int, m Modification) (*Detail, error) {
+	series, err := s.FindSeries(ctx, seriesID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, detail := range series.Occurrences {
+		if detail.Occurrence == occurrence {
+			return s.Modify(ctx, detail.ID, m)
+		}
+	}
+	return nil, errors.WithStack(exception.NotFound)
+}
@@ -0,0 +1,78 @@
+package reservation
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rutesun/reservation/exception"
+)
+
+// Waitlist 는 예약할 수 없던 시간에 대한 대기
+// 겹치는 예약이 취소되어 시간이 비면 먼저 들어온 대기부터 Status 로 예약되고 대기에서 빠짐
+// Status 는 대기에 들어갈 때 정해지며 승인이 필요한 회의실이면 StatusPending
+type Waitlist struct {
+	ID        int64     `json:"id"`
+	Room      Room      `json:"room"`
+	User      string    `json:"user"`
+	Start     time.Time `json:"startTime"`
+	End       time.Time `json:"endTime"`
+	Memo      string    `json:"memo"`
+	Status    Status    `json:"status"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// JoinWaitlist 는 예약할 수 없는(exception.Unavailable) 시간에 대기를 추가
+// 예약할 수 있는 권한이 있어야 하며 지금 바로 예약할 수 있는 시간이면 exception.InvalidCondition
+func (s *Service) JoinWaitlist(ctx context.Context, roomID int64, userName string, startTimestamp, endTimestamp time.Time, memo string) (*Waitlist, error) {
+	if err := validate(startTimestamp, endTimestamp); err != nil {
+		return nil, err
+	}
+	status, err := s.bookStatus(ctx, roomID, userName)
+	if err != nil {
+		return nil, err
+	}
+
+	if able, err := s.reservation.Available(ctx, roomID, startTimestamp, endTimestamp); err != nil {
+		return nil, errors.WithStack(err)
+	} else if able {
+		return nil, errors.WithStack(exception.InvalidCondition.WithDetail("지금 바로 예약할 수 있는 시간입니다"))
+	}
+
+	id, err := s.reservation.JoinWaitlist(ctx, &Waitlist{
+		Room:      Room{ID: roomID},
+		User:      userName,
+		Start:     startTimestamp,
+		End:       endTimestamp,
+		Memo:      memo,
+		Status:    status,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return s.FindWaitlist(ctx, id)
+}
+
+func (s *Service) FindWaitlist(ctx context.Context, waitlistID int64) (*Waitlist, error) {
+	w, err := s.reservation.FindWaitlist(ctx, waitlistID)
+	return w, errors.WithStack(err)
+}
+
+// Waitlist 는 사용자의 대기 목록을 들어온 순서대로 조회
+func (s *Service) Waitlist(ctx context.Context, userName string) ([]*Waitlist, error) {
+	list, err := s.reservation.ListWaitlist(ctx, userName)
+	return list, errors.WithStack(err)
+}
+
+// LeaveWaitlist 는 대기를 지우며 예약을 취소할 수 있는 사용자만 할 수 있음
+func (s *Service) LeaveWaitlist(ctx context.Context, waitlistID int64) error {
+	w, err := s.reservation.FindWaitlist(ctx, waitlistID)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if err := s.authorize(ctx, ActionCancel, Target{Owner: w.User}); err != nil {
+		return err
+	}
+	return errors.WithStack(s.reservation.LeaveWaitlist(ctx, waitlistID))
+}
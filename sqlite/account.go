@@ -0,0 +1,127 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/mattn/go-sqlite3"
+	"github.com/pkg/errors"
+	"github.com/rutesun/reservation/account"
+	"github.com/rutesun/reservation/exception"
+	sq "gopkg.in/Masterminds/squirrel.v1"
+)
+
+func (db *db) CreateUser(ctx context.Context, name, passwordHash string, now time.Time) (int64, error) {
+	builder := sq.Insert("account").
+		Columns("name", "password_hash", "created_at").
+		Values(name, passwordHash, utc(now))
+
+	res, err := db.Exec(ctx, builder)
+	if sqliteErr, ok := errors.Cause(err).(sqlite3.Error); ok && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
+		return 0, exception.UserExists
+	} else if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	id, err := res.LastInsertId()
+	return id, errors.WithStack(err)
+}
+
+func (db *db) FindUser(ctx context.Context, name string) (*account.User, error) {
+	builder := selectUser().
+		From("account AS a").
+		Where("a.name = ?", name)
+	return db.findUser(ctx, builder)
+}
+
+// UpdateUser 는 역할을 바꾸고 group 목록을 통째로 교체. 사용자는 Service 에서 먼저 조회함
+func (db *db) UpdateUser(ctx context.Context, user *account.User) error {
+	return db.transaction(ctx, func(tx *sqlx.Tx) error {
+		builder := sq.Update("account").
+			Set("role", string(user.Role)).
+			Where("id = ?", user.ID)
+
+		if _, err := db.execWith(ctx, tx, builder); err != nil {
+			return errors.WithStack(err)
+		}
+		if _, err := db.execWith(ctx, tx, sq.Delete("account_group").Where("account_id = ?", user.ID)); err != nil {
+			return errors.WithStack(err)
+		}
+		if len(user.Groups) == 0 {
+			return nil
+		}
+		insert := sq.Insert("account_group").Columns("account_id", "group_name")
+		for _, g := range user.Groups {
+			insert = insert.Values(user.ID, g)
+		}
+		_, err := db.execWith(ctx, tx, insert)
+		return errors.WithStack(err)
+	})
+}
+
+func (db *db) CreateToken(ctx context.Context, userID int64, tokenHash string, expiresAt time.Time) error {
+	builder := sq.Insert("account_token").
+		Columns("token_hash", "account_id", "expires_at").
+		Values(tokenHash, userID, utc(expiresAt))
+
+	_, err := db.Exec(ctx, builder)
+	return errors.WithStack(err)
+}
+
+func (db *db) FindUserByToken(ctx context.Context, tokenHash string, now time.Time) (*account.User, error) {
+	builder := selectUser().
+		From("account_token AS t").
+		Join("account AS a ON a.id = t.account_id").
+		Where("t.token_hash = ? AND t.expires_at > ?", tokenHash, utc(now))
+	return db.findUser(ctx, builder)
+}
+
+func (db *db) DeleteToken(ctx context.Context, tokenHash string) error {
+	builder := sq.Delete("account_token").Where("token_hash = ?", tokenHash)
+	_, err := db.Exec(ctx, builder)
+	return errors.WithStack(err)
+}
+
+func selectUser() sq.SelectBuilder {
+	return sq.Select("a.id", "a.name", "a.role", "a.password_hash", "a.created_at")
+}
+
+// findUser 는 사용자와 사용자의 group 을 조회
+func (db *db) findUser(ctx context.Context, builder sq.SelectBuilder) (*account.User, error) {
+	dto := dtoUser{}
+	if err := db.Get(ctx, &dto, builder); err == sql.ErrNoRows {
+		return nil, exception.UserNotFound
+	} else if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	user := convertUser(&dto)
+	groups := sq.Select("group_name").
+		From("account_group").
+		Where("account_id = ?", user.ID).
+		OrderBy("group_name")
+	if err := db.Select(ctx, &user.Groups, groups); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return user, nil
+}
+
+type dtoUser struct {
+	ID           int64     `db:"id"`
+	Name         string    `db:"name"`
+	Role         string    `db:"role"`
+	PasswordHash string    `db:"password_hash"`
+	CreatedAt    time.Time `db:"created_at"`
+}
+
+func convertUser(u *dtoUser) *account.User {
+	return &account.User{
+		ID:           u.ID,
+		Name:         u.Name,
+		Role:         account.Role(u.Role),
+		Groups:       []string{},
+		CreatedAt:    u.CreatedAt,
+		PasswordHash: u.PasswordHash,
+	}
+}
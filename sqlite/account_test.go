@@ -0,0 +1,53 @@
+package sqlite
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rutesun/reservation/exception"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDb_User(t *testing.T) {
+	sqlite := newTestDB(t)
+	now := time.Now()
+
+	id, err := sqlite.CreateUser(ctx, userName, "hash", now)
+	assert.NoError(t, err)
+	_, err = sqlite.CreateUser(ctx, userName, "hash", now)
+	assert.Equal(t, exception.UserExists, err)
+
+	user, err := sqlite.FindUser(ctx, userName)
+	assert.NoError(t, err)
+	assert.Equal(t, id, user.ID)
+	assert.Equal(t, "hash", user.PasswordHash)
+	_, err = sqlite.FindUser(ctx, "Amy")
+	assert.Equal(t, exception.UserNotFound, err)
+	assert.Equal(t, "member", string(user.Role))
+	assert.Empty(t, user.Groups)
+
+	user.Role, user.Groups = "admin", []string{"design", "dev"}
+	assert.NoError(t, sqlite.UpdateUser(ctx, user))
+	user, err = sqlite.FindUser(ctx, userName)
+	assert.NoError(t, err)
+	assert.Equal(t, "admin", string(user.Role))
+	assert.Equal(t, []string{"design", "dev"}, user.Groups)
+
+	user.Groups = []string{"dev"}
+	assert.NoError(t, sqlite.UpdateUser(ctx, user))
+	user, err = sqlite.FindUser(ctx, userName)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"dev"}, user.Groups)
+
+	assert.NoError(t, sqlite.CreateToken(ctx, id, "token", now.Add(time.Hour)))
+	user, err = sqlite.FindUserByToken(ctx, "token", now)
+	assert.NoError(t, err)
+	assert.Equal(t, userName, user.Name)
+
+	_, err = sqlite.FindUserByToken(ctx, "token", now.Add(2*time.Hour))
+	assert.Equal(t, exception.UserNotFound, err, "만료된 token")
+
+	assert.NoError(t, sqlite.DeleteToken(ctx, "token"))
+	_, err = sqlite.FindUserByToken(ctx, "token", now)
+	assert.Equal(t, exception.UserNotFound, err)
+}
@@ -0,0 +1,96 @@
+package sqlite
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"github.com/rutesun/reservation/exception"
+	"github.com/rutesun/reservation/reservation"
+	sq "gopkg.in/Masterminds/squirrel.v1"
+)
+
+// CheckIn 은 승인된 예약에만 체크인 시간을 기록하며 그 사이 no_show 로 바뀌었으면 exception.NotFound
+func (db *db) CheckIn(ctx context.Context, reservationID int64, now time.Time) error {
+	builder := sq.Update("reservation").
+		Set("checked_in_at", utc(now)).
+		Where("id = ? AND status = ? AND checked_in_at IS NULL", reservationID, string(reservation.StatusApproved))
+	res, err := db.Exec(ctx, builder)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if affected, err := res.RowsAffected(); err != nil {
+		return errors.WithStack(err)
+	} else if affected == 0 {
+		return exception.NotFound
+	}
+	return nil
+}
+
+// ReleaseNoShows 는 startedBefore 전에 시작하여 now 에 아직 진행 중인, 체크인하지 않은 승인된 예약을 no_show 로 바꿈
+// 같은 transaction 에서 남은 시간의 대기를 예약하여 바꾼 id 와 예약한 id 를 반환
+func (db *db) ReleaseNoShows(ctx context.Context, startedBefore, now time.Time) ([]int64, []int64, error) {
+	released, promoted := []int64{}, []int64{}
+	err := db.transaction(ctx, func(tx *sqlx.Tx) error {
+		slots := []*dtoSlot{}
+		builder := selectSlot().Where(noShow(startedBefore, now)).OrderBy("id")
+		if err := db.query(ctx, &slots, builder, tx.SelectContext); err != nil {
+			return errors.WithStack(err)
+		}
+		if len(slots) == 0 {
+			return nil
+		}
+		for _, slot := range slots {
+			released = append(released, slot.ID)
+		}
+
+		update := sq.Update("reservation").
+			Set("status", string(reservation.StatusNoShow)).
+			Where(sq.Eq{"id": released})
+		if _, err := db.execWith(ctx, tx, update); err != nil {
+			return errors.WithStack(err)
+		}
+
+		var err error
+		promoted, err = db.promoteSlots(ctx, tx, slots, now)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return released, promoted, nil
+}
+
+// noShow 는 no_show 로 바꿀 예약의 조건
+func noShow(startedBefore, now time.Time) sq.And {
+	return sq.And{
+		sq.Eq{"status": string(reservation.StatusApproved), "checked_in_at": nil},
+		sq.Expr("start_time <= ? AND end_time > ?", utc(startedBefore), utc(now)),
+	}
+}
+
+// CountNoShows 는 [from, to) 에 시작한 no_show 예약을 사용자별로 세어 많은 순서로 조회
+func (db *db) CountNoShows(ctx context.Context, from, to time.Time) ([]reservation.NoShowCount, error) {
+	list := []*dtoNoShowCount{}
+	builder := sq.Select("user_name", "count(*) AS count").
+		From("reservation").
+		Where("status = ? AND start_time >= ? AND start_time < ?", string(reservation.StatusNoShow), utc(from), utc(to)).
+		GroupBy("user_name").
+		OrderBy("count DESC", "user_name")
+	if err := db.Select(ctx, &list, builder); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	counts := make([]reservation.NoShowCount, len(list))
+	for i, c := range list {
+		counts[i] = reservation.NoShowCount{User: c.UserName, Count: c.Count}
+	}
+	return counts, nil
+}
+
+type dtoNoShowCount struct {
+	UserName string `db:"user_name"`
+	Count    int    `db:"count"`
+}
@@ -0,0 +1,449 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"github.com/rutesun/reservation/exception"
+	"github.com/rutesun/reservation/reservation"
+	sq "gopkg.in/Masterminds/squirrel.v1"
+)
+
+// active 는 회의실 시간을 차지하는(승인 대기, 승인된) 예약만 남기는 조건
+const active = "status IN ('pending', 'approved')"
+
+type db struct {
+	DB      *sqlx.DB
+	timeout time.Duration
+}
+
+// New 는 query 하나당 queryTimeout 을 적용하는 저장소를 생성. 0 이면 timeout 없음
+func New(d *sqlx.DB, queryTimeout time.Duration) *db {
+	return &db{DB: d, timeout: queryTimeout}
+}
+
+// sqlite 는 시간을 문자열로 저장하므로 비교가 가능하도록 항상 UTC 로 맞춰서 저장, 조회
+func utc(t time.Time) time.Time {
+	return t.UTC()
+}
+
+func (db *db) RoomList(ctx context.Context, filter reservation.RoomFilter) ([]*reservation.Room, error) {
+	rList := []*dtoRoom{}
+
+	builder := sq.Select(
+		"r.id",
+		"r.name",
+		"r.capacity",
+		"r.building",
+		"r.floor",
+		"r.policy",
+		"r.policy_group",
+		"r.requires_approval",
+	).
+		From("reservation_item AS r").
+		Where("r.item_type = 'MEETING' AND r.archived_at IS NULL").
+		OrderBy("r.id")
+	builder = filterRoom(builder, filter)
+
+	if err := db.Select(ctx, &rList, builder); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	rooms := make([]*reservation.Room, len(rList))
+	for i, r := range rList {
+		rooms[i] = convertRoom(r)
+	}
+
+	if err := db.loadEquipment(ctx, rooms); err != nil {
+		return nil, err
+	}
+	return rooms, nil
+}
+
+func (db *db) List(ctx context.Context, startDate, endDate time.Time) ([]*reservation.Detail, error) {
+	list, err := db.listAll(ctx, startDate, endDate)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	details := make([]*reservation.Detail, len(list))
+
+	for i, o := range list {
+		details[i] = convertReservation(o)
+	}
+	return details, nil
+}
+
+func (db *db) listAll(ctx context.Context, startDate, endDate time.Time) ([]*dtoReservation, error) {
+	reservations := []*dtoReservation{}
+
+	builder := selectReservation().
+		Where("r.start_time >= ? AND r.end_time < ?", utc(startDate), utc(endDate)).
+		Where("r." + active)
+
+	err := db.Select(ctx, &reservations, builder)
+	return reservations, err
+}
+
+// ListOverlapping 은 [startTime, endTime) 와 조금이라도 겹치는 예약을 모두 조회
+func (db *db) ListOverlapping(ctx context.Context, startTime, endTime time.Time) ([]*reservation.Detail, error) {
+	reservations := []*dtoReservation{}
+
+	builder := selectReservation().
+		Where("r.end_time > ? AND r.start_time < ?", utc(startTime), utc(endTime)).
+		Where("r." + active)
+
+	if err := db.Select(ctx, &reservations, builder); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	details := make([]*reservation.Detail, len(reservations))
+	for i, o := range reservations {
+		details[i] = convertReservation(o)
+	}
+	return details, nil
+}
+
+func selectReservation() sq.SelectBuilder {
+	return sq.Select(
+		"r.id",
+		"ri.id AS room_id",
+		"ri.name AS room_name",
+		"r.user_name AS user_name",
+		"r.start_time",
+		"r.end_time",
+		"r.memo",
+		"r.series_id",
+		"r.occurrence",
+		"r.is_exception",
+		"r.status",
+		"r.checked_in_at",
+	).
+		From("reservation AS r").
+		Join("reservation_item AS ri ON r.item_id = ri.id")
+}
+
+func (db *db) Find(ctx context.Context, reservationID int64) (*reservation.Detail, error) {
+	dto := dtoReservation{}
+
+	builder := selectReservation().Where("r.id = ?", reservationID)
+
+	if err := db.Get(ctx, &dto, builder); err == sql.ErrNoRows {
+		return nil, exception.NotFound
+	} else if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return convertReservation(&dto), nil
+}
+
+func (db *db) Available(ctx context.Context, roomID int64, startTime, endTime time.Time) (bool, error) {
+	return db.available(ctx, db.DB, roomID, startTime, endTime, 0)
+}
+
+// available 은 queryer(db 혹은 transaction) 로 excludeID 를 제외하고 겹치는 예약이 있는지 확인
+// connection 이 하나뿐이므로 transaction 중에는 반드시 해당 transaction 으로 조회해야 함
+func (db *db) available(ctx context.Context, queryer sqlx.QueryerContext, roomID int64, startTime, endTime time.Time, excludeID int64) (bool, error) {
+	builder := sq.Select("count(*)").
+		From("reservation").
+		Where("item_id = ?", roomID).
+		Where("end_time > ? AND start_time < ?", utc(startTime), utc(endTime)).
+		Where(active)
+	if excludeID != 0 {
+		builder = builder.Where("id <> ?", excludeID)
+	}
+
+	count := 0
+	if err := db.getWith(ctx, queryer, &count, builder); err != nil {
+		return false, errors.WithStack(err)
+	}
+
+	return count == 0, nil
+}
+
+// findRoom 은 회의실이 없거나 보관되었으면 exception.RoomNotFound
+func (db *db) findRoom(ctx context.Context, tx *sqlx.Tx, roomID int64) error {
+	builder := sq.Select("id").
+		From("reservation_item").
+		Where("id = ? AND item_type = 'MEETING' AND archived_at IS NULL", roomID)
+
+	var id int64
+	if err := db.getWith(ctx, tx, &id, builder); err == sql.ErrNoRows {
+		return exception.RoomNotFound
+	} else if err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// sqlite 는 transaction 을 BEGIN IMMEDIATE(_txlock=immediate) 로 시작하여 시작 시점에 쓰기 lock 을 잡음
+// 따라서 겹침 확인과 insert 사이에 다른 connection, process 가 끼어들 수 없음
+func (db *db) MakeSeries(ctx context.Context, roomID int64, userName string, rule string, slots []reservation.Slot, memo string, status reservation.Status) (int64, error) {
+	if len(slots) == 0 {
+		return 0, exception.InvalidRequest
+	}
+
+	var seriesID int64
+	err := db.transaction(ctx, func(tx *sqlx.Tx) error {
+		if err := db.findRoom(ctx, tx, roomID); err != nil {
+			return err
+		}
+
+		var err error
+		if seriesID, err = db.createSeries(ctx, tx, roomID, userName, rule, memo); err != nil {
+			return err
+		}
+
+		for _, slot := range slots {
+			if _, err := db.make(ctx, tx, roomID, userName, slot.Start, slot.End, memo, status, seriesID, slot.Occurrence); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return seriesID, nil
+}
+
+func (db *db) Make(ctx context.Context, roomID int64, userName string, startTime, endTime time.Time, memo string, status reservation.Status) (int64, error) {
+	var id int64
+	err := db.transaction(ctx, func(tx *sqlx.Tx) error {
+		if err := db.findRoom(ctx, tx, roomID); err != nil {
+			return err
+		}
+
+		var err error
+		id, err = db.make(ctx, tx, roomID, userName, startTime, endTime, memo, status, 0, 0)
+		return err
+	})
+	return id, err
+}
+
+// make 는 transaction 안에서 겹침을 확인한 뒤 insert
+// seriesID 가 0 이 아니면 반복 예약의 occurrence 번째 회차로 저장
+func (db *db) make(ctx context.Context, tx *sqlx.Tx, roomID int64, userName string, startTime, endTime time.Time, memo string, status reservation.Status, seriesID int64, occurrence int) (int64, error) {
+	if able, err := db.available(ctx, tx, roomID, startTime, endTime, 0); err != nil {
+		return 0, errors.WithStack(err)
+	} else if !able {
+		return 0, exception.Unavailable
+	}
+
+	columns := []string{"item_id", "user_name", "start_time", "end_time", "memo", "status"}
+	values := []interface{}{roomID, userName, utc(startTime), utc(endTime), memo, string(status)}
+
+	if seriesID != 0 {
+		columns = append(columns, "series_id", "occurrence")
+		values = append(values, seriesID, occurrence)
+	}
+
+	builder := sq.Insert("reservation").
+		Columns(columns...).
+		Values(values...)
+
+	res, err := db.execWith(ctx, tx, builder)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	return res.LastInsertId()
+}
+
+// Modify 는 자신을 제외한 겹침을 확인한 뒤 update 하며 취소, 거절된 예약이면 exception.NotFound
+func (db *db) Modify(ctx context.Context, reservationID int64, roomID int64, userName string, startTime, endTime time.Time, memo string, status reservation.Status) error {
+	return db.transaction(ctx, func(tx *sqlx.Tx) error {
+		var id int64
+		builder := sq.Select("id").From("reservation").Where("id = ? AND "+active, reservationID)
+		if err := db.getWith(ctx, tx, &id, builder); err == sql.ErrNoRows {
+			return exception.NotFound
+		} else if err != nil {
+			return errors.WithStack(err)
+		}
+
+		if err := db.findRoom(ctx, tx, roomID); err != nil {
+			return err
+		}
+
+		if able, err := db.available(ctx, tx, roomID, startTime, endTime, reservationID); err != nil {
+			return errors.WithStack(err)
+		} else if !able {
+			return exception.Unavailable
+		}
+
+		update := sq.Update("reservation").
+			SetMap(map[string]interface{}{
+				"item_id":    roomID,
+				"user_name":  userName,
+				"start_time": utc(startTime),
+				"end_time":   utc(endTime),
+				"memo":       memo,
+				"status":     string(status),
+				// 반복 예약의 회차를 따로 변경하면 예외 회차로 표시
+				"is_exception": sq.Expr("series_id IS NOT NULL"),
+			}).
+			Where("id = ?", reservationID)
+
+		_, err := db.execWith(ctx, tx, update)
+		return errors.WithStack(err)
+	})
+}
+
+// Cancel 은 예약을 지우지 않고 cancelled 로 바꾸며 이미 취소, 거절된 예약이면 exception.NotFound
+// 같은 transaction 에서 비워진 시간에 들어갈 수 있는 대기를 예약하고 예약한 id 를 반환
+func (db *db) Cancel(ctx context.Context, reservationID int64, now time.Time) ([]int64, error) {
+	var promoted []int64
+	err := db.transaction(ctx, func(tx *sqlx.Tx) error {
+		target := dtoSlot{}
+		builder := sq.Select("item_id", "start_time", "end_time").
+			From("reservation").
+			Where("id = ? AND "+active, reservationID)
+		if err := db.getWith(ctx, tx, &target, builder); err == sql.ErrNoRows {
+			return exception.NotFound
+		} else if err != nil {
+			return errors.WithStack(err)
+		}
+
+		update := sq.Update("reservation").
+			Set("status", string(reservation.StatusCancelled)).
+			Where("id = ?", reservationID)
+		if _, err := db.execWith(ctx, tx, update); err != nil {
+			return errors.WithStack(err)
+		}
+
+		// 보관된 회의실이면 대기를 예약하지 않음
+		if err := db.findRoom(ctx, tx, target.RoomID); err == exception.RoomNotFound {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		var err error
+		promoted, err = db.promote(ctx, tx, target.RoomID, target.StartTime, target.EndTime, now)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return promoted, nil
+}
+
+// Review 는 승인 대기 중인 예약의 상태만 바꾸며 이미 처리되었으면 exception.NotPending
+// 거절하면 같은 transaction 에서 비워진 시간의 대기를 예약하고 예약한 id 를 반환
+func (db *db) Review(ctx context.Context, reservationID int64, status reservation.Status, now time.Time) ([]int64, error) {
+	var promoted []int64
+	err := db.transaction(ctx, func(tx *sqlx.Tx) error {
+		slots := []*dtoSlot{}
+		builder := selectSlot().Where("id = ? AND status = ?", reservationID, string(reservation.StatusPending))
+		if err := db.query(ctx, &slots, builder, tx.SelectContext); err != nil {
+			return errors.WithStack(err)
+		}
+		if len(slots) == 0 {
+			return exception.NotPending
+		}
+
+		update := sq.Update("reservation").
+			Set("status", string(status)).
+			Where("id = ?", reservationID)
+		if _, err := db.execWith(ctx, tx, update); err != nil {
+			return errors.WithStack(err)
+		}
+
+		promoted = []int64{}
+		if status != reservation.StatusRejected {
+			return nil
+		}
+		var err error
+		promoted, err = db.promoteSlots(ctx, tx, slots, now)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return promoted, nil
+}
+
+type dtoRoom struct {
+	ID               int64  `db:"id"`
+	Name             string `db:"name"`
+	Capacity         int    `db:"capacity"`
+	Building         string `db:"building"`
+	Floor            string `db:"floor"`
+	Policy           string `db:"policy"`
+	Group            string `db:"policy_group"`
+	RequiresApproval bool   `db:"requires_approval"`
+}
+
+// dtoSlot 은 예약의 회의실과 시간
+// selectSlot 은 예약의 id, 회의실, 시간만 조회
+func selectSlot() sq.SelectBuilder {
+	return sq.Select("id", "item_id", "start_time", "end_time").From("reservation")
+}
+
+type dtoSlot struct {
+	ID        int64     `db:"id"`
+	RoomID    int64     `db:"item_id"`
+	StartTime time.Time `db:"start_time"`
+	EndTime   time.Time `db:"end_time"`
+}
+
+type dtoEquipment struct {
+	RoomID    int64  `db:"item_id"`
+	Equipment string `db:"equipment"`
+}
+
+type dtoReservation struct {
+	ID        int64          `db:"id"`
+	RoomID    int64          `db:"room_id"`
+	RoomName  string         `db:"room_name"`
+	UserName  string         `db:"user_name"`
+	StartTime time.Time      `db:"start_time"`
+	EndTime   time.Time      `db:"end_time"`
+	Memo      sql.NullString `db:"memo"`
+
+	SeriesID    sql.NullInt64 `db:"series_id"`
+	Occurrence  sql.NullInt64 `db:"occurrence"`
+	IsException bool          `db:"is_exception"`
+	Status      string        `db:"status"`
+	CheckedInAt *time.Time    `db:"checked_in_at"`
+}
+
+func convertRoom(r *dtoRoom) *reservation.Room {
+	return &reservation.Room{
+		ID:               r.ID,
+		Name:             r.Name,
+		Capacity:         r.Capacity,
+		Building:         r.Building,
+		Floor:            r.Floor,
+		Policy:           reservation.Policy(r.Policy),
+		Group:            r.Group,
+		RequiresApproval: r.RequiresApproval,
+	}
+}
+
+func convertReservation(r *dtoReservation) *reservation.Detail {
+	if r == nil {
+		return nil
+	}
+
+	detail := &reservation.Detail{
+		ID: r.ID,
+		Room: reservation.Room{
+			ID:   r.RoomID,
+			Name: r.RoomName,
+		},
+		User:  r.UserName,
+		Start: r.StartTime, End: r.EndTime,
+		Memo:   r.Memo.String,
+		Status: reservation.Status(r.Status),
+
+		Occurrence: int(r.Occurrence.Int64),
+		Exception:  r.IsException,
+
+		CheckedInAt: r.CheckedInAt,
+	}
+	if r.SeriesID.Valid {
+		detail.SeriesID = &r.SeriesID.Int64
+	}
+	return detail
+}
@@ -0,0 +1,581 @@
+package sqlite
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/pkg/errors"
+	"github.com/rutesun/reservation/config"
+	"github.com/rutesun/reservation/exception"
+	"github.com/rutesun/reservation/migration"
+	"github.com/rutesun/reservation/reservation"
+	"github.com/rutesun/reservation/reservation/reservationtest"
+	"github.com/rutesun/reservation/webhook"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	ctx      = context.Background()
+	roomID   = int64(1)
+	userName = "Ted"
+)
+
+// newTestDB 는 임시 파일에 스키마와 회의실 하나를 가진 db 를 생성
+func newTestDB(t *testing.T) *db {
+	conn, err := sqlx.Open("sqlite3", "file:"+filepath.Join(t.TempDir(), "test.db")+"?_foreign_keys=1&_txlock=immediate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.SetMaxOpenConns(1)
+	t.Cleanup(func() { conn.Close() })
+
+	m, err := migration.New(conn, config.SQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	sqlite := New(conn, 0)
+	if _, err := conn.Exec("INSERT INTO reservation_item (name) VALUES (?)", "회의실A"); err != nil {
+		t.Fatal(err)
+	}
+	return sqlite
+}
+
+func TestDb_RoomList(t *testing.T) {
+	sqlite := newTestDB(t)
+
+	rooms, err := sqlite.RoomList(ctx, reservation.RoomFilter{})
+	assert.NoError(t, err)
+	assert.Len(t, rooms, 1)
+	assert.Equal(t, "회의실A", rooms[0].Name)
+}
+
+func TestDb_Make(t *testing.T) {
+	sqlite := newTestDB(t)
+
+	st, _ := time.Parse(time.RFC3339, "2018-08-04T18:00:00+09:00")
+	et, _ := time.Parse(time.RFC3339, "2018-08-04T19:00:00+09:00")
+
+	id, err := sqlite.Make(ctx, roomID, userName, st, et, "", reservation.StatusApproved)
+	assert.NoError(t, err)
+	assert.True(t, id > 0)
+
+	check, err := sqlite.Available(ctx, roomID, st, et)
+	assert.NoError(t, err)
+	assert.False(t, check)
+
+	_, err = sqlite.Make(ctx, roomID, userName, st.Add(30*time.Minute), et, "", reservation.StatusApproved)
+	assert.EqualError(t, err, exception.Unavailable.Error())
+
+	// 끝나는 시간에 바로 이어서 시작하는 예약은 가능
+	_, err = sqlite.Make(ctx, roomID, userName, et, et.Add(time.Hour), "", reservation.StatusApproved)
+	assert.NoError(t, err)
+
+	list, err := sqlite.List(ctx, st.Add(-time.Hour), et.Add(30*time.Minute))
+	assert.NoError(t, err)
+	assert.Len(t, list, 1)
+	assert.True(t, st.Equal(list[0].Start))
+	assert.Equal(t, "회의실A", list[0].Room.Name)
+}
+
+// weekly 는 st, et 부터 매주 같은 시간으로 n 번 반복하는 예약 시간
+func weekly(st, et time.Time, n int) []reservation.Slot {
+	slots := make([]reservation.Slot, n)
+	for i := range slots {
+		slots[i] = reservation.Slot{Start: st.AddDate(0, 0, 7*i), End: et.AddDate(0, 0, 7*i), Occurrence: i + 1}
+	}
+	return slots
+}
+
+func TestDb_MakeSeries(t *testing.T) {
+	sqlite := newTestDB(t)
+
+	st, _ := time.Parse(time.RFC3339, "2018-08-05T16:00:00+09:00")
+	et, _ := time.Parse(time.RFC3339, "2018-08-05T19:00:00+09:00")
+
+	_, err := sqlite.Make(ctx, roomID, userName, st.AddDate(0, 0, 21), et.AddDate(0, 0, 21), "", reservation.StatusApproved)
+	assert.NoError(t, err)
+
+	_, err = sqlite.MakeSeries(ctx, roomID, userName, "FREQ=WEEKLY", weekly(st, et, 5), "", reservation.StatusApproved)
+	assert.EqualError(t, err, exception.Unavailable.Error())
+
+	check, err := sqlite.Available(ctx, roomID, st, et)
+	assert.NoError(t, err)
+	assert.True(t, check, "실패한 반복 예약은 rollback 되어야 함")
+
+	seriesID, err := sqlite.MakeSeries(ctx, roomID, userName, "FREQ=WEEKLY;COUNT=3", weekly(st, et, 3), "주간회의", reservation.StatusApproved)
+	assert.NoError(t, err)
+
+	series, err := sqlite.FindSeries(ctx, seriesID)
+	assert.NoError(t, err)
+	assert.Equal(t, "FREQ=WEEKLY;COUNT=3", series.Rule)
+	assert.Equal(t, "회의실A", series.Room.Name)
+	assert.Len(t, series.Occurrences, 3)
+	for i, o := range series.Occurrences {
+		assert.Equal(t, i+1, o.Occurrence)
+		assert.Equal(t, seriesID, *o.SeriesID)
+		assert.Equal(t, "주간회의", o.Memo)
+		assert.False(t, o.Exception)
+	}
+
+	t.Run("한 회차만 변경하면 예외 회차", func(t *testing.T) {
+		second := series.Occurrences[1]
+		err := sqlite.Modify(ctx, second.ID, roomID, userName, second.Start.Add(time.Hour), second.End.Add(time.Hour), "시간 변경", reservation.StatusApproved)
+		assert.NoError(t, err)
+
+		detail, err := sqlite.Find(ctx, second.ID)
+		assert.NoError(t, err)
+		assert.True(t, detail.Exception)
+		assert.Equal(t, 2, detail.Occurrence)
+	})
+
+	t.Run("이후 회차 취소", func(t *testing.T) {
+		third := series.Occurrences[2]
+		_, err := sqlite.JoinWaitlist(ctx, &reservation.Waitlist{
+			Room: reservation.Room{ID: roomID}, User: "Amy", Start: third.Start, End: third.End,
+			Status: reservation.StatusApproved, CreatedAt: st,
+		})
+		assert.NoError(t, err)
+
+		canceled, promoted, err := sqlite.CancelSeries(ctx, seriesID, 2, st)
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{series.Occurrences[1].ID, third.ID}, canceled)
+		if assert.Len(t, promoted, 1, "취소된 회차의 시간은 대기를 예약") {
+			amy, err := sqlite.Find(ctx, promoted[0])
+			assert.NoError(t, err)
+			assert.Equal(t, "Amy", amy.User)
+			assert.True(t, third.Start.Equal(amy.Start))
+		}
+
+		series, err := sqlite.FindSeries(ctx, seriesID)
+		assert.NoError(t, err)
+		assert.Len(t, series.Occurrences, 1)
+
+		detail, err := sqlite.Find(ctx, canceled[0])
+		assert.NoError(t, err)
+		assert.Equal(t, reservation.StatusCancelled, detail.Status)
+	})
+
+	t.Run("시작한 회차는 남기고 전체 취소", func(t *testing.T) {
+		canceled, _, err := sqlite.CancelSeries(ctx, seriesID, 1, st.Add(time.Hour))
+		assert.NoError(t, err)
+		assert.Empty(t, canceled)
+
+		canceled, promoted, err := sqlite.CancelSeries(ctx, seriesID, 1, st)
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{series.Occurrences[0].ID}, canceled)
+		assert.Empty(t, promoted)
+
+		series, err := sqlite.FindSeries(ctx, seriesID)
+		assert.NoError(t, err, "반복 예약은 남음")
+		assert.Empty(t, series.Occurrences)
+	})
+}
+
+func TestDb_Cancel(t *testing.T) {
+	sqlite := newTestDB(t)
+
+	st, _ := time.Parse(time.RFC3339, "2018-08-07T10:00:00+09:00")
+	et, _ := time.Parse(time.RFC3339, "2018-08-07T12:00:00+09:00")
+
+	id, err := sqlite.Make(ctx, roomID, userName, st, et, "", reservation.StatusApproved)
+	assert.NoError(t, err)
+
+	_, err = sqlite.Cancel(ctx, id, st)
+	assert.NoError(t, err)
+
+	check, err := sqlite.Available(ctx, roomID, st, et)
+	assert.NoError(t, err)
+	assert.True(t, check)
+
+	_, err = sqlite.Cancel(ctx, id, st)
+	assert.Equal(t, exception.NotFound, errors.Cause(err))
+
+	detail, err := sqlite.Find(ctx, id)
+	assert.NoError(t, err)
+	assert.Equal(t, reservation.StatusCancelled, detail.Status)
+
+	t.Run("지난 시간의 대기는 예약하지 않음", func(t *testing.T) {
+		id, err := sqlite.Make(ctx, roomID, userName, st.AddDate(0, 0, 1), et.AddDate(0, 0, 1), "", reservation.StatusApproved)
+		assert.NoError(t, err)
+		_, err = sqlite.JoinWaitlist(ctx, &reservation.Waitlist{
+			Room: reservation.Room{ID: roomID}, User: "Amy", Start: st.AddDate(0, 0, 1), End: et.AddDate(0, 0, 1),
+			Status: reservation.StatusApproved, CreatedAt: st,
+		})
+		assert.NoError(t, err)
+
+		promoted, err := sqlite.Cancel(ctx, id, et.AddDate(0, 0, 1))
+		assert.NoError(t, err)
+		assert.Empty(t, promoted)
+	})
+
+	_, err = sqlite.Make(ctx, roomID, userName, st, et, "", reservation.StatusApproved)
+	assert.NoError(t, err, "취소된 예약과 같은 시간에 다시 예약")
+}
+
+func TestDb_Review(t *testing.T) {
+	sqlite := newTestDB(t)
+
+	st, _ := time.Parse(time.RFC3339, "2018-08-07T10:00:00+09:00")
+	et, _ := time.Parse(time.RFC3339, "2018-08-07T12:00:00+09:00")
+
+	id, err := sqlite.Make(ctx, roomID, userName, st, et, "", reservation.StatusPending)
+	assert.NoError(t, err)
+
+	check, err := sqlite.Available(ctx, roomID, st, et)
+	assert.NoError(t, err)
+	assert.False(t, check, "승인 대기 중인 예약도 시간을 차지")
+
+	_, err = sqlite.JoinWaitlist(ctx, &reservation.Waitlist{
+		Room: reservation.Room{ID: roomID}, User: "Amy", Start: st, End: et,
+		Status: reservation.StatusApproved, CreatedAt: st.AddDate(0, 0, -1),
+	})
+	assert.NoError(t, err)
+
+	promoted, err := sqlite.Review(ctx, id, reservation.StatusRejected, st)
+	assert.NoError(t, err)
+	assert.Len(t, promoted, 1, "거절된 시간은 대기를 예약")
+	_, err = sqlite.Review(ctx, id, reservation.StatusApproved, st)
+	assert.Equal(t, exception.NotPending, err)
+
+	detail, err := sqlite.Find(ctx, id)
+	assert.NoError(t, err)
+	assert.Equal(t, reservation.StatusRejected, detail.Status)
+
+	list, err := sqlite.List(ctx, st.AddDate(0, 0, -1), st.AddDate(0, 0, 1))
+	assert.NoError(t, err)
+	if assert.Len(t, list, 1, "거절된 예약은 목록에서 제외") {
+		assert.Equal(t, "Amy", list[0].User)
+	}
+
+	err = sqlite.Modify(ctx, id, roomID, userName, st, et, "", reservation.StatusPending)
+	assert.Equal(t, exception.NotFound, errors.Cause(err))
+}
+
+func TestDb_Waitlist(t *testing.T) {
+	sqlite := newTestDB(t)
+
+	st, _ := time.Parse(time.RFC3339, "2018-08-07T10:00:00+09:00")
+	et, _ := time.Parse(time.RFC3339, "2018-08-07T12:00:00+09:00")
+
+	id, err := sqlite.Make(ctx, roomID, userName, st, et, "", reservation.StatusApproved)
+	assert.NoError(t, err)
+
+	join := func(user string, start, end time.Time, status reservation.Status) int64 {
+		id, err := sqlite.JoinWaitlist(ctx, &reservation.Waitlist{
+			Room: reservation.Room{ID: roomID}, User: user, Start: start, End: end,
+			Memo: user, Status: status, CreatedAt: st.AddDate(0, 0, -1),
+		})
+		assert.NoError(t, err)
+		return id
+	}
+	join("Amy", st, st.Add(time.Hour), reservation.StatusPending)
+	bob := join("Bob", st, et, reservation.StatusApproved)
+	join("Carl", st.Add(time.Hour), et, reservation.StatusApproved)
+	dan := join("Dan", et.Add(time.Hour), et.Add(2*time.Hour), reservation.StatusApproved)
+
+	w, err := sqlite.FindWaitlist(ctx, bob)
+	assert.NoError(t, err)
+	assert.Equal(t, "회의실A", w.Room.Name)
+	assert.True(t, st.Equal(w.Start))
+
+	// 먼저 들어온 Amy 가 예약된 뒤 Bob 은 들어갈 수 없고 Carl 은 남은 시간에 예약됨
+	promoted, err := sqlite.Cancel(ctx, id, st)
+	assert.NoError(t, err)
+	if assert.Len(t, promoted, 2) {
+		amy, err := sqlite.Find(ctx, promoted[0])
+		assert.NoError(t, err)
+		assert.Equal(t, "Amy", amy.User)
+		assert.Equal(t, "Amy", amy.Memo)
+		assert.Equal(t, reservation.StatusPending, amy.Status)
+
+		carl, err := sqlite.Find(ctx, promoted[1])
+		assert.NoError(t, err)
+		assert.Equal(t, "Carl", carl.User)
+		assert.True(t, et.Equal(carl.End))
+	}
+
+	list, err := sqlite.ListWaitlist(ctx, "Amy")
+	assert.NoError(t, err)
+	assert.Empty(t, list)
+	list, err = sqlite.ListWaitlist(ctx, "Dan")
+	assert.NoError(t, err)
+	if assert.Len(t, list, 1) {
+		assert.Equal(t, dan, list[0].ID)
+	}
+
+	assert.NoError(t, sqlite.LeaveWaitlist(ctx, bob))
+	assert.Equal(t, exception.WaitlistNotFound, sqlite.LeaveWaitlist(ctx, bob))
+	_, err = sqlite.FindWaitlist(ctx, bob)
+	assert.Equal(t, exception.WaitlistNotFound, errors.Cause(err))
+}
+
+func TestDb_NoShow(t *testing.T) {
+	sqlite := newTestDB(t)
+
+	at := func(hour int) time.Time {
+		return time.Date(2018, 8, 7, hour, 0, 0, 0, time.FixedZone("KST", 9*60*60))
+	}
+	started, err := sqlite.Make(ctx, roomID, userName, at(10), at(11), "", reservation.StatusApproved)
+	assert.NoError(t, err)
+	ended, err := sqlite.Make(ctx, roomID, userName, at(9), at(10), "", reservation.StatusApproved)
+	assert.NoError(t, err)
+	checkedIn, err := sqlite.Make(ctx, roomID, userName, at(11), at(12), "", reservation.StatusApproved)
+	assert.NoError(t, err)
+
+	assert.NoError(t, sqlite.CheckIn(ctx, checkedIn, at(10).Add(50*time.Minute)))
+	detail, err := sqlite.Find(ctx, checkedIn)
+	assert.NoError(t, err)
+	if assert.NotNil(t, detail.CheckedInAt) {
+		assert.True(t, at(10).Add(50*time.Minute).Equal(*detail.CheckedInAt))
+	}
+
+	// 먼저 들어왔지만 이미 시작한 Bob 의 대기는 건너뛰고 남은 시간에 시작하는 Amy 의 대기만 예약
+	for _, w := range []reservation.Waitlist{
+		{Room: reservation.Room{ID: roomID}, User: "Bob", Start: at(10), End: at(11), Status: reservation.StatusApproved, CreatedAt: at(9)},
+		{Room: reservation.Room{ID: roomID}, User: "Amy", Start: at(10).Add(30 * time.Minute), End: at(11), Status: reservation.StatusApproved, CreatedAt: at(9)},
+	} {
+		w := w
+		_, err = sqlite.JoinWaitlist(ctx, &w)
+		assert.NoError(t, err)
+	}
+
+	// 이미 끝난 예약과 체크인한 예약은 그대로 둠
+	released, promoted, err := sqlite.ReleaseNoShows(ctx, at(10).Add(5*time.Minute), at(10).Add(20*time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{started}, released)
+	if assert.Len(t, promoted, 1) {
+		detail, err := sqlite.Find(ctx, promoted[0])
+		assert.NoError(t, err)
+		assert.Equal(t, "Amy", detail.User)
+	}
+	released, promoted, err = sqlite.ReleaseNoShows(ctx, at(11).Add(5*time.Minute), at(11).Add(20*time.Minute))
+	assert.NoError(t, err)
+	assert.Empty(t, released)
+	assert.Empty(t, promoted)
+
+	detail, err = sqlite.Find(ctx, started)
+	assert.NoError(t, err)
+	assert.Equal(t, reservation.StatusNoShow, detail.Status)
+	detail, err = sqlite.Find(ctx, ended)
+	assert.NoError(t, err)
+	assert.Equal(t, reservation.StatusApproved, detail.Status)
+
+	check, err := sqlite.Available(ctx, roomID, at(10), at(10).Add(30*time.Minute))
+	assert.NoError(t, err)
+	assert.True(t, check, "no_show 인 예약의 시간은 다시 예약 가능")
+	assert.Equal(t, exception.NotFound, sqlite.CheckIn(ctx, started, at(10).Add(30*time.Minute)))
+
+	counts, err := sqlite.CountNoShows(ctx, at(0), at(24))
+	assert.NoError(t, err)
+	assert.Equal(t, []reservation.NoShowCount{{User: userName, Count: 1}}, counts)
+}
+
+func TestDb_MakeConcurrently(t *testing.T) {
+	sqlite := newTestDB(t)
+
+	st, _ := time.Parse(time.RFC3339, "2018-08-08T10:00:00+09:00")
+	et, _ := time.Parse(time.RFC3339, "2018-08-08T12:00:00+09:00")
+
+	reservationtest.MakeConcurrently(t, sqlite, roomID, st, et, 30)
+}
+
+func TestDb_Modify(t *testing.T) {
+	sqlite := newTestDB(t)
+
+	st, _ := time.Parse(time.RFC3339, "2018-08-07T10:00:00+09:00")
+	et, _ := time.Parse(time.RFC3339, "2018-08-07T12:00:00+09:00")
+
+	id, err := sqlite.Make(ctx, roomID, userName, st, et, "", reservation.StatusApproved)
+	assert.NoError(t, err)
+	_, err = sqlite.Make(ctx, roomID, userName, et.Add(time.Hour), et.Add(2*time.Hour), "", reservation.StatusApproved)
+	assert.NoError(t, err)
+
+	// 자기 자신과의 겹침은 무시
+	err = sqlite.Modify(ctx, id, roomID, "Ryan", st.Add(time.Hour), et.Add(time.Hour), "memo", reservation.StatusApproved)
+	assert.NoError(t, err)
+
+	detail, err := sqlite.Find(ctx, id)
+	assert.NoError(t, err)
+	assert.Equal(t, "Ryan", detail.User)
+	assert.Equal(t, "memo", detail.Memo)
+	assert.True(t, st.Add(time.Hour).Equal(detail.Start))
+
+	err = sqlite.Modify(ctx, id, roomID, userName, st.Add(2*time.Hour), et.Add(2*time.Hour), "", reservation.StatusApproved)
+	assert.EqualError(t, err, exception.Unavailable.Error())
+
+	err = sqlite.Modify(ctx, id+100, roomID, userName, st, et, "", reservation.StatusApproved)
+	assert.EqualError(t, err, exception.NotFound.Error())
+
+	_, err = sqlite.Find(ctx, id+100)
+	assert.EqualError(t, err, exception.NotFound.Error())
+}
+
+func TestDb_ArchiveRoom(t *testing.T) {
+	sqlite := newTestDB(t)
+
+	now, _ := time.Parse(time.RFC3339, "2018-08-07T12:00:00+09:00")
+
+	_, err := sqlite.Make(ctx, roomID, userName, now.Add(-2*time.Hour), now.Add(-time.Hour), "", reservation.StatusApproved)
+	assert.NoError(t, err)
+	upcoming, err := sqlite.Make(ctx, roomID, userName, now.Add(time.Hour), now.Add(2*time.Hour), "", reservation.StatusApproved)
+	assert.NoError(t, err)
+	_, err = sqlite.JoinWaitlist(ctx, &reservation.Waitlist{
+		Room: reservation.Room{ID: roomID}, User: "Amy", Start: now.Add(time.Hour), End: now.Add(2 * time.Hour),
+		Status: reservation.StatusApproved, CreatedAt: now,
+	})
+	assert.NoError(t, err)
+
+	_, err = sqlite.ArchiveRoom(ctx, roomID, now, false)
+	assert.EqualError(t, err, exception.RoomInUse.Error())
+
+	canceled, err := sqlite.ArchiveRoom(ctx, roomID, now, true)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{upcoming}, canceled)
+
+	waiting, err := sqlite.ListWaitlist(ctx, "Amy")
+	assert.NoError(t, err)
+	assert.Empty(t, waiting)
+
+	rooms, err := sqlite.RoomList(ctx, reservation.RoomFilter{})
+	assert.NoError(t, err)
+	assert.Empty(t, rooms)
+
+	list, err := sqlite.List(ctx, now.AddDate(0, 0, -1), now.AddDate(0, 0, 1))
+	assert.NoError(t, err)
+	assert.Len(t, list, 1)
+	assert.Equal(t, "회의실A", list[0].Room.Name)
+
+	_, err = sqlite.Make(ctx, roomID, userName, now.Add(3*time.Hour), now.Add(4*time.Hour), "", reservation.StatusApproved)
+	assert.EqualError(t, err, exception.RoomNotFound.Error())
+
+	err = sqlite.UpdateRoom(ctx, &reservation.Room{ID: roomID, Name: "회의실B"})
+	assert.EqualError(t, err, exception.RoomNotFound.Error())
+}
+
+func TestDb_RoomFilter(t *testing.T) {
+	sqlite := newTestDB(t)
+
+	large, err := sqlite.CreateRoom(ctx, &reservation.Room{
+		Name: "대회의실", Capacity: 12, Building: "본관", Floor: "3",
+		Equipment: []string{"screen", "vc"},
+	})
+	assert.NoError(t, err)
+	small, err := sqlite.CreateRoom(ctx, &reservation.Room{
+		Name: "소회의실", Capacity: 4, Equipment: []string{"whiteboard"},
+	})
+	assert.NoError(t, err)
+
+	rooms, err := sqlite.RoomList(ctx, reservation.RoomFilter{})
+	assert.NoError(t, err)
+	assert.Len(t, rooms, 3)
+	assert.Equal(t, &reservation.Room{
+		ID: large, Name: "대회의실", Capacity: 12, Building: "본관", Floor: "3",
+		Equipment: []string{"screen", "vc"},
+	}, rooms[1])
+
+	rooms, err = sqlite.RoomList(ctx, reservation.RoomFilter{MinCapacity: 8})
+	assert.NoError(t, err)
+	assert.Len(t, rooms, 1)
+	assert.Equal(t, large, rooms[0].ID)
+
+	rooms, err = sqlite.RoomList(ctx, reservation.RoomFilter{Equipment: []string{"vc", "whiteboard"}})
+	assert.NoError(t, err)
+	assert.Empty(t, rooms)
+
+	err = sqlite.UpdateRoom(ctx, &reservation.Room{ID: small, Name: "소회의실", Capacity: 4, Equipment: []string{"vc", "whiteboard"}})
+	assert.NoError(t, err)
+
+	rooms, err = sqlite.RoomList(ctx, reservation.RoomFilter{Equipment: []string{"vc", "whiteboard"}})
+	assert.NoError(t, err)
+	assert.Len(t, rooms, 1)
+	assert.Equal(t, small, rooms[0].ID)
+	assert.Equal(t, []string{"vc", "whiteboard"}, rooms[0].Equipment)
+}
+
+func TestDb_Webhook(t *testing.T) {
+	sqlite := newTestDB(t)
+
+	now, _ := time.Parse(time.RFC3339, "2018-08-07T10:00:00+09:00")
+	missing := int64(999)
+	_, err := sqlite.CreateSubscription(ctx, &webhook.Subscription{URL: "http://localhost/hook", Secret: "s", RoomID: &missing, CreatedAt: now})
+	assert.Equal(t, exception.RoomNotFound, errors.Cause(err))
+
+	id, err := sqlite.CreateSubscription(ctx, &webhook.Subscription{
+		URL: "http://localhost/hook", Secret: "s", RoomID: &roomID, CreatedBy: "admin", CreatedAt: now,
+		Events: []reservation.EventType{reservation.EventCancelled, reservation.EventCreated},
+	})
+	assert.NoError(t, err)
+	all, err := sqlite.CreateSubscription(ctx, &webhook.Subscription{URL: "http://localhost/all", Secret: "s", CreatedAt: now})
+	assert.NoError(t, err)
+
+	list, err := sqlite.ListSubscriptions(ctx)
+	assert.NoError(t, err)
+	if assert.Len(t, list, 2) {
+		assert.Equal(t, []reservation.EventType{reservation.EventCancelled, reservation.EventCreated}, list[0].Events)
+		assert.Equal(t, roomID, *list[0].RoomID)
+		assert.Empty(t, list[1].Events)
+		assert.Nil(t, list[1].RoomID)
+	}
+
+	deliver := func(subscriptionID int64, next time.Time) int64 {
+		id, err := sqlite.CreateDelivery(ctx, &webhook.Delivery{
+			SubscriptionID: subscriptionID, EventType: reservation.EventCreated, Payload: []byte(`{"type":"reservation.created"}`),
+			Status: webhook.DeliveryPending, NextAttemptAt: &next, CreatedAt: now,
+		})
+		assert.NoError(t, err)
+		return id
+	}
+	later := deliver(id, now.Add(time.Minute))
+	first := deliver(id, now)
+	deliver(all, now)
+
+	due, err := sqlite.DueDeliveries(ctx, now, 10)
+	assert.NoError(t, err)
+	if assert.Len(t, due, 2) {
+		assert.Equal(t, first, due[0].ID)
+		assert.JSONEq(t, `{"type":"reservation.created"}`, string(due[0].Payload))
+	}
+
+	// 실패하여 다시 보낼 시간을 미루면 아직 보낼 때가 아님
+	next := now.Add(time.Hour)
+	due[0].Attempts, due[0].ResponseStatus, due[0].LastError, due[0].NextAttemptAt = 1, 500, "500 로 응답했습니다", &next
+	assert.NoError(t, sqlite.UpdateDelivery(ctx, due[0]))
+	due, err = sqlite.DueDeliveries(ctx, now.Add(time.Minute), 10)
+	assert.NoError(t, err)
+	assert.Len(t, due, 2)
+
+	d, err := sqlite.FindDelivery(ctx, first)
+	assert.NoError(t, err)
+	assert.Equal(t, 500, d.ResponseStatus)
+	assert.True(t, next.Equal(*d.NextAttemptAt))
+
+	d.Status, d.Attempts, d.ResponseStatus, d.LastError, d.NextAttemptAt, d.DeliveredAt = webhook.DeliverySucceeded, 2, 200, "", nil, &next
+	assert.NoError(t, sqlite.UpdateDelivery(ctx, d))
+	succeeded, err := sqlite.ListDeliveries(ctx, id, webhook.DeliverySucceeded)
+	assert.NoError(t, err)
+	if assert.Len(t, succeeded, 1) {
+		assert.Equal(t, first, succeeded[0].ID)
+		assert.Nil(t, succeeded[0].NextAttemptAt)
+	}
+	deliveries, err := sqlite.ListDeliveries(ctx, id, "")
+	assert.NoError(t, err)
+	if assert.Len(t, deliveries, 2) {
+		assert.Equal(t, first, deliveries[0].ID, "최근 전달부터")
+		assert.Equal(t, later, deliveries[1].ID)
+	}
+
+	assert.NoError(t, sqlite.DeleteSubscription(ctx, id))
+	assert.Equal(t, exception.WebhookNotFound, sqlite.DeleteSubscription(ctx, id))
+	_, err = sqlite.FindDelivery(ctx, first)
+	assert.Equal(t, exception.DeliveryNotFound, err)
+	_, err = sqlite.FindSubscription(ctx, id)
+	assert.Equal(t, exception.WebhookNotFound, err)
+}
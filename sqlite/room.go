@@ -0,0 +1,165 @@
+package sqlite
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"github.com/rutesun/reservation/exception"
+	"github.com/rutesun/reservation/reservation"
+	sq "gopkg.in/Masterminds/squirrel.v1"
+)
+
+func (db *db) CreateRoom(ctx context.Context, room *reservation.Room) (int64, error) {
+	var id int64
+	err := db.transaction(ctx, func(tx *sqlx.Tx) error {
+		builder := sq.Insert("reservation_item").
+			Columns("item_type", "name", "capacity", "building", "floor", "policy", "policy_group", "requires_approval").
+			Values("MEETING", room.Name, room.Capacity, room.Building, room.Floor, string(room.Policy), room.Group, room.RequiresApproval)
+
+		res, err := db.execWith(ctx, tx, builder)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if id, err = res.LastInsertId(); err != nil {
+			return errors.WithStack(err)
+		}
+		return db.replaceEquipment(ctx, tx, id, room.Equipment)
+	})
+	return id, err
+}
+
+func (db *db) UpdateRoom(ctx context.Context, room *reservation.Room) error {
+	return db.transaction(ctx, func(tx *sqlx.Tx) error {
+		if err := db.findRoom(ctx, tx, room.ID); err != nil {
+			return err
+		}
+
+		builder := sq.Update("reservation_item").
+			SetMap(map[string]interface{}{
+				"name":              room.Name,
+				"capacity":          room.Capacity,
+				"building":          room.Building,
+				"floor":             room.Floor,
+				"policy":            string(room.Policy),
+				"policy_group":      room.Group,
+				"requires_approval": room.RequiresApproval,
+			}).
+			Where("id = ?", room.ID)
+
+		if _, err := db.execWith(ctx, tx, builder); err != nil {
+			return errors.WithStack(err)
+		}
+		return db.replaceEquipment(ctx, tx, room.ID, room.Equipment)
+	})
+}
+
+// ArchiveRoom 은 쓰기 lock 을 잡은 transaction 안에서 처리하므로 보관하는 동안 새 예약이 생기지 않음
+func (db *db) ArchiveRoom(ctx context.Context, roomID int64, now time.Time, cascade bool) ([]int64, error) {
+	canceled := []int64{}
+	err := db.transaction(ctx, func(tx *sqlx.Tx) error {
+		if err := db.findRoom(ctx, tx, roomID); err != nil {
+			return err
+		}
+
+		builder := sq.Select("id").
+			From("reservation").
+			Where("item_id = ? AND start_time >= ? AND "+active, roomID, utc(now))
+		if err := db.query(ctx, &canceled, builder, tx.SelectContext); err != nil {
+			return errors.WithStack(err)
+		}
+
+		if len(canceled) > 0 {
+			if !cascade {
+				return exception.RoomInUse
+			}
+
+			_, err := db.execWith(ctx, tx, sq.Update("reservation").
+				Set("status", string(reservation.StatusCancelled)).
+				Where(sq.Eq{"id": canceled}))
+			if err != nil {
+				return errors.WithStack(err)
+			}
+		}
+
+		// 보관된 회의실의 대기는 예약될 수 없으므로 지움
+		if _, err := db.execWith(ctx, tx, sq.Delete("waitlist").Where("item_id = ?", roomID)); err != nil {
+			return errors.WithStack(err)
+		}
+
+		_, err := db.execWith(ctx, tx, sq.Update("reservation_item").
+			Set("archived_at", utc(now)).
+			Where("id = ?", roomID))
+		return errors.WithStack(err)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return canceled, nil
+}
+
+// filterRoom 은 조건에 맞는 회의실만 조회하도록 builder 에 조건을 추가
+// 장비는 요청한 장비를 모두 갖춘 회의실만 남도록 일치하는 장비 수를 비교
+func filterRoom(builder sq.SelectBuilder, filter reservation.RoomFilter) sq.SelectBuilder {
+	if filter.MinCapacity > 0 {
+		builder = builder.Where("r.capacity >= ?", filter.MinCapacity)
+	}
+	if len(filter.Equipment) > 0 {
+		args := make([]interface{}, 0, len(filter.Equipment)+1)
+		for _, e := range filter.Equipment {
+			args = append(args, e)
+		}
+		args = append(args, len(filter.Equipment))
+		builder = builder.Where("(SELECT count(*) FROM reservation_item_equipment AS e "+
+			"WHERE e.item_id = r.id AND e.equipment IN ("+sq.Placeholders(len(filter.Equipment))+")) = ?", args...)
+	}
+	return builder
+}
+
+// loadEquipment 는 회의실 목록의 장비를 한번에 조회하여 채움
+func (db *db) loadEquipment(ctx context.Context, rooms []*reservation.Room) error {
+	if len(rooms) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, len(rooms))
+	byID := make(map[int64]*reservation.Room, len(rooms))
+	for i, r := range rooms {
+		ids[i] = r.ID
+		byID[r.ID] = r
+	}
+
+	eList := []*dtoEquipment{}
+	builder := sq.Select("item_id", "equipment").
+		From("reservation_item_equipment").
+		Where(sq.Eq{"item_id": ids}).
+		OrderBy("item_id", "equipment")
+	if err := db.Select(ctx, &eList, builder); err != nil {
+		return errors.WithStack(err)
+	}
+
+	for _, e := range eList {
+		if r, ok := byID[e.RoomID]; ok {
+			r.Equipment = append(r.Equipment, e.Equipment)
+		}
+	}
+	return nil
+}
+
+// replaceEquipment 는 회의실의 장비 목록을 주어진 목록으로 교체
+func (db *db) replaceEquipment(ctx context.Context, tx *sqlx.Tx, roomID int64, equipment []string) error {
+	if _, err := db.execWith(ctx, tx, sq.Delete("reservation_item_equipment").Where("item_id = ?", roomID)); err != nil {
+		return errors.WithStack(err)
+	}
+	if len(equipment) == 0 {
+		return nil
+	}
+
+	builder := sq.Insert("reservation_item_equipment").Columns("item_id", "equipment")
+	for _, e := range equipment {
+		builder = builder.Values(roomID, e)
+	}
+	_, err := db.execWith(ctx, tx, builder)
+	return errors.WithStack(err)
+}
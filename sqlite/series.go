@@ -0,0 +1,130 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"github.com/rutesun/reservation/exception"
+	"github.com/rutesun/reservation/reservation"
+	sq "gopkg.in/Masterminds/squirrel.v1"
+)
+
+// createSeries 는 반복 예약의 규칙과 공통 정보를 저장
+func (db *db) createSeries(ctx context.Context, tx *sqlx.Tx, roomID int64, userName, rule, memo string) (int64, error) {
+	builder := sq.Insert("reservation_series").
+		Columns("item_id", "user_name", "rule", "memo").
+		Values(roomID, userName, rule, memo)
+
+	res, err := db.execWith(ctx, tx, builder)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	return res.LastInsertId()
+}
+
+func (db *db) FindSeries(ctx context.Context, seriesID int64) (*reservation.Series, error) {
+	dto := dtoSeries{}
+
+	builder := sq.Select(
+		"s.id",
+		"ri.id AS room_id",
+		"ri.name AS room_name",
+		"s.user_name",
+		"s.rule",
+		"s.memo",
+	).
+		From("reservation_series AS s").
+		Join("reservation_item AS ri ON s.item_id = ri.id").
+		Where("s.id = ?", seriesID)
+
+	if err := db.Get(ctx, &dto, builder); err == sql.ErrNoRows {
+		return nil, exception.SeriesNotFound
+	} else if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	reservations := []*dtoReservation{}
+	occurrences := selectReservation().
+		Where("r.series_id = ? AND r."+active, seriesID).
+		OrderBy("r.occurrence")
+	if err := db.Select(ctx, &reservations, occurrences); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	series := convertSeries(&dto)
+	series.Occurrences = make([]*reservation.Detail, len(reservations))
+	for i, r := range reservations {
+		series.Occurrences[i] = convertReservation(r)
+	}
+	return series, nil
+}
+
+// CancelSeries 는 from 번째 회차부터 아직 시작하지 않은 회차를 취소(StatusCancelled) 하고 회차 순서로 id 를 반환
+// 반복 예약과 지난 회차는 그대로 남으며 같은 transaction 에서 회차마다 대기를 예약
+func (db *db) CancelSeries(ctx context.Context, seriesID int64, from int, now time.Time) ([]int64, []int64, error) {
+	canceled, promoted := []int64{}, []int64{}
+	err := db.transaction(ctx, func(tx *sqlx.Tx) error {
+		var id int64
+		builder := sq.Select("id").
+			From("reservation_series").
+			Where("id = ?", seriesID)
+		if err := db.getWith(ctx, tx, &id, builder); err == sql.ErrNoRows {
+			return exception.SeriesNotFound
+		} else if err != nil {
+			return errors.WithStack(err)
+		}
+
+		slots := []*dtoSlot{}
+		occurrences := selectSlot().
+			Where("series_id = ? AND occurrence >= ? AND start_time >= ? AND "+active, seriesID, from, utc(now)).
+			OrderBy("occurrence")
+		if err := db.query(ctx, &slots, occurrences, tx.SelectContext); err != nil {
+			return errors.WithStack(err)
+		}
+		if len(slots) == 0 {
+			return nil
+		}
+		for _, slot := range slots {
+			canceled = append(canceled, slot.ID)
+		}
+
+		_, err := db.execWith(ctx, tx, sq.Update("reservation").
+			Set("status", string(reservation.StatusCancelled)).
+			Where(sq.Eq{"id": canceled}))
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		promoted, err = db.promoteSlots(ctx, tx, slots, now)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return canceled, promoted, nil
+}
+
+type dtoSeries struct {
+	ID       int64          `db:"id"`
+	RoomID   int64          `db:"room_id"`
+	RoomName string         `db:"room_name"`
+	UserName string         `db:"user_name"`
+	Rule     string         `db:"rule"`
+	Memo     sql.NullString `db:"memo"`
+}
+
+func convertSeries(s *dtoSeries) *reservation.Series {
+	return &reservation.Series{
+		ID: s.ID,
+		Room: reservation.Room{
+			ID:   s.RoomID,
+			Name: s.RoomName,
+		},
+		User: s.UserName,
+		Rule: s.Rule,
+		Memo: s.Memo.String,
+	}
+}
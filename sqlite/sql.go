@@ -0,0 +1,99 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"github.com/rutesun/reservation/log"
+	"gopkg.in/Masterminds/squirrel.v1"
+)
+
+type queryFn func(context.Context, interface{}, string, ...interface{}) error
+
+// withTimeout 은 query 하나에 적용할 timeout 을 ctx 에 추가
+func (db *db) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if db.timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, db.timeout)
+}
+
+func (db *db) query(ctx context.Context, v interface{}, q squirrel.SelectBuilder, fn queryFn) error {
+	query, args, err := q.ToSql()
+	if err != nil {
+		return err
+	}
+
+	log.Debugf("query = %s\targs = %v", query, args)
+
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	return fn(ctx, v, query, args...)
+}
+
+// Query 는 호출하는 쪽에서 rows 를 읽어야 하므로 timeout 을 적용하지 않음
+func (db *db) Query(ctx context.Context, q squirrel.SelectBuilder) (*sqlx.Rows, error) {
+	query, args, err := q.ToSql()
+	if err != nil {
+		return nil, err
+	}
+	return db.DB.QueryxContext(ctx, query, args...)
+}
+
+func (db *db) Get(ctx context.Context, v interface{}, q squirrel.SelectBuilder) error {
+	return db.query(ctx, v, q, db.DB.GetContext)
+}
+
+func (db *db) Select(ctx context.Context, v interface{}, q squirrel.SelectBuilder) error {
+	return db.query(ctx, v, q, db.DB.SelectContext)
+}
+
+type toSql interface {
+	ToSql() (string, []interface{}, error)
+}
+
+func (db *db) Exec(ctx context.Context, q toSql) (sql.Result, error) {
+	return db.execWith(ctx, db.DB, q)
+}
+
+// getWith 는 db 혹은 transaction 으로 한 row 를 조회
+func (db *db) getWith(ctx context.Context, queryer sqlx.QueryerContext, v interface{}, q squirrel.SelectBuilder) error {
+	return db.query(ctx, v, q, func(ctx context.Context, v interface{}, query string, args ...interface{}) error {
+		return sqlx.GetContext(ctx, queryer, v, query, args...)
+	})
+}
+
+// execWith 는 db 혹은 transaction 으로 실행
+func (db *db) execWith(ctx context.Context, execer sqlx.ExecerContext, q toSql) (sql.Result, error) {
+	query, args, err := q.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	log.Debugf("query = %s\targs = %v", query, args)
+
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	return execer.ExecContext(ctx, query, args...)
+}
+
+// transaction 은 fn 이 error 를 반환하면 rollback, 아니면 commit
+// transaction 은 ctx 가 취소되면 rollback 되며 timeout 은 각 query 에만 적용
+func (db *db) transaction(ctx context.Context, fn func(tx *sqlx.Tx) error) error {
+	tx, err := db.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "Fail to begin transaction")
+	}
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.Wrap(err, "Fail to commit transaction")
+	}
+	return nil
+}
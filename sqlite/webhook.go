@@ -0,0 +1,277 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"github.com/rutesun/reservation/exception"
+	"github.com/rutesun/reservation/reservation"
+	"github.com/rutesun/reservation/webhook"
+	sq "gopkg.in/Masterminds/squirrel.v1"
+)
+
+// CreateSubscription 은 회의실을 지정하면 보관되지 않은 회의실인지 확인
+func (db *db) CreateSubscription(ctx context.Context, s *webhook.Subscription) (int64, error) {
+	var id int64
+	err := db.transaction(ctx, func(tx *sqlx.Tx) error {
+		if s.RoomID != nil {
+			if err := db.findRoom(ctx, tx, *s.RoomID); err != nil {
+				return err
+			}
+		}
+
+		builder := sq.Insert("webhook_subscription").
+			Columns("url", "secret", "item_id", "created_by", "created_at").
+			Values(s.URL, s.Secret, s.RoomID, s.CreatedBy, utc(s.CreatedAt))
+		res, err := db.execWith(ctx, tx, builder)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if id, err = res.LastInsertId(); err != nil {
+			return errors.WithStack(err)
+		}
+
+		if len(s.Events) == 0 {
+			return nil
+		}
+		insert := sq.Insert("webhook_subscription_event").Columns("subscription_id", "event_type")
+		for _, t := range s.Events {
+			insert = insert.Values(id, string(t))
+		}
+		_, err = db.execWith(ctx, tx, insert)
+		return errors.WithStack(err)
+	})
+	return id, err
+}
+
+func (db *db) FindSubscription(ctx context.Context, subscriptionID int64) (*webhook.Subscription, error) {
+	list, err := db.subscriptions(ctx, selectSubscription().Where("id = ?", subscriptionID))
+	if err != nil {
+		return nil, err
+	}
+	if len(list) == 0 {
+		return nil, exception.WebhookNotFound
+	}
+	return list[0], nil
+}
+
+func (db *db) ListSubscriptions(ctx context.Context) ([]*webhook.Subscription, error) {
+	return db.subscriptions(ctx, selectSubscription().OrderBy("id"))
+}
+
+// subscriptions 는 webhook 을 조회하고 각 webhook 의 Event 종류를 채움
+func (db *db) subscriptions(ctx context.Context, builder sq.SelectBuilder) ([]*webhook.Subscription, error) {
+	list := []*dtoSubscription{}
+	if err := db.Select(ctx, &list, builder); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if len(list) == 0 {
+		return []*webhook.Subscription{}, nil
+	}
+
+	ids := make([]int64, len(list))
+	for i, s := range list {
+		ids[i] = s.ID
+	}
+	events := []struct {
+		SubscriptionID int64  `db:"subscription_id"`
+		EventType      string `db:"event_type"`
+	}{}
+	eventBuilder := sq.Select("subscription_id", "event_type").
+		From("webhook_subscription_event").
+		Where(sq.Eq{"subscription_id": ids}).
+		OrderBy("subscription_id", "event_type")
+	if err := db.Select(ctx, &events, eventBuilder); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	byID := make(map[int64][]reservation.EventType)
+	for _, e := range events {
+		byID[e.SubscriptionID] = append(byID[e.SubscriptionID], reservation.EventType(e.EventType))
+	}
+
+	subscriptions := make([]*webhook.Subscription, len(list))
+	for i, s := range list {
+		subscriptions[i] = convertSubscription(s, byID[s.ID])
+	}
+	return subscriptions, nil
+}
+
+func (db *db) DeleteSubscription(ctx context.Context, subscriptionID int64) error {
+	return db.transaction(ctx, func(tx *sqlx.Tx) error {
+		if _, err := db.execWith(ctx, tx, sq.Delete("webhook_delivery").Where("subscription_id = ?", subscriptionID)); err != nil {
+			return errors.WithStack(err)
+		}
+		if _, err := db.execWith(ctx, tx, sq.Delete("webhook_subscription_event").Where("subscription_id = ?", subscriptionID)); err != nil {
+			return errors.WithStack(err)
+		}
+		res, err := db.execWith(ctx, tx, sq.Delete("webhook_subscription").Where("id = ?", subscriptionID))
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		if affected, err := res.RowsAffected(); err != nil {
+			return errors.WithStack(err)
+		} else if affected == 0 {
+			return exception.WebhookNotFound
+		}
+		return nil
+	})
+}
+
+func (db *db) CreateDelivery(ctx context.Context, d *webhook.Delivery) (int64, error) {
+	builder := sq.Insert("webhook_delivery").
+		Columns("subscription_id", "event_type", "payload", "status", "attempts", "next_attempt_at", "created_at").
+		Values(d.SubscriptionID, string(d.EventType), string(d.Payload), string(d.Status), d.Attempts, utcOrNil(d.NextAttemptAt), utc(d.CreatedAt))
+	res, err := db.Exec(ctx, builder)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	id, err := res.LastInsertId()
+	return id, errors.WithStack(err)
+}
+
+func (db *db) FindDelivery(ctx context.Context, deliveryID int64) (*webhook.Delivery, error) {
+	dto := dtoDelivery{}
+	if err := db.Get(ctx, &dto, selectDelivery().Where("id = ?", deliveryID)); err == sql.ErrNoRows {
+		return nil, exception.DeliveryNotFound
+	} else if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return convertDelivery(&dto), nil
+}
+
+func (db *db) ListDeliveries(ctx context.Context, subscriptionID int64, status webhook.DeliveryStatus) ([]*webhook.Delivery, error) {
+	builder := selectDelivery().Where("subscription_id = ?", subscriptionID).OrderBy("id DESC")
+	if status != "" {
+		builder = builder.Where("status = ?", string(status))
+	}
+	return db.deliveries(ctx, builder)
+}
+
+func (db *db) DueDeliveries(ctx context.Context, now time.Time, limit int) ([]*webhook.Delivery, error) {
+	builder := selectDelivery().
+		Where("status = ? AND next_attempt_at <= ?", string(webhook.DeliveryPending), utc(now)).
+		OrderBy("next_attempt_at", "id").
+		Limit(uint64(limit))
+	return db.deliveries(ctx, builder)
+}
+
+func (db *db) deliveries(ctx context.Context, builder sq.SelectBuilder) ([]*webhook.Delivery, error) {
+	list := []*dtoDelivery{}
+	if err := db.Select(ctx, &list, builder); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	deliveries := make([]*webhook.Delivery, len(list))
+	for i, d := range list {
+		deliveries[i] = convertDelivery(d)
+	}
+	return deliveries, nil
+}
+
+func (db *db) UpdateDelivery(ctx context.Context, d *webhook.Delivery) error {
+	builder := sq.Update("webhook_delivery").
+		Set("status", string(d.Status)).
+		Set("attempts", d.Attempts).
+		Set("response_status", nullInt(d.ResponseStatus)).
+		Set("last_error", d.LastError).
+		Set("next_attempt_at", utcOrNil(d.NextAttemptAt)).
+		Set("delivered_at", utcOrNil(d.DeliveredAt)).
+		Where("id = ?", d.ID)
+
+	_, err := db.Exec(ctx, builder)
+	return errors.WithStack(err)
+}
+
+func utcOrNil(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return utc(*t)
+}
+
+func nullInt(v int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(v), Valid: v != 0}
+}
+
+func selectSubscription() sq.SelectBuilder {
+	return sq.Select("id", "url", "secret", "item_id", "created_by", "created_at").
+		From("webhook_subscription")
+}
+
+func selectDelivery() sq.SelectBuilder {
+	return sq.Select(
+		"id",
+		"subscription_id",
+		"event_type",
+		"payload",
+		"status",
+		"attempts",
+		"response_status",
+		"last_error",
+		"next_attempt_at",
+		"created_at",
+		"delivered_at",
+	).
+		From("webhook_delivery")
+}
+
+type dtoSubscription struct {
+	ID        int64         `db:"id"`
+	URL       string        `db:"url"`
+	Secret    string        `db:"secret"`
+	RoomID    sql.NullInt64 `db:"item_id"`
+	CreatedBy string        `db:"created_by"`
+	CreatedAt time.Time     `db:"created_at"`
+}
+
+func convertSubscription(s *dtoSubscription, events []reservation.EventType) *webhook.Subscription {
+	subscription := &webhook.Subscription{
+		ID:        s.ID,
+		URL:       s.URL,
+		Secret:    s.Secret,
+		Events:    events,
+		CreatedBy: s.CreatedBy,
+		CreatedAt: s.CreatedAt,
+	}
+	if subscription.Events == nil {
+		subscription.Events = []reservation.EventType{}
+	}
+	if s.RoomID.Valid {
+		subscription.RoomID = &s.RoomID.Int64
+	}
+	return subscription
+}
+
+type dtoDelivery struct {
+	ID             int64          `db:"id"`
+	SubscriptionID int64          `db:"subscription_id"`
+	EventType      string         `db:"event_type"`
+	Payload        string         `db:"payload"`
+	Status         string         `db:"status"`
+	Attempts       int            `db:"attempts"`
+	ResponseStatus sql.NullInt64  `db:"response_status"`
+	LastError      sql.NullString `db:"last_error"`
+	NextAttemptAt  *time.Time     `db:"next_attempt_at"`
+	CreatedAt      time.Time      `db:"created_at"`
+	DeliveredAt    *time.Time     `db:"delivered_at"`
+}
+
+func convertDelivery(d *dtoDelivery) *webhook.Delivery {
+	return &webhook.Delivery{
+		ID:             d.ID,
+		SubscriptionID: d.SubscriptionID,
+		EventType:      reservation.EventType(d.EventType),
+		Payload:        []byte(d.Payload),
+		Status:         webhook.DeliveryStatus(d.Status),
+		Attempts:       d.Attempts,
+		ResponseStatus: int(d.ResponseStatus.Int64),
+		LastError:      d.LastError.String,
+		NextAttemptAt:  d.NextAttemptAt,
+		CreatedAt:      d.CreatedAt,
+		DeliveredAt:    d.DeliveredAt,
+	}
+}
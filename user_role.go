@@ -0,0 +1,25 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/rutesun/reservation/account"
+)
+
+// runUserRole 은 사용자의 역할과 group 을 바꿈. 첫 관리자를 만들 때 사용
+//
+//	user-role <name> <admin|facility_manager|member|guest> [group...]
+func runUserRole(s *account.Service, args []string) error {
+	if len(args) < 2 {
+		return errors.New("사용법: user-role <name> <admin|facility_manager|member|guest> [group...]")
+	}
+
+	user, err := s.Update(context.Background(), args[0], account.Role(args[1]), args[2:])
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s: role=%s groups=%v\n", user.Name, user.Role, user.Groups)
+	return nil
+}
@@ -0,0 +1,51 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// sendTimeout 은 한번 보내는 데 허용하는 시간
+const sendTimeout = 10 * time.Second
+
+type sender struct {
+	client *http.Client
+}
+
+func newSender() *sender {
+	return &sender{client: &http.Client{Timeout: sendTimeout}}
+}
+
+// send 는 delivery 의 payload 를 서명하여 POST 하고 응답 상태를 반환. 2xx 가 아니면 error
+func (s *sender) send(ctx context.Context, subscription *Subscription, delivery *Delivery) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, subscription.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "reservation-webhook")
+	req.Header.Set("X-Webhook-Id", strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set("X-Webhook-Event", string(delivery.EventType))
+	req.Header.Set("X-Webhook-Timestamp", strconv.FormatInt(timestamp, 10))
+	req.Header.Set("X-Webhook-Signature", Sign(subscription.Secret, timestamp, delivery.Payload))
+
+	res, err := s.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	defer res.Body.Close()
+	io.Copy(ioutil.Discard, io.LimitReader(res.Body, 64<<10))
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return res.StatusCode, errors.Errorf("%d 로 응답했습니다", res.StatusCode)
+	}
+	return res.StatusCode, nil
+}
@@ -0,0 +1,430 @@
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rutesun/reservation/account"
+	"github.com/rutesun/reservation/exception"
+	"github.com/rutesun/reservation/log"
+	"github.com/rutesun/reservation/reservation"
+)
+
+const (
+	// DefaultAttempts 는 전달마다 보내는 최대 횟수
+	DefaultAttempts = 6
+	// DefaultBackoff 는 첫 재시도까지 기다리는 시간이며 재시도마다 두배 (10s, 20s, 40s ...)
+	DefaultBackoff = 10 * time.Second
+
+	// dueLimit 은 한번에 보내는 전달 수
+	dueLimit = 100
+)
+
+// DeliveryStatus 는 webhook 전달 상태
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliverySucceeded DeliveryStatus = "succeeded"
+	DeliveryFailed    DeliveryStatus = "failed"
+)
+
+func (s DeliveryStatus) valid() bool {
+	switch s {
+	case DeliveryPending, DeliverySucceeded, DeliveryFailed:
+		return true
+	}
+	return false
+}
+
+// Subscription 은 관리자가 등록한 webhook
+// Events 가 비어 있으면 모든 Event, RoomID 가 없으면 모든 회의실의 Event 를 보냄
+// Secret 은 등록할 때만 응답
+type Subscription struct {
+	ID        int64                   `json:"id"`
+	URL       string                  `json:"url"`
+	Secret    string                  `json:"secret,omitempty"`
+	Events    []reservation.EventType `json:"events"`
+	RoomID    *int64                  `json:"roomId,omitempty"`
+	CreatedBy string                  `json:"createdBy"`
+	CreatedAt time.Time               `json:"createdAt"`
+}
+
+// Match 는 event 를 보내야 하는 webhook 인지 확인
+func (s *Subscription) Match(event reservation.Event) bool {
+	if s.RoomID != nil && *s.RoomID != event.Reservation.Room.ID {
+		return false
+	}
+	if len(s.Events) == 0 {
+		return true
+	}
+	for _, t := range s.Events {
+		if t == event.Type {
+			return true
+		}
+	}
+	return false
+}
+
+// Delivery 는 webhook 전달 기록. Payload 는 보낸(보낼) body 그대로이며 다시 보내도 바뀌지 않음
+type Delivery struct {
+	ID             int64                 `json:"id"`
+	SubscriptionID int64                 `json:"subscriptionId"`
+	EventType      reservation.EventType `json:"eventType"`
+	Payload        json.RawMessage       `json:"payload"`
+	Status         DeliveryStatus        `json:"status"`
+	Attempts       int                   `json:"attempts"`
+	ResponseStatus int                   `json:"responseStatus,omitempty"`
+	LastError      string                `json:"lastError,omitempty"`
+	NextAttemptAt  *time.Time            `json:"nextAttemptAt,omitempty"`
+	CreatedAt      time.Time             `json:"createdAt"`
+	DeliveredAt    *time.Time            `json:"deliveredAt,omitempty"`
+}
+
+// payload 는 webhook 으로 보내는 JSON
+type payload struct {
+	Type        reservation.EventType `json:"type"`
+	Reservation *reservation.Detail   `json:"reservation"`
+	Occurrences int                   `json:"occurrences,omitempty"`
+	At          time.Time             `json:"at"`
+}
+
+type webhookRepository interface {
+	// CreateSubscription 은 없거나 보관된 회의실이면 exception.RoomNotFound
+	CreateSubscription(ctx context.Context, subscription *Subscription) (int64, error)
+	FindSubscription(ctx context.Context, subscriptionID int64) (*Subscription, error)
+	ListSubscriptions(ctx context.Context) ([]*Subscription, error)
+	// DeleteSubscription 은 전달 기록도 함께 지움
+	DeleteSubscription(ctx context.Context, subscriptionID int64) error
+
+	CreateDelivery(ctx context.Context, delivery *Delivery) (int64, error)
+	FindDelivery(ctx context.Context, deliveryID int64) (*Delivery, error)
+	// ListDeliveries 는 최근 전달부터, status 가 비어 있으면 모든 상태
+	ListDeliveries(ctx context.Context, subscriptionID int64, status DeliveryStatus) ([]*Delivery, error)
+	// DueDeliveries 는 next_attempt_at 이 now 이전인 pending 전달을 오래된 순서로 limit 개
+	DueDeliveries(ctx context.Context, now time.Time, limit int) ([]*Delivery, error)
+	// UpdateDelivery 는 전달 결과(status, attempts, response_status, last_error, next_attempt_at, delivered_at) 를 저장
+	UpdateDelivery(ctx context.Context, delivery *Delivery) error
+}
+
+type Service struct {
+	webhook webhookRepository
+	sender  *sender
+	// attempts 는 전달마다 보내는 최대 횟수, backoff 는 첫 재시도까지 기다리는 시간
+	attempts int
+	backoff  time.Duration
+	// wake 는 새 전달이 생겼음을 Watch 에 알림
+	wake chan struct{}
+}
+
+// New 는 attempts, backoff 가 0 이하이면 DefaultAttempts, DefaultBackoff 를 사용
+func New(webhook webhookRepository, attempts int, backoff time.Duration) *Service {
+	if attempts <= 0 {
+		attempts = DefaultAttempts
+	}
+	if backoff <= 0 {
+		backoff = DefaultBackoff
+	}
+	return &Service{
+		webhook:  webhook,
+		sender:   newSender(),
+		attempts: attempts,
+		backoff:  backoff,
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+// authorize 는 관리자만 webhook 을 관리할 수 있도록 확인. 요청한 사용자가 없는 내부 호출은 확인하지 않음
+func authorize(ctx context.Context) error {
+	if user, ok := account.FromContext(ctx); ok && user.Role != account.Admin {
+		return errors.WithStack(exception.Forbidden.WithDetail("관리자만 webhook 을 관리할 수 있습니다"))
+	}
+	return nil
+}
+
+// Subscribe 는 webhook 을 등록. secret 이 없으면 만들어 응답하며 이후에는 응답하지 않음
+func (s *Service) Subscribe(ctx context.Context, rawURL, secret string, events []string, roomID *int64) (*Subscription, error) {
+	if err := authorize(ctx); err != nil {
+		return nil, err
+	}
+	if u, err := url.Parse(rawURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, errors.WithStack(exception.Invalid("url", "http, https 주소여야 합니다: %s", rawURL))
+	}
+	eventTypes, err := parseEvents(events)
+	if err != nil {
+		return nil, err
+	}
+	if secret == "" {
+		b := make([]byte, 32)
+		if _, err := rand.Read(b); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		secret = hex.EncodeToString(b)
+	}
+
+	subscription := &Subscription{
+		URL:       rawURL,
+		Secret:    secret,
+		Events:    eventTypes,
+		RoomID:    roomID,
+		CreatedAt: time.Now(),
+	}
+	if user, ok := account.FromContext(ctx); ok {
+		subscription.CreatedBy = user.Name
+	}
+	if subscription.ID, err = s.webhook.CreateSubscription(ctx, subscription); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return subscription, nil
+}
+
+// parseEvents 는 쉼표로 구분된 Event 종류도 받으며 중복을 제거하여 정렬
+func parseEvents(events []string) ([]reservation.EventType, error) {
+	seen := make(map[reservation.EventType]bool)
+	eventTypes := []reservation.EventType{}
+	for _, e := range events {
+		for _, name := range strings.Split(e, ",") {
+			t := reservation.EventType(strings.TrimSpace(name))
+			if t == "" || seen[t] {
+				continue
+			}
+			if !knownEvent(t) {
+				return nil, errors.WithStack(exception.Invalid("events", "알 수 없는 event 입니다: %s", t))
+			}
+			seen[t] = true
+			eventTypes = append(eventTypes, t)
+		}
+	}
+	sort.Slice(eventTypes, func(i, j int) bool { return eventTypes[i] < eventTypes[j] })
+	return eventTypes, nil
+}
+
+func knownEvent(t reservation.EventType) bool {
+	for _, known := range reservation.EventTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Service) Subscriptions(ctx context.Context) ([]*Subscription, error) {
+	if err := authorize(ctx); err != nil {
+		return nil, err
+	}
+	list, err := s.webhook.ListSubscriptions(ctx)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	for _, subscription := range list {
+		subscription.Secret = ""
+	}
+	return list, nil
+}
+
+func (s *Service) FindSubscription(ctx context.Context, subscriptionID int64) (*Subscription, error) {
+	if err := authorize(ctx); err != nil {
+		return nil, err
+	}
+	subscription, err := s.webhook.FindSubscription(ctx, subscriptionID)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	subscription.Secret = ""
+	return subscription, nil
+}
+
+// Unsubscribe 는 webhook 과 전달 기록을 지우며 아직 보내지 않은 전달도 보내지 않음
+func (s *Service) Unsubscribe(ctx context.Context, subscriptionID int64) error {
+	if err := authorize(ctx); err != nil {
+		return err
+	}
+	return errors.WithStack(s.webhook.DeleteSubscription(ctx, subscriptionID))
+}
+
+// Deliveries 는 webhook 의 전달 기록을 최근 순서로 조회. status 가 비어 있으면 모든 상태
+func (s *Service) Deliveries(ctx context.Context, subscriptionID int64, status string) ([]*Delivery, error) {
+	if err := authorize(ctx); err != nil {
+		return nil, err
+	}
+	deliveryStatus := DeliveryStatus(status)
+	if status != "" && !deliveryStatus.valid() {
+		return nil, errors.WithStack(exception.Invalid("status", "status 는 pending, succeeded, failed 중 하나입니다"))
+	}
+	if _, err := s.webhook.FindSubscription(ctx, subscriptionID); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	list, err := s.webhook.ListDeliveries(ctx, subscriptionID, deliveryStatus)
+	return list, errors.WithStack(err)
+}
+
+// FindDelivery 는 webhook 의 전달 기록이 아니면 exception.DeliveryNotFound
+func (s *Service) FindDelivery(ctx context.Context, subscriptionID, deliveryID int64) (*Delivery, error) {
+	if err := authorize(ctx); err != nil {
+		return nil, err
+	}
+	delivery, err := s.webhook.FindDelivery(ctx, deliveryID)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if delivery.SubscriptionID != subscriptionID {
+		return nil, errors.WithStack(exception.DeliveryNotFound)
+	}
+	return delivery, nil
+}
+
+// Replay 는 전달 기록과 같은 payload 를 새 전달로 다시 보냄. 기존 전달 기록은 그대로 둠
+func (s *Service) Replay(ctx context.Context, subscriptionID, deliveryID int64) (*Delivery, error) {
+	delivery, err := s.FindDelivery(ctx, subscriptionID, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	replay := &Delivery{
+		SubscriptionID: delivery.SubscriptionID,
+		EventType:      delivery.EventType,
+		Payload:        delivery.Payload,
+		Status:         DeliveryPending,
+		NextAttemptAt:  &now,
+		CreatedAt:      now,
+	}
+	if replay.ID, err = s.webhook.CreateDelivery(ctx, replay); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	s.signal()
+	return replay, nil
+}
+
+// Notify 는 event 와 맞는 webhook 마다 전달을 저장만 하고 Watch 가 따로 보냄
+// 저장한 뒤 응답하므로 서버가 멈춰도 전달은 남아 있음
+func (s *Service) Notify(ctx context.Context, event reservation.Event) {
+	subscriptions, err := s.webhook.ListSubscriptions(ctx)
+	if err != nil {
+		log.Errorf("%s webhook 을 조회하지 못했습니다: %+v", event.Type, err)
+		return
+	}
+
+	var body []byte
+	now := time.Now()
+	for _, subscription := range subscriptions {
+		if !subscription.Match(event) {
+			continue
+		}
+		if body == nil {
+			if body, err = json.Marshal(payload{Type: event.Type, Reservation: event.Reservation, Occurrences: event.Occurrences, At: event.At}); err != nil {
+				log.Errorf("%s webhook payload 를 만들지 못했습니다: %+v", event.Type, err)
+				return
+			}
+		}
+		delivery := &Delivery{
+			SubscriptionID: subscription.ID,
+			EventType:      event.Type,
+			Payload:        body,
+			Status:         DeliveryPending,
+			NextAttemptAt:  &now,
+			CreatedAt:      now,
+		}
+		if _, err := s.webhook.CreateDelivery(ctx, delivery); err != nil {
+			log.Errorf("webhook #%d 의 %s 전달을 저장하지 못했습니다: %+v", subscription.ID, event.Type, err)
+		}
+	}
+	if body != nil {
+		s.signal()
+	}
+}
+
+func (s *Service) signal() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Deliver 는 now 까지 보내야 하는 전달을 보내고 보낸 수를 반환
+// 실패하면 backoff 뒤 다시 보내며 attempts 번 실패하면 failed
+func (s *Service) Deliver(ctx context.Context, now time.Time) (int, error) {
+	due, err := s.webhook.DueDeliveries(ctx, now, dueLimit)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+
+	subscriptions := make(map[int64]*Subscription)
+	for _, delivery := range due {
+		subscription, ok := subscriptions[delivery.SubscriptionID]
+		if !ok {
+			if subscription, err = s.webhook.FindSubscription(ctx, delivery.SubscriptionID); errors.Cause(err) == exception.WebhookNotFound {
+				// 보내는 사이 webhook 을 지운 경우
+				continue
+			} else if err != nil {
+				return 0, errors.WithStack(err)
+			}
+			subscriptions[delivery.SubscriptionID] = subscription
+		}
+
+		status, err := s.sender.send(ctx, subscription, delivery)
+		s.record(delivery, status, err, time.Now())
+		if err := s.webhook.UpdateDelivery(ctx, delivery); err != nil {
+			return 0, errors.WithStack(err)
+		}
+	}
+	return len(due), nil
+}
+
+// record 는 보낸 결과를 delivery 에 기록
+func (s *Service) record(delivery *Delivery, status int, err error, now time.Time) {
+	delivery.Attempts++
+	delivery.ResponseStatus = status
+	if err == nil {
+		delivery.Status, delivery.LastError = DeliverySucceeded, ""
+		delivery.NextAttemptAt, delivery.DeliveredAt = nil, &now
+		return
+	}
+
+	delivery.LastError = err.Error()
+	if delivery.Attempts >= s.attempts {
+		delivery.Status, delivery.NextAttemptAt = DeliveryFailed, nil
+		log.Warnf("webhook #%d 에 전달 #%d 를 %d 번 보내지 못했습니다: %v", delivery.SubscriptionID, delivery.ID, delivery.Attempts, err)
+		return
+	}
+	next := now.Add(s.backoff << uint(delivery.Attempts-1))
+	delivery.NextAttemptAt = &next
+}
+
+// Watch 는 interval 마다, 그리고 새 전달이 생기면 바로 전달을 보냄. ctx 가 취소되면 멈춤
+func (s *Service) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.Deliver(ctx, time.Now()); err != nil {
+			log.Errorf("webhook 전달 실패: %+v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+	}
+}
+
+// Sign 은 timestamp(unix 초) 와 body 를 secret 으로 서명한 값 (HMAC-SHA256, hex)
+// 받는 쪽은 X-Webhook-Timestamp 와 body 로 같은 값을 만들어 X-Webhook-Signature 와 비교
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
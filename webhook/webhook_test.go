@@ -0,0 +1,76 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rutesun/reservation/exception"
+	"github.com/rutesun/reservation/reservation"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSign(t *testing.T) {
+	body := []byte(`{"type":"reservation.created"}`)
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte("1700000000." + string(body)))
+
+	assert.Equal(t, "sha256="+hex.EncodeToString(mac.Sum(nil)), Sign("secret", 1700000000, body))
+	assert.NotEqual(t, Sign("secret", 1700000000, body), Sign("secret", 1700000001, body), "timestamp 도 서명")
+}
+
+func TestSubscription_Match(t *testing.T) {
+	assert := assert.New(t)
+
+	roomID := int64(1)
+	event := func(eventType reservation.EventType, roomID int64) reservation.Event {
+		return reservation.Event{Type: eventType, Reservation: &reservation.Detail{Room: reservation.Room{ID: roomID}}}
+	}
+
+	all := &Subscription{}
+	assert.True(all.Match(event(reservation.EventNoShow, 2)))
+
+	filtered := &Subscription{Events: []reservation.EventType{reservation.EventCreated}, RoomID: &roomID}
+	assert.True(filtered.Match(event(reservation.EventCreated, 1)))
+	assert.False(filtered.Match(event(reservation.EventCreated, 2)))
+	assert.False(filtered.Match(event(reservation.EventCancelled, 1)))
+}
+
+func TestParseEvents(t *testing.T) {
+	events, err := parseEvents([]string{"reservation.modified, reservation.created", "reservation.created"})
+	assert.NoError(t, err)
+	assert.Equal(t, []reservation.EventType{reservation.EventCreated, reservation.EventModified}, events)
+
+	_, err = parseEvents([]string{"reservation.deleted"})
+	assert.Equal(t, exception.InvalidRequest, errors.Cause(err))
+}
+
+func TestService_Record(t *testing.T) {
+	assert := assert.New(t)
+
+	s := New(nil, 3, time.Second)
+	now := time.Now()
+	d := &Delivery{Status: DeliveryPending}
+
+	s.record(d, 500, errors.New("500 로 응답했습니다"), now)
+	assert.Equal(DeliveryPending, d.Status)
+	assert.Equal(now.Add(time.Second), *d.NextAttemptAt)
+
+	s.record(d, 0, errors.New("timeout"), now)
+	assert.Equal(now.Add(2*time.Second), *d.NextAttemptAt, "재시도마다 두배")
+	assert.Equal("timeout", d.LastError)
+
+	s.record(d, 500, errors.New("500 로 응답했습니다"), now)
+	assert.Equal(DeliveryFailed, d.Status)
+	assert.Nil(d.NextAttemptAt)
+	assert.Equal(3, d.Attempts)
+
+	d = &Delivery{Status: DeliveryPending}
+	s.record(d, 204, nil, now)
+	assert.Equal(DeliverySucceeded, d.Status)
+	assert.Equal(now, *d.DeliveredAt)
+	assert.Nil(d.NextAttemptAt)
+}